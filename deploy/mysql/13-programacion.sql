@@ -0,0 +1,8 @@
+USE vigo360;
+
+-- Momento en el que se notificó la publicación a los buscadores. Las publicaciones con fecha de publicación pasada
+-- y sin notificar las recoge el programador de publicaciones.
+ALTER TABLE publicaciones ADD COLUMN fecha_notificacion DATETIME DEFAULT NULL;
+
+-- Las publicaciones ya existentes se consideran notificadas
+UPDATE publicaciones SET fecha_notificacion = fecha_publicacion WHERE fecha_publicacion IS NOT NULL AND fecha_publicacion <= NOW();
@@ -0,0 +1,27 @@
+USE vigo360;
+
+CREATE TABLE revisiones(
+    id INT NOT NULL AUTO_INCREMENT,
+    tipo ENUM("publicacion", "trabajo") NOT NULL,
+    entidad_id VARCHAR(40) NOT NULL,
+
+    titulo VARCHAR(80) NOT NULL,
+    resumen VARCHAR(300) NOT NULL,
+    contenido TEXT NOT NULL,
+    alt_portada VARCHAR(300) NOT NULL,
+
+    autor_id VARCHAR(40) NOT NULL,
+    fecha DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    INDEX (tipo, entidad_id),
+    FOREIGN KEY (autor_id) REFERENCES autores(id)
+);
+
+-- El estado actual de cada publicación y trabajo es su primera revisión
+INSERT INTO revisiones (tipo, entidad_id, titulo, resumen, contenido, alt_portada, autor_id, fecha)
+SELECT "publicacion", id, titulo, resumen, contenido, alt_portada, autor_id, COALESCE(fecha_actualizacion, NOW())
+FROM publicaciones;
+
+INSERT INTO revisiones (tipo, entidad_id, titulo, resumen, contenido, alt_portada, autor_id, fecha)
+SELECT "trabajo", id, titulo, resumen, contenido, alt_portada, autor_id, fecha_actualizacion
+FROM trabajos;
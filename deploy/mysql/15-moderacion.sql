@@ -0,0 +1,34 @@
+USE vigo360;
+
+ALTER TABLE comentarios ADD COLUMN spam BOOLEAN NOT NULL DEFAULT false;
+
+-- Registro de cada decisión o edición de un moderador sobre un comentario
+CREATE TABLE comentarios_moderacion(
+    id INT NOT NULL AUTO_INCREMENT,
+    comentario_id VARCHAR(13) NOT NULL,
+    moderador VARCHAR(40) NOT NULL,
+    accion ENUM("aprobar", "rechazar", "spam", "revertir", "editar") NOT NULL,
+    contenido_anterior TEXT,
+    fecha DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    INDEX (comentario_id),
+    FOREIGN KEY (comentario_id) REFERENCES comentarios(id) ON DELETE CASCADE,
+    FOREIGN KEY (moderador) REFERENCES autores(id)
+);
+
+CREATE OR REPLACE VIEW vigo360.comment_moderation AS
+SELECT c.id,
+       c.publicacion_id,
+       p.titulo                         as publicacion_titulo,
+       COALESCE(padre_id, '')           as padre_id,
+       c.nombre,
+       c.es_autor,
+       c.autor_original,
+       c.contenido,
+       c.fecha_creacion,
+       COALESCE(c.fecha_moderacion, '') as fecha_moderacion,
+       c.estado + 0                     as estado,
+       COALESCE(c.moderador, '')        as moderador,
+       c.spam
+FROM comentarios c
+         LEFT JOIN publicaciones p ON c.publicacion_id = p.id;
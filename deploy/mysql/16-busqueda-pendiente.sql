@@ -0,0 +1,27 @@
+USE vigo360;
+
+-- Publicaciones cuyo estado hay que enviar al índice de búsqueda. El sincronizador decide si subirlas o
+-- eliminarlas según su estado en el momento de enviarlas.
+CREATE TABLE busqueda_pendiente(
+    publicacion_id VARCHAR(40) NOT NULL,
+    -- Aumenta con cada cambio, para no descartar uno que llegue mientras se envía el anterior
+    cambios INT NOT NULL DEFAULT 0,
+    intentos INT NOT NULL DEFAULT 0,
+    siguiente_intento DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_error TEXT,
+    PRIMARY KEY (publicacion_id),
+    INDEX (siguiente_intento)
+);
+
+-- Cualquier cambio en una publicación, incluida la retirada legal desde la base de datos, se sincroniza
+CREATE TRIGGER publicaciones_busqueda_update AFTER UPDATE ON publicaciones FOR EACH ROW
+INSERT INTO busqueda_pendiente (publicacion_id) VALUES (NEW.id)
+ON DUPLICATE KEY UPDATE cambios=cambios+1, intentos=0, siguiente_intento=NOW(), ultimo_error=NULL;
+
+CREATE TRIGGER publicaciones_busqueda_delete AFTER DELETE ON publicaciones FOR EACH ROW
+INSERT INTO busqueda_pendiente (publicacion_id) VALUES (OLD.id)
+ON DUPLICATE KEY UPDATE cambios=cambios+1, intentos=0, siguiente_intento=NOW(), ultimo_error=NULL;
+
+-- Carga inicial del índice con todas las publicaciones
+INSERT INTO busqueda_pendiente (publicacion_id)
+SELECT id FROM publicaciones;
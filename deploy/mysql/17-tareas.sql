@@ -0,0 +1,40 @@
+USE vigo360;
+
+-- Cola persistente de tareas en segundo plano (IndexNow, portadas, índice de búsqueda)
+CREATE TABLE tareas(
+    id INT NOT NULL AUTO_INCREMENT,
+    tipo VARCHAR(40) NOT NULL,
+    carga TEXT NOT NULL,
+
+    estado ENUM("pendiente", "en_curso", "completada", "fallida") NOT NULL DEFAULT "pendiente",
+    intentos INT NOT NULL DEFAULT 0,
+    max_intentos INT NOT NULL DEFAULT 8,
+    siguiente_intento DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reclamada_en DATETIME,
+    ultimo_error TEXT,
+
+    fecha_creacion DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    fecha_actualizacion DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    INDEX (estado, siguiente_intento)
+);
+
+-- La sincronización con el índice de búsqueda pasa a ser una tarea más
+DROP TRIGGER publicaciones_busqueda_update;
+DROP TRIGGER publicaciones_busqueda_delete;
+
+-- La carga es TEXT, así que se compara el id extraído y no el JSON entero, que nunca sería igual a un texto
+CREATE TRIGGER publicaciones_busqueda_update AFTER UPDATE ON publicaciones FOR EACH ROW
+INSERT INTO tareas (tipo, carga)
+SELECT "busqueda", JSON_OBJECT("publicacion_id", NEW.id) FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM tareas WHERE tipo = "busqueda" AND estado = "pendiente" AND JSON_UNQUOTE(JSON_EXTRACT(carga, "$.publicacion_id")) = NEW.id);
+
+CREATE TRIGGER publicaciones_busqueda_delete AFTER DELETE ON publicaciones FOR EACH ROW
+INSERT INTO tareas (tipo, carga)
+SELECT "busqueda", JSON_OBJECT("publicacion_id", OLD.id) FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM tareas WHERE tipo = "busqueda" AND estado = "pendiente" AND JSON_UNQUOTE(JSON_EXTRACT(carga, "$.publicacion_id")) = OLD.id);
+
+INSERT INTO tareas (tipo, carga)
+SELECT "busqueda", JSON_OBJECT("publicacion_id", publicacion_id) FROM busqueda_pendiente;
+
+DROP TABLE busqueda_pendiente;
@@ -0,0 +1,20 @@
+USE vigo360;
+
+-- Mediateca: imágenes extra que se pueden insertar en cualquier publicación o trabajo
+CREATE TABLE media(
+    id INT NOT NULL AUTO_INCREMENT,
+    nombre_archivo VARCHAR(100) NOT NULL UNIQUE,
+    alt VARCHAR(300) NOT NULL DEFAULT '',
+    leyenda VARCHAR(300) NOT NULL DEFAULT '',
+    ancho INT NOT NULL,
+    alto INT NOT NULL,
+    bytes INT NOT NULL,
+    -- Publicación desde la que se subió la imagen, si la hay
+    publicacion_id VARCHAR(40) DEFAULT NULL,
+    autor_id VARCHAR(40) DEFAULT NULL,
+    fecha_subida DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    INDEX (publicacion_id),
+    FOREIGN KEY (publicacion_id) REFERENCES publicaciones(id) ON DELETE SET NULL,
+    FOREIGN KEY (autor_id) REFERENCES autores(id) ON DELETE SET NULL
+);
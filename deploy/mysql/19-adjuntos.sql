@@ -0,0 +1,7 @@
+USE vigo360;
+
+ALTER TABLE adjuntos MODIFY nombre_archivo VARCHAR(100) NOT NULL;
+ALTER TABLE adjuntos ADD COLUMN bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE adjuntos ADD COLUMN mime VARCHAR(100) NOT NULL DEFAULT 'application/octet-stream';
+-- Suma SHA-256 en hexadecimal, vacía para los adjuntos subidos antes de esta migración
+ALTER TABLE adjuntos ADD COLUMN sha256 CHAR(64) NOT NULL DEFAULT '';
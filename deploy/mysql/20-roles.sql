@@ -0,0 +1,52 @@
+USE vigo360;
+
+/* Permisos que comprueba cada ruta del panel */
+INSERT INTO permisos (id, comentario) VALUES
+	("publicaciones_editar", "Crear y editar publicaciones y su mediateca"),
+	("trabajos_editar", "Crear y editar trabajos y sus adjuntos"),
+	("series_editar", "Crear y editar series"),
+	("comentarios_moderar", "Moderar y editar comentarios"),
+	("tareas_gestionar", "Ver y reintentar tareas en segundo plano"),
+	("permisos_gestionar", "Gestionar roles y asignarlos a autores");
+
+/* Un rol agrupa permisos. Un autor tiene los permisos de todos sus roles, más los asignados directamente */
+CREATE TABLE roles (
+	id varchar(40) NOT NULL,
+	nombre varchar(80) NOT NULL,
+	descripcion varchar(255) NOT NULL DEFAULT '',
+	PRIMARY KEY (id),
+	CHECK (id != "")
+);
+
+CREATE TABLE roles_permisos (
+	rol_id varchar(40) NOT NULL,
+	permiso_id varchar(50) NOT NULL,
+	PRIMARY KEY (rol_id, permiso_id),
+	FOREIGN KEY (rol_id) REFERENCES roles(id) ON DELETE CASCADE,
+	FOREIGN KEY (permiso_id) REFERENCES permisos(id) ON DELETE CASCADE
+);
+
+CREATE TABLE roles_autores (
+	rol_id varchar(40) NOT NULL,
+	autor_id varchar(40) NOT NULL,
+	PRIMARY KEY (rol_id, autor_id),
+	FOREIGN KEY (rol_id) REFERENCES roles(id) ON DELETE CASCADE,
+	FOREIGN KEY (autor_id) REFERENCES autores(id) ON DELETE CASCADE
+);
+
+INSERT INTO roles (id, nombre, descripcion) VALUES
+	("admin", "Administración", "Todos los permisos"),
+	("editor", "Edición", "Publicaciones, trabajos y series, sin moderar ni eliminar"),
+	("moderador", "Moderación", "Moderar comentarios");
+
+INSERT INTO roles_permisos (rol_id, permiso_id) SELECT "admin", id FROM permisos;
+INSERT INTO roles_permisos (rol_id, permiso_id) VALUES
+	("editor", "publicaciones_editar"),
+	("editor", "trabajos_editar"),
+	("editor", "series_editar"),
+	("moderador", "comentarios_moderar");
+
+/* Hasta ahora cualquier autor podía editar y moderar, y solo quien tenía publicaciones_delete podía eliminar */
+INSERT INTO roles_autores (rol_id, autor_id) SELECT "editor", id FROM autores;
+INSERT INTO roles_autores (rol_id, autor_id) SELECT "moderador", id FROM autores;
+INSERT INTO roles_autores (rol_id, autor_id) SELECT "admin", autor_id FROM permisos_usuarios WHERE permiso_id = "publicaciones_delete";
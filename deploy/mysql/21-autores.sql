@@ -0,0 +1,20 @@
+USE vigo360;
+
+/* Un autor desactivado no puede iniciar sesión, pero sus publicaciones siguen visibles */
+ALTER TABLE autores ADD COLUMN activo BOOLEAN NOT NULL DEFAULT true;
+
+/* Enlaces de un solo uso para aceptar una invitación o restablecer la contraseña. Solo se guarda el hash del token */
+CREATE TABLE autores_tokens (
+	token_hash CHAR(64) NOT NULL,
+	autor_id VARCHAR(40) NOT NULL,
+	tipo ENUM("invitacion", "restablecer") NOT NULL,
+	creado DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	caduca DATETIME NOT NULL,
+	usado DATETIME DEFAULT NULL,
+	PRIMARY KEY (token_hash),
+	INDEX (autor_id),
+	FOREIGN KEY (autor_id) REFERENCES autores(id) ON DELETE CASCADE
+);
+
+INSERT INTO permisos (id, comentario) VALUES ("autores_gestionar", "Crear, invitar y desactivar autores");
+INSERT INTO roles_permisos (rol_id, permiso_id) VALUES ("admin", "autores_gestionar");
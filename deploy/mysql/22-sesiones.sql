@@ -0,0 +1,11 @@
+USE vigo360;
+
+/* El sessid es el valor de la cookie, así que las sesiones se identifican en el panel por un id numérico */
+ALTER TABLE sesiones ADD COLUMN id INT NOT NULL AUTO_INCREMENT UNIQUE;
+ALTER TABLE sesiones ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE sesiones ADD COLUMN ip VARCHAR(45) NOT NULL DEFAULT '';
+ALTER TABLE sesiones ADD COLUMN ultimo_uso DATETIME DEFAULT NULL;
+UPDATE sesiones SET ultimo_uso = iniciada;
+
+INSERT INTO permisos (id, comentario) VALUES ("sesiones_gestionar", "Ver y revocar las sesiones de cualquier autor");
+INSERT INTO roles_permisos (rol_id, permiso_id) VALUES ("admin", "sesiones_gestionar");
@@ -0,0 +1,31 @@
+USE vigo360;
+
+/* Verificación en dos pasos con TOTP. El secreto pendiente es el que se está dando de alta y aún no se ha confirmado */
+ALTER TABLE autores ADD COLUMN totp_secreto VARCHAR(64) DEFAULT NULL;
+ALTER TABLE autores ADD COLUMN totp_pendiente VARCHAR(64) DEFAULT NULL;
+/* Último paso de 30 segundos con el que se entró, para que un código no se pueda usar dos veces */
+ALTER TABLE autores ADD COLUMN totp_ultimo_paso BIGINT NOT NULL DEFAULT 0;
+
+/* Códigos de recuperación de un solo uso, por si se pierde el dispositivo. Solo se guarda su hash */
+CREATE TABLE autores_recuperacion (
+	codigo_hash CHAR(64) NOT NULL,
+	autor_id VARCHAR(40) NOT NULL,
+	usado DATETIME DEFAULT NULL,
+	PRIMARY KEY (codigo_hash),
+	INDEX (autor_id),
+	FOREIGN KEY (autor_id) REFERENCES autores(id) ON DELETE CASCADE
+);
+
+/* Los autores con algún rol que lo exija no pueden entrar sin dar de alta la verificación en dos pasos */
+ALTER TABLE roles ADD COLUMN exige_2fa BOOLEAN NOT NULL DEFAULT false;
+
+/* Inicios de sesión con la contraseña ya comprobada, a la espera del código. Solo se guarda el hash del token */
+CREATE TABLE logins_pendientes (
+	token_hash CHAR(64) NOT NULL,
+	autor_id VARCHAR(40) NOT NULL,
+	caduca DATETIME NOT NULL,
+	intentos INT NOT NULL DEFAULT 0,
+	PRIMARY KEY (token_hash),
+	INDEX (autor_id),
+	FOREIGN KEY (autor_id) REFERENCES autores(id) ON DELETE CASCADE
+);
@@ -0,0 +1,18 @@
+USE vigo360;
+
+/*
+Intentos de inicio de sesión recientes por cuenta ("autor:<id>") y por IP ("ip:<ip>"). Las fechas son segundos Unix
+para que las comparaciones no dependan de la zona horaria de la conexión.
+*/
+CREATE TABLE login_intentos (
+	clave VARCHAR(120) NOT NULL,
+	intentos INT NOT NULL,
+	ultimo BIGINT NOT NULL,
+	bloqueado_hasta BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (clave),
+	INDEX (ultimo)
+);
+
+/* Eventos destacados de la petición, como los bloqueos de inicio de sesión */
+ALTER TABLE log ADD COLUMN evento VARCHAR(40) NOT NULL DEFAULT '';
+ALTER TABLE log ADD INDEX (evento);
@@ -0,0 +1,24 @@
+USE vigo360;
+
+/*
+Tokens de larga duración para publicar desde scripts con la API. Solo se guarda el hash del token, que se muestra una
+única vez al crearlo. Los alcances van separados por comas y limitan lo que puede hacer el token, pero nunca le dan
+más de lo que permiten los roles del autor en cada momento
+*/
+CREATE TABLE tokens_api (
+	id INT NOT NULL AUTO_INCREMENT,
+	autor_id VARCHAR(40) NOT NULL,
+	nombre VARCHAR(80) NOT NULL,
+	token_hash CHAR(64) NOT NULL,
+	/* Primeros caracteres del token, para reconocerlo en el panel */
+	prefijo VARCHAR(16) NOT NULL,
+	alcances VARCHAR(255) NOT NULL,
+	creado DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	ultimo_uso DATETIME DEFAULT NULL,
+	caduca DATETIME DEFAULT NULL,
+	revocado BOOLEAN NOT NULL DEFAULT false,
+	PRIMARY KEY (id),
+	UNIQUE (token_hash),
+	INDEX (autor_id),
+	FOREIGN KEY (autor_id) REFERENCES autores(id) ON DELETE CASCADE
+);
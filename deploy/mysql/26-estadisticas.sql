@@ -0,0 +1,15 @@
+USE vigo360;
+
+/* Datos para las estadísticas del panel. La ruta es la plantilla de gorilla/mux, como /post/{postid} */
+ALTER TABLE log ADD COLUMN referer VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE log ADD COLUMN ruta VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE log ADD COLUMN bot BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE log ADD INDEX (time);
+ALTER TABLE log ADD INDEX (ruta, time);
+
+/* Las peticiones anteriores no tienen referer, pero se puede recuperar el resto. Debe coincidir con models.EsBot */
+UPDATE log SET bot = (user_agent = '' OR LOWER(user_agent) REGEXP 'bot|crawl|spider|slurp|curl|wget|python|go-http-client|java/|libwww|httpclient|headless|lighthouse|facebookexternalhit|feed|preview|monitor');
+UPDATE log SET ruta = '/post/{postid}' WHERE url LIKE '/post/%' AND url NOT LIKE '/post/%/%';
+
+INSERT INTO permisos (id, comentario) VALUES ("estadisticas_ver", "Ver las estadísticas de visitas");
+INSERT INTO roles_permisos (rol_id, permiso_id) VALUES ("admin", "estadisticas_ver");
@@ -15,12 +15,16 @@ require (
 	github.com/algolia/algoliasearch-client-go/v3 v3.31.4
 	github.com/arielcostas/goldmark-figures v1.0.2
 	github.com/chai2010/webp v1.1.1
+	github.com/gen2brain/avif v0.4.1
 	github.com/go-playground/validator/v10 v10.23.0
 	github.com/jmoiron/sqlx v1.4.0
 	github.com/kataras/hcaptcha v0.0.2
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
 	github.com/thanhpk/randstr v1.0.6
 	golang.org/x/crypto v0.31.0
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
+	rsc.io/qr v0.2.0
 )
 
 require (
@@ -28,6 +32,7 @@ require (
 	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/Masterminds/goutils v1.1.1 // indirect
 	github.com/Masterminds/semver/v3 v3.3.0 // indirect
+	github.com/ebitengine/purego v0.8.1 // indirect
 	github.com/go-playground/locales v0.14.1 // indirect
 	github.com/go-playground/universal-translator v0.18.1 // indirect
 	github.com/google/uuid v1.6.0 // indirect
@@ -37,8 +42,8 @@ require (
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
 	github.com/shopspring/decimal v1.4.0 // indirect
 	github.com/spf13/cast v1.7.0 // indirect
+	github.com/tetratelabs/wazero v1.8.1 // indirect
 	golang.org/x/sys v0.28.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
 )
 
 require (
@@ -1,100 +1,47 @@
 package internal
 
 import (
-	"database/sql"
+	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"net/http"
 	"os"
-	"strings"
 
-	"github.com/thanhpk/randstr"
-	"vigo360.es/new/internal/database"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/service"
 )
 
 func (s *Server) adminApiAttachmentCreate() http.HandlerFunc {
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
-		uploadPath := os.Getenv("UPLOAD_PATH")
-
-		trabajoId := r.FormValue("trabajo")
-		if trabajoId == "" {
-			log.Error("el id del trabajo no puede estar vacío: %s")
-			s.handleJsonError(r, w, 500, messages.ErrorFormulario)
-			return
-		}
-
-		titulo := r.FormValue("titulo")
-		if trabajoId == "" {
-			log.Error("el titulo del adjunto no puede estar vacío: %s")
-			s.handleJsonError(r, w, 500, messages.ErrorFormulario)
-			return
-		}
+		log := logger.DesdeContexto(r.Context())
+		var as = service.NewAdjuntoService(s.store.adjunto, s.store.trabajo, os.Getenv("ADJUNTOS_PATH"))
 
 		file, fileheader, err := r.FormFile("file")
-		if err != nil && !errors.Is(err, http.ErrMissingFile) {
-			log.Error("no se ha subido ningun archivo: %s", err.Error())
-			s.handleJsonError(r, w, 500, messages.ErrorFormulario)
-			return
-		}
-
-		fileBytes, err := io.ReadAll(file)
 		if err != nil {
-			log.Error("no se pudo extraer el archivo del formulario: %s", err.Error())
-			s.handleJsonError(r, w, 500, messages.ErrorFormulario)
-			return
-		}
-
-		var tx *sql.Tx
-		if nt, err := database.GetDB().Begin(); err != nil {
-			log.Error("error iniciando transacción: %s", err.Error())
-			s.handleError(r, w, 500, messages.ErrorDatos)
+			log.Error("no se ha subido ningun archivo: %s", err.Error())
+			s.handleJsonError(r, w, 400, messages.ErrorFormulario)
 			return
-		} else {
-			tx = nt
 		}
+		defer file.Close()
 
-		// filename = fileheader.Filename all as lowercase, separated by underscores and up to 40 characters long + extension
-		var filename = strings.ToLower(fileheader.Filename)
-		filename = strings.ReplaceAll(filename, " ", "_")
-		filename = strings.ReplaceAll(filename, "á", "a")
-		filename = strings.ReplaceAll(filename, "é", "e")
-		filename = strings.ReplaceAll(filename, "í", "i")
-		filename = strings.ReplaceAll(filename, "ó", "o")
-		filename = strings.ReplaceAll(filename, "ú", "u")
-		filename = strings.ReplaceAll(filename, "ñ", "n")
-		filename = filename[:40]
-		var salt = randstr.String(3) + "_"
-		filename = salt + filename
-		filename = filename + "." + strings.Split(fileheader.Filename, ".")[1]
-
-		_, err = tx.Exec(
-			"INSERT INTO adjuntos (trabajo_id, nombre_archivo, titulo) VALUES (?, ?, ?)",
-			trabajoId, filename, titulo,
-		)
+		adjunto, err := as.Subir(r.FormValue("trabajo"), r.FormValue("titulo"), fileheader.Filename, file)
 		if err != nil {
-			log.Error("error guardando a bbddd: %s", err.Error())
-			s.handleError(r, w, 500, messages.ErrorDatos)
+			log.Error("error guardando adjunto: %s", err.Error())
+			if errors.Is(err, service.Err_AdjuntoTituloInvalido) || errors.Is(err, service.Err_AdjuntoTrabajoInvalido) || errors.Is(err, service.Err_AdjuntoVacio) {
+				s.handleJsonError(r, w, 400, messages.ErrorValidacion)
+			} else {
+				s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			}
 			return
 		}
 
-		var imagePath = fmt.Sprintf("%s/papers/%s", uploadPath, filename)
-		err = os.WriteFile(imagePath, fileBytes, 0o644)
+		resbytes, err := json.MarshalIndent(adjunto, "", "\t")
 		if err != nil {
-			_ = tx.Rollback()
-			log.Error("error escribiendo imagen a %s: %s", imagePath, err.Error())
+			log.Error("error escribiendo json de respuesta: %s", err.Error())
 			s.handleJsonError(r, w, 500, messages.ErrorRender)
 			return
-		} else {
-			_ = tx.Commit()
 		}
-
 		w.WriteHeader(201)
-		w.Write([]byte("{}\n"))
-		w.Header().Add("Content-Type", "application/json")
+		w.Write(resbytes)
 	}
 }
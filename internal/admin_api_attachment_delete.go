@@ -4,51 +4,35 @@ import (
 	"errors"
 	"net/http"
 	"os"
-	"vigo360.es/new/internal/database"
+	"strconv"
 
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/service"
 )
 
 func (s *Server) adminApiAttachmentDelete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
+		var as = service.NewAdjuntoService(s.store.adjunto, s.store.trabajo, os.Getenv("ADJUNTOS_PATH"))
 
-		uploadPath := os.Getenv("UPLOAD_PATH")
-
-		fotoId := r.URL.Query().Get("id")
-		if fotoId == "" {
-			log.Error("falta el parámetro foto en la URL")
-			s.handleJsonError(r, w, 500, messages.ErrorFormulario)
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			log.Error("el parámetro id de la URL no es válido: %s", err.Error())
+			s.handleJsonError(r, w, 400, messages.ErrorFormulario)
 			return
 		}
 
-		db := database.GetDB()
-
-		row := db.QueryRow("SELECT nombre_archivo FROM adjuntos WHERE id = ?", fotoId)
-		var nombreArchivo string
-		if err := row.Scan(&nombreArchivo); err != nil {
-			log.Error("error escaneando nombre de archivo: %s", err.Error())
-			s.handleJsonError(r, w, 500, messages.ErrorNoResultados)
+		if err := as.Eliminar(id); errors.Is(err, service.Err_AdjuntoNoExiste) {
+			log.Error("el adjunto %d no existe", id)
+			s.handleJsonError(r, w, 404, messages.ErrorNoResultados)
+			return
+		} else if err != nil {
+			log.Error("error eliminando adjunto %d: %s", id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
 			return
 		}
 
-		_, _ = db.Exec("DELETE FROM adjuntos WHERE id = ? LIMIT 1", fotoId)
-
-		if f, err := os.Stat(uploadPath + "/papers/" + nombreArchivo); err == nil {
-			e2 := os.Remove(uploadPath + "/papers/" + f.Name())
-			if e2 != nil {
-				log.Error("error borrando fotografía: %s", err.Error())
-				s.handleJsonError(r, w, 500, "Error borrando fotografía")
-				return
-			}
-			w.WriteHeader(204)
-		} else if errors.Is(err, os.ErrNotExist) {
-			log.Error("attachment doesn't exist: %s", err.Error())
-			s.handleJsonError(r, w, 404, messages.ErrorNoResultados)
-		} else {
-			log.Error("error finding file to remove: %s", err.Error())
-			s.handleJsonError(r, w, 500, messages.ErrorNoResultados)
-		}
+		w.WriteHeader(204)
 	}
 }
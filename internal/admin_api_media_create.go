@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/chai2010/webp"
+	"github.com/go-playground/validator/v10"
+	"github.com/thanhpk/randstr"
+	"vigo360.es/new/internal/imagenes"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+)
+
+type mediaFormInput struct {
+	Alt     string `validate:"required,max=300"`
+	Leyenda string `validate:"max=300"`
+}
+
+func (s *Server) handleAdminCrearMedia() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		uploadPath := os.Getenv("UPLOAD_PATH")
+
+		if err := r.ParseMultipartForm(26214400); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleJsonError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		fi := mediaFormInput{
+			Alt:     strings.TrimSpace(r.FormValue("alt")),
+			Leyenda: strings.TrimSpace(r.FormValue("leyenda")),
+		}
+		if err := validator.New().Struct(fi); err != nil {
+			log.Error("error validando el formulario: %s", err.Error())
+			s.handleJsonError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		// La publicación es opcional, solo sirve para saber desde dónde se subió la imagen
+		articuloId := r.FormValue("articulo")
+		if articuloId != "" {
+			if _, err := s.store.publicacion.ObtenerPorId(articuloId, false); err != nil {
+				log.Error("no se encontró la publicación %s: %s", articuloId, err.Error())
+				s.handleJsonError(r, w, 400, messages.ErrorValidacion)
+				return
+			}
+		}
+
+		file, _, err := r.FormFile("foto")
+		if err != nil {
+			log.Error("no se ha subido ninguna imagen: %s", err.Error())
+			s.handleJsonError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		photoBytes, err := io.ReadAll(file)
+		if err != nil {
+			log.Error("no se pudo extraer la imagen del formulario: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorFormulario)
+			return
+		}
+
+		image, err := imagenes.Decodificar(photoBytes)
+		if err != nil {
+			log.Error("error decodificando la imagen: %s", err.Error())
+			s.handleJsonError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		var fotoEscribir bytes.Buffer
+		err = webp.Encode(&fotoEscribir, image, &webp.Options{Quality: 80})
+		if err != nil {
+			log.Error("error codificando la imagen: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		var prefijo = articuloId
+		if prefijo == "" {
+			prefijo = "media"
+		}
+		var media = models.Media{
+			Nombre_archivo: fmt.Sprintf("%s-%s.webp", prefijo, randstr.String(5)),
+			Alt:            fi.Alt,
+			Leyenda:        fi.Leyenda,
+			Ancho:          image.Bounds().Dx(),
+			Alto:           image.Bounds().Dy(),
+			Bytes:          fotoEscribir.Len(),
+			Publicacion_id: articuloId,
+			Autor_id:       sess.Autor_id,
+		}
+
+		var imagePath = fmt.Sprintf("%s/extra/%s", uploadPath, media.Nombre_archivo)
+		err = os.WriteFile(imagePath, fotoEscribir.Bytes(), 0o644)
+		if err != nil {
+			log.Error("error escribiendo imagen a %s: %s", imagePath, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		media.Id, err = s.store.media.Crear(media)
+		if err != nil {
+			_ = os.Remove(imagePath)
+			log.Error("error guardando imagen en la mediateca: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		resbytes, err := json.MarshalIndent(media, "", "\t")
+		if err != nil {
+			log.Error("error escribiendo json de respuesta: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorRender)
+			return
+		}
+		w.WriteHeader(201)
+		w.Write(resbytes)
+	}
+}
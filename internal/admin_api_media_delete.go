@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+var errorMediaEnUso messages.ErrorMessage = "La imagen se está usando en alguna publicación o trabajo."
+
+/*
+Elimina una imagen de la mediateca. Si alguna publicación o trabajo la enlaza en su Markdown no se borra, y se
+responde con un 409 y la lista de usos para que se pueda quitar antes. El store lo comprueba en el mismo DELETE.
+*/
+func (s *Server) handleAdminDeleteMedia() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		uploadPath := os.Getenv("UPLOAD_PATH")
+		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		media, err := s.store.media.Obtener(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Error("no existe la imagen %d", id)
+			s.handleJsonError(r, w, 404, messages.ErrorNoResultados)
+			return
+		} else if err != nil {
+			log.Error("error obteniendo imagen %d: %s", id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		err = s.store.media.Eliminar(media)
+		if errors.Is(err, repository.ErrMediaEnUso) {
+			usos, err := s.store.media.Usos(media)
+			if err != nil {
+				log.Error("error comprobando usos de %s: %s", media.Nombre_archivo, err.Error())
+				s.handleJsonError(r, w, 500, messages.ErrorDatos)
+				return
+			}
+			if len(usos) == 0 {
+				// No se usa, así que otra petición la borró entre Obtener y Eliminar
+				log.Error("no existe la imagen %d", id)
+				s.handleJsonError(r, w, 404, messages.ErrorNoResultados)
+				return
+			}
+			log.Warning("no se borra %s, se usa en %d publicaciones o trabajos", media.Nombre_archivo, len(usos))
+			resbytes, err := json.MarshalIndent(struct {
+				Error messages.ErrorMessage `json:"error"`
+				Usos  []models.UsoMedia     `json:"usos"`
+			}{errorMediaEnUso, usos}, "", "\t")
+			if err != nil {
+				log.Error("error escribiendo json de respuesta: %s", err.Error())
+				s.handleJsonError(r, w, 500, messages.ErrorRender)
+				return
+			}
+			w.WriteHeader(409)
+			w.Write(resbytes)
+			return
+		} else if err != nil {
+			log.Error("error eliminando imagen %d: %s", id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if err := os.Remove(uploadPath + "/extra/" + media.Nombre_archivo); err != nil && !errors.Is(err, os.ErrNotExist) {
+			// La fila ya no existe, así que el archivo solo queda huérfano en disco
+			log.Error("error borrando archivo %s: %s", media.Nombre_archivo, err.Error())
+		}
+
+		w.Write([]byte(`{ "error": false }`))
+	}
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+)
+
+/*
+Lista las imágenes de la mediateca. Con ?articulo= se limita a las que se subieron desde esa publicación,
+pero cualquier imagen se puede insertar en cualquier publicación.
+*/
+func (s *Server) handleAdminListarMedia() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		media, err := s.store.media.Listar(r.URL.Query().Get("articulo"))
+		if err != nil {
+			log.Error("error listando mediateca: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		resbytes, err := json.MarshalIndent(media, "", "\t")
+		if err != nil {
+			log.Error("error escribiendo json de respuesta: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorRender)
+			return
+		}
+		w.Write(resbytes)
+	}
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+)
+
+// Cambia el texto alternativo y la leyenda de una imagen de la mediateca
+func (s *Server) handleAdminEditarMedia() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleJsonError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		fi := mediaFormInput{
+			Alt:     strings.TrimSpace(r.FormValue("alt")),
+			Leyenda: strings.TrimSpace(r.FormValue("leyenda")),
+		}
+		if err := validator.New().Struct(fi); err != nil {
+			log.Error("error validando el formulario: %s", err.Error())
+			s.handleJsonError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		if _, err := s.store.media.Obtener(id); errors.Is(err, sql.ErrNoRows) {
+			log.Error("no existe la imagen %d", id)
+			s.handleJsonError(r, w, 404, messages.ErrorNoResultados)
+			return
+		} else if err != nil {
+			log.Error("error obteniendo imagen %d: %s", id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if err := s.store.media.Actualizar(id, fi.Alt, fi.Leyenda); err != nil {
+			log.Error("error actualizando imagen %d: %s", id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		w.Write([]byte(`{ "error": false }`))
+	}
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/service"
+)
+
+// Quita la verificación en dos pasos de un autor que ha perdido el dispositivo y los códigos de recuperación
+func (s *Server) handleAdminRestablecerSegundoFactor() http.HandlerFunc {
+	var sf = service.NewSegundoFactorService(s.store.segundoFactor)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		autorId := mux.Vars(r)["id"]
+
+		err := sf.Restablecer(autorId)
+		if errors.Is(err, service.Err_AutorNoExiste) {
+			log.Error("no existe el autor %s", autorId)
+			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			return
+		} else if err != nil {
+			log.Error("error quitando la verificación en dos pasos de %s: %s", autorId, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s quitó la verificación en dos pasos de %s", sess.Autor_id, autorId)
+		w.Header().Add("Location", "/admin/autores?aviso=2fa")
+		w.WriteHeader(303)
+	}
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/service"
+)
+
+// Activa o desactiva un autor. Un autor no se puede desactivar a sí mismo
+func (s *Server) handleAdminCambiarActivoAutor(activo bool) http.HandlerFunc {
+	var as = service.NewAutorService(s.store.autor, s.store.sesion, s.store.correo)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		autorId := mux.Vars(r)["id"]
+
+		if !activo && autorId == sess.Autor_id {
+			log.Error("%s ha intentado desactivarse a sí mismo", sess.Autor_id)
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		err := as.CambiarActivo(autorId, activo)
+		if errors.Is(err, service.Err_AutorNoExiste) {
+			log.Error("no existe el autor %s", autorId)
+			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			return
+		} else if err != nil {
+			log.Error("error cambiando estado de %s: %s", autorId, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s cambió el estado de %s a activo=%t", sess.Autor_id, autorId, activo)
+		w.Header().Add("Location", "/admin/autores")
+		w.WriteHeader(303)
+	}
+}
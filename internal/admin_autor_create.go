@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/service"
+)
+
+// Crea un autor y le envía una invitación para que elija su contraseña
+func (s *Server) handleAdminCrearAutor() http.HandlerFunc {
+	var as = service.NewAutorService(s.store.autor, s.store.sesion, s.store.correo)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		var autor = models.Autor{
+			Id:     r.FormValue("id"),
+			Nombre: r.FormValue("nombre"),
+			Email:  r.FormValue("email"),
+			Rol:    r.FormValue("rol"),
+		}
+
+		err := as.Invitar(autor)
+		if errors.Is(err, service.Err_AutorDatosInvalidos) {
+			log.Error("error validando el formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		} else if errors.Is(err, service.Err_AutorDuplicado) {
+			log.Error("no se puede invitar a %s: %s", autor.Id, err.Error())
+			s.handleError(r, w, 400, messages.ErrorIdDuplicado)
+			return
+		} else if err != nil {
+			log.Error("error invitando a %s: %s", autor.Id, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s invitó a %s", sess.Autor_id, autor.Id)
+		w.Header().Add("Location", "/admin/autores?aviso=invitado")
+		w.WriteHeader(303)
+	}
+}
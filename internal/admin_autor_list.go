@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"net/http"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+// Página para invitar, desactivar y restablecer la contraseña de autores
+func (s *Server) handleAdminListAutores() http.HandlerFunc {
+	type response struct {
+		Autores []models.Autor
+		Aviso   string
+		Session models.Session
+	}
+
+	var avisos = map[string]string{
+		"invitado":    "Se ha enviado la invitación.",
+		"restablecer": "Se ha enviado el enlace para restablecer la contraseña.",
+		"2fa":         "Se ha quitado la verificación en dos pasos. El autor podrá volver a activarla desde su perfil.",
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		autores, err := s.store.autor.Listar()
+		if err != nil {
+			log.Error("error listando autores: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		err = templates.Render(w, "admin-autores.html", response{
+			Autores: autores,
+			Aviso:   avisos[r.URL.Query().Get("aviso")],
+			Session: sess,
+		})
+		if err != nil {
+			log.Error("error renderizando la página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/service"
+)
+
+// Envía a un autor un enlace para restablecer su contraseña
+func (s *Server) handleAdminEnviarRestablecimiento() http.HandlerFunc {
+	var as = service.NewAutorService(s.store.autor, s.store.sesion, s.store.correo)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		autorId := mux.Vars(r)["id"]
+
+		err := as.EnviarRestablecimiento(autorId)
+		if errors.Is(err, service.Err_AutorNoExiste) {
+			log.Error("no existe el autor %s", autorId)
+			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			return
+		} else if err != nil {
+			log.Error("error enviando restablecimiento a %s: %s", autorId, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s envió un enlace de restablecimiento a %s", sess.Autor_id, autorId)
+		w.Header().Add("Location", "/admin/autores?aviso=restablecer")
+		w.WriteHeader(303)
+	}
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+)
+
+// Reemplaza los roles de un autor por los marcados en el formulario
+func (s *Server) handleAdminAsignarRoles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		autorId := mux.Vars(r)["id"]
+
+		if _, err := s.store.autor.Obtener(autorId); errors.Is(err, sql.ErrNoRows) {
+			log.Error("no existe el autor %s", autorId)
+			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			return
+		} else if err != nil {
+			log.Error("error obteniendo autor %s: %s", autorId, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		err := s.store.rol.AsignarRoles(autorId, r.Form["roles"])
+		if errors.Is(err, models.ErrSinGestorPermisos) {
+			log.Error("se rechaza cambiar los roles de %s: %s", autorId, err.Error())
+			s.handleError(r, w, 409, errorSinGestorPermisos)
+			return
+		} else if err != nil {
+			log.Error("error asignando roles a %s: %s", autorId, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s cambió los roles de %s a %v", sess.Autor_id, autorId, r.Form["roles"])
+		w.Header().Add("Location", "/admin/roles")
+		w.WriteHeader(303)
+	}
+}
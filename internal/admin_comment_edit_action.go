@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/service"
+)
+
+func (s *Server) handleAdminEditComentarioAction() http.HandlerFunc {
+	var cs = service.NewComentarioService(s.store.comentario, s.store.publicacion)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		comentarioId := mux.Vars(r)["id"]
+
+		err := cs.Editar(comentarioId, r.FormValue("contenido"), sess.Autor_id)
+		if errors.Is(err, service.Err_ComentarioContenidoInvalido) {
+			log.Error("contenido de comentario no válido")
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Error("comentario %s no encontrado", comentarioId)
+			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			return
+		}
+		if err != nil {
+			log.Error("error editando comentario %s: %s", comentarioId, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("editado comentario %s", comentarioId)
+
+		w.Header().Add("Location", r.URL.Path)
+		defer w.WriteHeader(303)
+	}
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+func (s *Server) handleAdminEditComentarioPage() http.HandlerFunc {
+	type returnParams struct {
+		Comentario   models.Comentario
+		Moderaciones []models.Moderacion
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		comentarioId := mux.Vars(r)["id"]
+
+		comentario, err := s.store.comentario.Obtener(comentarioId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				log.Error("comentario no encontrado: %s", err.Error())
+				s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			} else {
+				log.Error("error recuperando comentario: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+			}
+			return
+		}
+
+		moderaciones, err := s.store.comentario.ListarModeraciones(comentarioId)
+		if err != nil {
+			log.Error("error recuperando historial de moderación: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		err = templates.Render(w, "admin-comentarios-id.html", returnParams{
+			Comentario:   comentario,
+			Moderaciones: moderaciones,
+		})
+		if err != nil {
+			log.Error("error renderizando la página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
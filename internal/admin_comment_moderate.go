@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/service"
+)
+
+// Aplica la misma decisión a todos los comentarios seleccionados en la lista de moderación
+func (s *Server) handleAdminModerarComentarios() http.HandlerFunc {
+	var cs = service.NewComentarioService(s.store.comentario, s.store.publicacion)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		if err := r.ParseForm(); err != nil {
+			logger.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		var (
+			ids    = r.PostForm["cid"] // cid = commentId = los comentarios a moderar
+			accion = models.AccionModeracion(r.PostFormValue("accion"))
+		)
+
+		err := cs.Moderar(ids, accion, sess.Autor_id)
+		if errors.Is(err, service.Err_ModeracionAccionInvalida) || errors.Is(err, service.Err_ModeracionSinComentarios) {
+			logger.Error("moderación no válida: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+		if err != nil {
+			logger.Error("error moderando comentarios %v: %s", ids, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		logger.Information("%s %d comentarios", accion, len(ids))
+
+		var destino = "/admin/comentarios"
+		if estado := r.PostFormValue("estado"); estado != "" {
+			destino += "?estado=" + url.QueryEscape(estado)
+		}
+		w.Header().Add("Location", destino)
+		defer w.WriteHeader(303)
+	}
+}
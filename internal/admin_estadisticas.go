@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+// Periodos que se pueden elegir en la página, en días
+var periodosEstadisticas = []int{7, 30, 90}
+
+/*
+Muestra las estadísticas de visitas calculadas a partir de la tabla log, sin servicios externos. Con ?publicacion=
+muestra además las visitas por día de esa publicación.
+*/
+func (s *Server) handleAdminEstadisticas() http.HandlerFunc {
+	type response struct {
+		Periodos    []int
+		Dias        int
+		Resumen     []models.EstadisticaDia
+		MaxDia      int
+		Humanas     int
+		Bots        int
+		Publicacion models.VisitasPublicacion
+		Visitas     []models.VisitasDia
+		MaxVisitas  int
+		MasVistas   []models.VisitasPublicacion
+		Referentes  []models.Referente
+		Lentas      []models.TiempoRuta
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		var res = response{Periodos: periodosEstadisticas, Dias: 30}
+		if d, err := strconv.Atoi(r.URL.Query().Get("dias")); err == nil {
+			for _, p := range periodosEstadisticas {
+				if p == d {
+					res.Dias = d
+				}
+			}
+		}
+		var desde = time.Now().AddDate(0, 0, -res.Dias)
+
+		var dominio = ""
+		if u, err := url.Parse(os.Getenv("DOMAIN")); err == nil {
+			dominio = u.Host
+		}
+
+		var err error
+		if res.Resumen, err = s.store.registro.ResumenDiario(desde); err != nil {
+			log.Error("error recuperando el resumen diario: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		for _, d := range res.Resumen {
+			res.Humanas += d.Humanas
+			res.Bots += d.Bots
+			if d.Humanas+d.Bots > res.MaxDia {
+				res.MaxDia = d.Humanas + d.Bots
+			}
+		}
+
+		if res.MasVistas, err = s.store.registro.PublicacionesMasVistas(desde, 20); err != nil {
+			log.Error("error recuperando las publicaciones más vistas: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		if res.Referentes, err = s.store.registro.Referentes(desde, dominio, 20); err != nil {
+			log.Error("error recuperando los referentes: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		if res.Lentas, err = s.store.registro.RutasMasLentas(desde, 20); err != nil {
+			log.Error("error recuperando los tiempos de respuesta: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if id := r.URL.Query().Get("publicacion"); id != "" {
+			p, err := s.store.publicacion.ObtenerPorId(id, false)
+			if err != nil {
+				log.Warning("no se encontró la publicación %s: %s", id, err.Error())
+				s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+				return
+			}
+			res.Publicacion = models.VisitasPublicacion{Id: p.Id, Titulo: p.Titulo}
+
+			if res.Visitas, err = s.store.registro.VisitasPublicacion(id, desde); err != nil {
+				log.Error("error recuperando las visitas de %s: %s", id, err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+				return
+			}
+			for _, v := range res.Visitas {
+				res.Publicacion.Visitas += v.Visitas
+				if v.Visitas > res.MaxVisitas {
+					res.MaxVisitas = v.Visitas
+				}
+			}
+		}
+
+		err = templates.Render(w, "admin-estadisticas.html", res)
+		if err != nil {
+			log.Error("error renderizando la página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
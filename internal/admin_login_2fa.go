@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rsc.io/qr"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/service"
+	"vigo360.es/new/internal/templates"
+)
+
+// Cookie con el token del inicio de sesión que ya ha pasado la contraseña y espera el código
+const cookieLoginPendiente = "login2fa"
+
+// Separa el secreto en grupos de cuatro letras, para copiarlo a mano con menos errores
+func agruparSecreto(secreto string) string {
+	var grupos []string
+	for i := 0; i < len(secreto); i += 4 {
+		grupos = append(grupos, secreto[i:getMinimo(i+4, len(secreto))])
+	}
+	return strings.Join(grupos, " ")
+}
+
+/*
+Genera el QR de la URI otpauth:// como PNG dentro de una URL data:, para que la aplicación pueda leerlo de la pantalla
+sin que el secreto salga del servidor hacia ningún servicio externo.
+*/
+func qrAlta(uri string) (template.URL, error) {
+	codigo, err := qr.Encode(uri, qr.M)
+	if err != nil {
+		return "", err
+	}
+	codigo.Scale = 5
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(codigo.PNG())), nil
+}
+
+func borrarCookieLoginPendiente(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieLoginPendiente,
+		Value:    "",
+		Path:     "/admin/login",
+		Domain:   r.URL.Host,
+		Expires:  time.Date(1970, 01, 01, 00, 00, 00, 00, time.UTC),
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   true,
+	})
+}
+
+/*
+Segundo paso del inicio de sesión. Si el autor tiene la verificación en dos pasos activada pide un código; si no la
+tiene pero alguno de sus roles la exige, tiene que darla de alta antes de entrar.
+*/
+func (s *Server) handleAdminLogin2fa() http.HandlerFunc {
+	type response struct {
+		Alta     bool
+		Secreto  string
+		Uri      template.URL
+		Qr       template.URL
+		Error    string
+		Autor_id string
+	}
+
+	var sf = service.NewSegundoFactorService(s.store.segundoFactor)
+	var limite = service.NewLimiteLoginService(s.store.limiteLogin)
+
+	var mostrar = func(w http.ResponseWriter, autor_id string, mensaje string) error {
+		estado, _, err := sf.Estado(autor_id)
+		if err != nil {
+			return err
+		}
+
+		var resp = response{Error: mensaje, Autor_id: autor_id}
+		if !estado.Activo() {
+			secreto, uri, err := sf.IniciarAlta(autor_id)
+			if err != nil {
+				return err
+			}
+			resp.Alta, resp.Secreto, resp.Uri = true, agruparSecreto(secreto), template.URL(uri)
+			if resp.Qr, err = qrAlta(uri); err != nil {
+				return err
+			}
+		}
+		return templates.Render(w, "admin-login-2fa.html", resp)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		var token string
+		if c, err := r.Cookie(cookieLoginPendiente); err == nil {
+			token = c.Value
+		}
+		login, err := sf.ObtenerLogin(token)
+		if errors.Is(err, service.Err_LoginPendienteInvalido) {
+			log.Notice("no hay ningún inicio de sesión pendiente válido")
+			borrarCookieLoginPendiente(w, r)
+			w.Header().Add("Location", "/admin/login?"+r.URL.RawQuery)
+			w.WriteHeader(303)
+			return
+		} else if err != nil {
+			log.Error("error obteniendo el inicio de sesión pendiente: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			if err := mostrar(w, login.Autor_id, ""); err != nil {
+				log.Error("error mostrando el segundo paso: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorRender)
+			}
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("error recuperando datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		// Los códigos cuentan como intentos de la cuenta, para que conocer la contraseña no permita probarlos sin límite
+		var ip = ipCliente(r)
+		intento, err := limite.Intentar(login.Autor_id, ip)
+		if err != nil {
+			log.Error("error comprobando los intentos de inicio de sesión: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		if intento.Espera > 0 {
+			log.Warning("se rechaza el código de '%s' desde %s, debe esperar %s", login.Autor_id, ip, intento.Espera)
+			anotarEvento(r, "login_limitado")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(intento.Espera.Seconds()))))
+			w.WriteHeader(http.StatusTooManyRequests)
+			if err := mostrar(w, login.Autor_id, "Demasiados intentos fallidos. Espera un poco antes de volver a intentarlo."); err != nil {
+				log.Error("error mostrando el segundo paso: %s", err.Error())
+			}
+			return
+		}
+
+		estado, _, err := sf.Estado(login.Autor_id)
+		if err != nil {
+			log.Error("error obteniendo la verificación en dos pasos: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		var codigos []string
+		if estado.Activo() {
+			err = sf.Verificar(login.Autor_id, r.PostFormValue("codigo"))
+		} else {
+			codigos, err = sf.ConfirmarAlta(login.Autor_id, r.PostFormValue("codigo"))
+		}
+
+		if errors.Is(err, service.Err_CodigoIncorrecto) || errors.Is(err, service.Err_SinAltaPendiente) {
+			log.Warning("código incorrecto en el segundo paso de '%s'", login.Autor_id)
+			if intento.Bloqueo {
+				log.Warning("se bloquea temporalmente el inicio de sesión de '%s' o de %s", login.Autor_id, ip)
+				anotarEvento(r, "login_bloqueo")
+			} else {
+				anotarEvento(r, "2fa_fallido")
+			}
+			if err := sf.FallarLogin(token); err != nil {
+				log.Error("error apuntando el intento fallido: %s", err.Error())
+			}
+			if err := mostrar(w, login.Autor_id, "El código no es correcto o ya se ha usado."); err != nil {
+				log.Error("error mostrando el segundo paso: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorRender)
+			}
+			return
+		} else if err != nil {
+			log.Error("error comprobando el código de '%s': %s", login.Autor_id, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if err := limite.Exito(login.Autor_id, ip); err != nil {
+			log.Error("error olvidando los intentos de inicio de sesión: %s", err.Error())
+		}
+		if err := sf.CompletarLogin(token); err != nil {
+			log.Error("error eliminando el inicio de sesión pendiente: %s", err.Error())
+		}
+		borrarCookieLoginPendiente(w, r)
+
+		if err := s.iniciarSesion(w, r, login.Autor_id); err != nil {
+			log.Error("error guardando nueva sesión: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		log.Information("'%s' ha completado el segundo paso", login.Autor_id)
+
+		if codigos != nil {
+			err = templates.Render(w, "admin-2fa-codigos.html", codigosRecuperacion{Codigos: codigos, Continuar: destinoLogin(r)})
+			if err != nil {
+				log.Error("error mostrando los códigos de recuperación: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorRender)
+			}
+			return
+		}
+
+		w.Header().Add("Location", destinoLogin(r))
+		w.WriteHeader(303)
+	}
+}
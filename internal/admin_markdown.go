@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+var errFicheroMarkdown = errors.New("solo se pueden importar ficheros .md o .zip")
+var errFicheroMarkdownGrande = errors.New("el fichero ocupa más de 2MB")
+
+var errorImportacionGrande messages.ErrorMessage = "Los ficheros ocupan más de 50MB, impórtalos en varias veces"
+
+const maxImportacionMarkdown = 50 << 20
+
+// Cuerpo de la petición de importación, con margen para los separadores y cabeceras de cada fichero del multipart
+const maxCuerpoImportacion = maxImportacionMarkdown + 1<<20
+
+// Lo que pase de aquí al leer un formulario multipart se guarda en ficheros temporales en lugar de en memoria
+const memoriaFormulario = 25 << 20
+
+// Tamaño máximo de cada fichero dentro de un zip, para que un zip pequeño no se descomprima en algo enorme
+const maxFicheroMarkdown = 2 << 20
+
+// Descarga todas las publicaciones en un zip con un .md por cada una
+func (s *Server) handleAdminExportarMarkdown() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		// Se genera entero antes de responder para poder devolver un error si falla a medias
+		var buf bytes.Buffer
+		var zw = zip.NewWriter(&buf)
+		n, err := s.exportarMarkdown(func(nombre string, datos []byte) error {
+			f, err := zw.Create(nombre)
+			if err != nil {
+				return err
+			}
+			_, err = f.Write(datos)
+			return err
+		})
+		if err == nil {
+			err = zw.Close()
+		}
+		if err != nil {
+			log.Error("error exportando publicaciones: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("exportadas %d publicaciones a markdown", n)
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="vigo360-publicaciones-`+time.Now().Format("2006-01-02")+`.zip"`)
+		w.Write(buf.Bytes())
+	}
+}
+
+/*
+Importa los ficheros .md subidos, sueltos o dentro de un zip como el que genera handleAdminExportarMarkdown, y
+muestra el resultado de cada uno. Un fichero con errores no impide importar el resto.
+*/
+func (s *Server) handleAdminImportarMarkdown() http.HandlerFunc {
+	type response struct {
+		Resultados []resultadoImportacion
+		Fallidos   int
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		// Normalmente VerifyCsrf ya ha limitado el cuerpo y leído el formulario, y entonces esto no hace nada
+		r.Body = http.MaxBytesReader(w, r.Body, maxCuerpoImportacion)
+		var demasiado *http.MaxBytesError
+		if err := r.ParseMultipartForm(memoriaFormulario); errors.As(err, &demasiado) {
+			s.handleError(r, w, 413, errorImportacionGrande)
+			return
+		} else if err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		// El cuerpo incluye más que los ficheros, así que el límite de lo que ocupan se comprueba aparte
+		var total int64
+		for _, cabecera := range r.MultipartForm.File["archivos"] {
+			total += cabecera.Size
+		}
+		if total > maxImportacionMarkdown {
+			s.handleError(r, w, 413, errorImportacionGrande)
+			return
+		}
+
+		var res response
+		var importar = func(nombre string, datos []byte) {
+			var resultado = s.importarMarkdown(nombre, datos, sess.Autor_id, sess.Permisos["autores_gestionar"])
+			if resultado.Estado == importacionError {
+				log.Warning("error importando %s: %s", nombre, resultado.Error)
+				res.Fallidos++
+			}
+			res.Resultados = append(res.Resultados, resultado)
+		}
+		var fallo = func(nombre string, err error) {
+			res.Fallidos++
+			res.Resultados = append(res.Resultados, resultadoImportacion{Fichero: nombre, Estado: importacionError, Error: err.Error()})
+		}
+
+		for _, cabecera := range r.MultipartForm.File["archivos"] {
+			f, err := cabecera.Open()
+			if err != nil {
+				fallo(cabecera.Filename, err)
+				continue
+			}
+			datos, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				fallo(cabecera.Filename, err)
+				continue
+			}
+
+			switch strings.ToLower(path.Ext(cabecera.Filename)) {
+			case ".md":
+				importar(cabecera.Filename, datos)
+			case ".zip":
+				zr, err := zip.NewReader(bytes.NewReader(datos), int64(len(datos)))
+				if err != nil {
+					fallo(cabecera.Filename, err)
+					continue
+				}
+				for _, zf := range zr.File {
+					if zf.FileInfo().IsDir() || strings.ToLower(path.Ext(zf.Name)) != ".md" {
+						continue
+					}
+					var nombre = cabecera.Filename + "/" + zf.Name
+					rc, err := zf.Open()
+					if err != nil {
+						fallo(nombre, err)
+						continue
+					}
+					contenido, err := io.ReadAll(io.LimitReader(rc, maxFicheroMarkdown+1))
+					rc.Close()
+					if err != nil {
+						fallo(nombre, err)
+						continue
+					} else if len(contenido) > maxFicheroMarkdown {
+						fallo(nombre, errFicheroMarkdownGrande)
+						continue
+					}
+					importar(nombre, contenido)
+				}
+			default:
+				fallo(cabecera.Filename, errFicheroMarkdown)
+			}
+		}
+
+		log.Information("%s importó %d ficheros markdown, %d con errores", sess.Autor_id, len(res.Resultados), res.Fallidos)
+
+		if err := templates.Render(w, "admin-importar.html", res); err != nil {
+			log.Error("error renderizando la página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/service"
+	"vigo360.es/new/internal/templates"
+)
+
+// Códigos de recuperación recién generados, que solo se muestran una vez
+type codigosRecuperacion struct {
+	Codigos   []string
+	Continuar string
+}
+
+var resultadosSegundoFactor = map[string]string{
+	"desactivada": "Se ha desactivado la verificación en dos pasos.",
+	"incorrecto":  "El código no es correcto o ya se ha usado.",
+	"exigida":     "Alguno de tus roles exige la verificación en dos pasos, así que no se puede desactivar.",
+}
+
+// Muestra el estado de la verificación en dos pasos del autor actual y, si se indica, el alta en curso
+func (s *Server) mostrarSegundoFactor(w http.ResponseWriter, r *http.Request, sf service.SegundoFactor, alta bool, mensaje string) {
+	type response struct {
+		Estado  models.SegundoFactor
+		Exigido bool
+		Alta    bool
+		Secreto string
+		Uri     template.URL
+		Qr      template.URL
+		Mensaje string
+	}
+
+	log := logger.DesdeContexto(r.Context())
+	sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+	estado, exigido, err := sf.Estado(sess.Autor_id)
+	if err != nil {
+		log.Error("error obteniendo la verificación en dos pasos: %s", err.Error())
+		s.handleError(r, w, 500, messages.ErrorDatos)
+		return
+	}
+
+	var resp = response{Estado: estado, Exigido: exigido, Mensaje: mensaje}
+	if alta && !estado.Activo() {
+		secreto, uri, err := sf.IniciarAlta(sess.Autor_id)
+		if err != nil {
+			log.Error("error iniciando el alta de la verificación en dos pasos: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		resp.Alta, resp.Secreto, resp.Uri = true, agruparSecreto(secreto), template.URL(uri)
+		if resp.Qr, err = qrAlta(uri); err != nil {
+			log.Error("error generando el QR de la verificación en dos pasos: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+			return
+		}
+	}
+
+	if err := templates.Render(w, "admin-2fa.html", resp); err != nil {
+		log.Error("error renderizando la página: %s", err.Error())
+		s.handleError(r, w, 500, messages.ErrorRender)
+	}
+}
+
+func (s *Server) handleAdminSegundoFactorView() http.HandlerFunc {
+	var sf = service.NewSegundoFactorService(s.store.segundoFactor)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mostrarSegundoFactor(w, r, sf, false, resultadosSegundoFactor[r.URL.Query().Get("resultado")])
+	}
+}
+
+// Genera el secreto y muestra cómo darlo de alta en la aplicación
+func (s *Server) handleAdminSegundoFactorAlta() http.HandlerFunc {
+	var sf = service.NewSegundoFactorService(s.store.segundoFactor)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mostrarSegundoFactor(w, r, sf, true, "")
+	}
+}
+
+// Confirma el alta con un código de la aplicación y muestra los códigos de recuperación
+func (s *Server) handleAdminSegundoFactorConfirmar() http.HandlerFunc {
+	var sf = service.NewSegundoFactorService(s.store.segundoFactor)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		codigos, err := sf.ConfirmarAlta(sess.Autor_id, r.PostFormValue("codigo"))
+		if errors.Is(err, service.Err_CodigoIncorrecto) || errors.Is(err, service.Err_SinAltaPendiente) {
+			log.Warning("%s no pudo confirmar la verificación en dos pasos: %s", sess.Autor_id, err.Error())
+			s.mostrarSegundoFactor(w, r, sf, true, resultadosSegundoFactor["incorrecto"])
+			return
+		} else if err != nil {
+			log.Error("error activando la verificación en dos pasos de %s: %s", sess.Autor_id, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s activó la verificación en dos pasos", sess.Autor_id)
+		if err := templates.Render(w, "admin-2fa-codigos.html", codigosRecuperacion{Codigos: codigos, Continuar: "/admin/perfil/2fa"}); err != nil {
+			log.Error("error mostrando los códigos de recuperación: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
+
+// Sustituye los códigos de recuperación tras comprobar un código
+func (s *Server) handleAdminSegundoFactorCodigos() http.HandlerFunc {
+	var sf = service.NewSegundoFactorService(s.store.segundoFactor)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		codigos, err := sf.RegenerarCodigos(sess.Autor_id, r.PostFormValue("codigo"))
+		if errors.Is(err, service.Err_CodigoIncorrecto) || errors.Is(err, service.Err_SegundoFactorInactivo) {
+			log.Warning("%s no pudo regenerar sus códigos de recuperación: %s", sess.Autor_id, err.Error())
+			w.Header().Add("Location", "/admin/perfil/2fa?resultado=incorrecto")
+			w.WriteHeader(303)
+			return
+		} else if err != nil {
+			log.Error("error regenerando los códigos de recuperación de %s: %s", sess.Autor_id, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s regeneró sus códigos de recuperación", sess.Autor_id)
+		if err := templates.Render(w, "admin-2fa-codigos.html", codigosRecuperacion{Codigos: codigos, Continuar: "/admin/perfil/2fa"}); err != nil {
+			log.Error("error mostrando los códigos de recuperación: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
+
+// Desactiva la verificación en dos pasos tras comprobar un código, si ningún rol del autor la exige
+func (s *Server) handleAdminSegundoFactorDesactivar() http.HandlerFunc {
+	var sf = service.NewSegundoFactorService(s.store.segundoFactor)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		var resultado = "desactivada"
+		err := sf.Desactivar(sess.Autor_id, r.PostFormValue("codigo"))
+		if errors.Is(err, service.Err_SegundoFactorExigido) {
+			resultado = "exigida"
+		} else if errors.Is(err, service.Err_CodigoIncorrecto) || errors.Is(err, service.Err_SegundoFactorInactivo) {
+			resultado = "incorrecto"
+		} else if err != nil {
+			log.Error("error desactivando la verificación en dos pasos de %s: %s", sess.Autor_id, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if resultado == "desactivada" {
+			log.Information("%s desactivó la verificación en dos pasos", sess.Autor_id)
+		} else {
+			log.Warning("%s no pudo desactivar la verificación en dos pasos: %s", sess.Autor_id, resultado)
+		}
+		w.Header().Add("Location", "/admin/perfil/2fa?resultado="+resultado)
+		w.WriteHeader(303)
+	}
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/service"
+)
+
+// Cambia la contraseña del autor actual y cierra el resto de sus sesiones
+func (s *Server) handleAdminCambiarContraseña() http.HandlerFunc {
+	var as = service.NewAutorService(s.store.autor, s.store.sesion, s.store.correo)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		var resultado = "cambiada"
+		if r.PostFormValue("nueva") != r.PostFormValue("repetir") {
+			resultado = "distintas"
+		} else if err := as.CambiarContraseña(sess.Autor_id, r.PostFormValue("actual"), r.PostFormValue("nueva"), sess.Id); errors.Is(err, service.Err_ContraseñaIncorrecta) {
+			resultado = "incorrecta"
+		} else if errors.Is(err, service.Err_ContraseñaInvalida) {
+			resultado = "invalida"
+		} else if err != nil {
+			log.Error("error cambiando la contraseña de %s: %s", sess.Autor_id, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if resultado == "cambiada" {
+			log.Information("%s cambió su contraseña", sess.Autor_id)
+		} else {
+			log.Warning("%s no pudo cambiar su contraseña: %s", sess.Autor_id, resultado)
+		}
+		w.Header().Add("Location", "/admin/perfil?contrasena="+resultado)
+		w.WriteHeader(303)
+	}
+}
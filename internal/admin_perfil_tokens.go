@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/service"
+	"vigo360.es/new/internal/templates"
+)
+
+var resultadosTokensApi = map[string]string{
+	"revocado":  "Se ha revocado el token. Los scripts que lo usen dejarán de funcionar.",
+	"invalido":  "El nombre debe tener entre 3 y 80 caracteres y hay que elegir al menos un alcance.",
+	"permisos":  "Tus roles no permiten alguno de los alcances elegidos.",
+	"no-existe": "No se encontró el token, puede que ya esté revocado.",
+}
+
+// Opciones de caducidad del formulario, en días. 0 es que no caduca
+var validecesTokenApi = []int{30, 90, 365, 0}
+
+// Muestra los tokens de la API del autor actual y, justo después de crearlo, el token nuevo
+func (s *Server) mostrarTokensApi(w http.ResponseWriter, r *http.Request, ts service.TokenApi, nuevo string, mensaje string) {
+	type alcance struct {
+		Alcance     models.AlcanceApi
+		Descripcion string
+		Permitido   bool
+	}
+	type response struct {
+		Tokens   []models.TokenApi
+		Alcances []alcance
+		Validez  []int
+		Nuevo    string
+		Mensaje  string
+		UrlApi   string
+	}
+
+	log := logger.DesdeContexto(r.Context())
+	sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+	tokens, err := ts.Listar(sess.Autor_id)
+	if err != nil {
+		log.Error("error listando tokens de la API: %s", err.Error())
+		s.handleError(r, w, 500, messages.ErrorDatos)
+		return
+	}
+
+	var alcances []alcance
+	for _, a := range models.AlcancesApi {
+		alcances = append(alcances, alcance{Alcance: a, Descripcion: a.Descripcion(), Permitido: sess.Permisos[a.Permiso()]})
+	}
+
+	if nuevo != "" {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	err = templates.Render(w, "admin-tokens-api.html", response{
+		Tokens:   tokens,
+		Alcances: alcances,
+		Validez:  validecesTokenApi,
+		Nuevo:    nuevo,
+		Mensaje:  mensaje,
+		UrlApi:   fullCanonica("/api/v1"),
+	})
+	if err != nil {
+		log.Error("error renderizando la página: %s", err.Error())
+		s.handleError(r, w, 500, messages.ErrorRender)
+	}
+}
+
+func (s *Server) handleAdminTokensApiView() http.HandlerFunc {
+	var ts = service.NewTokenApiService(s.store.tokenApi)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mostrarTokensApi(w, r, ts, "", resultadosTokensApi[r.URL.Query().Get("resultado")])
+	}
+}
+
+// Crea un token y lo muestra una sola vez
+func (s *Server) handleAdminCrearTokenApi() http.HandlerFunc {
+	var ts = service.NewTokenApiService(s.store.tokenApi)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		dias, err := strconv.Atoi(r.PostFormValue("validez"))
+		if err != nil || dias < 0 {
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		token, err := ts.Crear(sess.Autor_id, r.PostFormValue("nombre"), r.PostForm["alcances"], time.Duration(dias)*24*time.Hour, sess.Permisos)
+		if errors.Is(err, service.Err_TokenApiDatosInvalidos) {
+			s.mostrarTokensApi(w, r, ts, "", resultadosTokensApi["invalido"])
+			return
+		} else if errors.Is(err, service.Err_TokenApiSinPermiso) {
+			s.mostrarTokensApi(w, r, ts, "", resultadosTokensApi["permisos"])
+			return
+		} else if err != nil {
+			log.Error("error creando token de la API para %s: %s", sess.Autor_id, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s creó un token de la API con alcances %v", sess.Autor_id, r.PostForm["alcances"])
+		s.mostrarTokensApi(w, r, ts, token, "")
+	}
+}
+
+// Revoca uno de los tokens del autor actual
+func (s *Server) handleAdminRevocarTokenApi() http.HandlerFunc {
+	var ts = service.NewTokenApiService(s.store.tokenApi)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		var resultado = "revocado"
+		if err := ts.Revocar(id, sess.Autor_id); errors.Is(err, service.Err_TokenApiInvalido) {
+			resultado = "no-existe"
+		} else if err != nil {
+			log.Error("error revocando el token %d: %s", id, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		} else {
+			log.Information("%s revocó su token de la API %d", sess.Autor_id, id)
+		}
+
+		w.Header().Add("Location", "/admin/perfil/tokens?resultado="+resultado)
+		w.WriteHeader(303)
+	}
+}
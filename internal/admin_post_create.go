@@ -1,13 +1,10 @@
 package internal
 
 import (
-	"database/sql"
 	_ "embed"
+	"errors"
 	"github.com/go-playground/validator/v10"
 	"net/http"
-	"os"
-	"regexp"
-	"vigo360.es/new/internal/database"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
 	"vigo360.es/new/internal/models"
@@ -30,13 +27,8 @@ func (s *Server) handleAdminCreatePost() http.HandlerFunc {
 		Session models.Session
 	}
 
-	var postIdRegexp = regexp.MustCompile(`^[A-Za-z0-9\-_]{3,40}$`)
-	var ValidatePostId = func(id string) bool {
-		return postIdRegexp.MatchString(id)
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
 		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
 
 		var artAutor = sess.Autor_id
@@ -52,7 +44,7 @@ func (s *Server) handleAdminCreatePost() http.HandlerFunc {
 			Titulo: r.FormValue("art-titulo"),
 		}
 
-		if !ValidatePostId(fi.ArtId) {
+		if !postIdRegexp.MatchString(fi.ArtId) {
 			log.Error("error validando id")
 			s.handleError(r, w, 500, messages.ErrorIdInvalido)
 			return
@@ -65,45 +57,14 @@ func (s *Server) handleAdminCreatePost() http.HandlerFunc {
 			return
 		}
 
-		var tx *sql.Tx
-		if nt, err := database.GetDB().Begin(); err != nil {
-			log.Error("error iniciando transacción: %s", err.Error())
-			s.handleError(r, w, 500, messages.ErrorDatos)
-			return
-		} else {
-			tx = nt
-		}
-
-		q := "INSERT INTO publicaciones(id, titulo, alt_portada, resumen, contenido, autor_id) VALUES (?, ?, 'CAMBIAME','', '', ?);"
-		if _, err := tx.Exec(q, fi.ArtId, fi.Titulo, artAutor); err != nil {
-			tx.Rollback()
+		if err := s.crearPublicacion(fi.ArtId, fi.Titulo, artAutor); errors.Is(err, errPublicacionDuplicada) {
 			log.Error("error creando artículo: %s", err.Error())
-			if err.Error() == "UNIQUE constraint failed: publicaciones.id" {
-				s.handleError(r, w, 500, messages.ErrorIdDuplicado)
-			} else {
-				s.handleError(r, w, 500, messages.ErrorDatos)
-			}
+			s.handleError(r, w, 500, messages.ErrorIdDuplicado)
 			return
-		}
-
-		photopath := os.Getenv("UPLOAD_PATH")
-		if err := os.WriteFile(photopath+"/images/"+fi.ArtId+".webp", defaultImageWebp, 0o644); err != nil {
-			tx.Rollback()
-			log.Error("error escribiendo imagen webp: %s", err.Error())
-			s.handleError(r, w, 500, messages.ErrorDatos)
-			return
-		}
-
-		if err := os.WriteFile(photopath+"/thumb/"+fi.ArtId+".jpg", defaultImageJPG, 0o644); err != nil {
-			tx.Rollback()
-			log.Error("error escribiendo imagen jpg: %s", err.Error())
-			s.handleError(r, w, 500, messages.ErrorDatos)
-			return
-		}
-
-		if err := tx.Commit(); err != nil {
-			tx.Rollback()
-			log.Error("error ejecutando transacción: %s", err.Error())
+		} else if errors.Is(err, errPortadaNoEncolada) {
+			log.Error("%s", err.Error())
+		} else if err != nil {
+			log.Error("error creando artículo: %s", err.Error())
 			s.handleError(r, w, 500, messages.ErrorDatos)
 			return
 		}
@@ -7,19 +7,11 @@ import (
 	"vigo360.es/new/internal/database"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
-	"vigo360.es/new/internal/models"
 )
 
 func (s *Server) handleAdminDeletePost() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
-		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
-		if !sess.Permisos["publicaciones_delete"] {
-			logger.Error("sin permiso para eliminar la publicación")
-			s.handleError(r, w, 403, messages.ErrorSinPermiso)
-			return
-		}
-
+		logger := logger.DesdeContexto(r.Context())
 		// TODO: Convertir esto en procedimiento
 		var postid = mux.Vars(r)["postid"]
 		tx, err := database.GetDB().Begin()
@@ -46,8 +38,9 @@ func (s *Server) handleAdminDeletePost() http.HandlerFunc {
 			s.handleError(r, w, 500, messages.ErrorDatos)
 			return
 		}
+		s.despertarTrabajadores()
 
 		w.Header().Add("Location", "/admin/post")
-		defer w.WriteHeader(307)
+		defer w.WriteHeader(303)
 	}
 }
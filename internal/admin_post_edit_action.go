@@ -1,36 +1,26 @@
 package internal
 
 import (
-	"bytes"
 	"database/sql"
 	"errors"
-	"io"
 	"net/http"
-	"os"
-	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"vigo360.es/new/internal/database"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
-	"vigo360.es/new/internal/seo"
+	"vigo360.es/new/internal/models"
 )
 
 func (s *Server) handleAdminEditPostAction() http.HandlerFunc {
-
-	type EditPostActionFormInput struct {
-		Titulo     string `validate:"required,min=3,max=80"`
-		Resumen    string `validate:"required,min=3,max=300"`
-		Contenido  string `validate:"required"`
-		AltPortada string `validate:"required,min=3,max=300"`
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
 		publicacionId := mux.Vars(r)["id"]
 
-		_, err := s.store.publicacion.ObtenerPorId(publicacionId, false)
+		publicacion, err := s.store.publicacion.ObtenerPorId(publicacionId, false)
 		if err != nil {
 			log.Error("no se encontró la publicación a editar")
 			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
@@ -43,11 +33,12 @@ func (s *Server) handleAdminEditPostAction() http.HandlerFunc {
 			return
 		}
 
-		fi := EditPostActionFormInput{
+		fi := contenidoPublicacion{
 			Titulo:     r.FormValue("art-titulo"),
 			Resumen:    r.FormValue("art-resumen"),
 			Contenido:  r.FormValue("art-contenido"),
 			AltPortada: r.FormValue("alt-portada"),
+			Tags:       r.Form["tags"],
 		}
 
 		if err := validator.New().Struct(fi); err != nil {
@@ -57,7 +48,26 @@ func (s *Server) handleAdminEditPostAction() http.HandlerFunc {
 			return
 		}
 
-		tags := r.Form["tags"]
+		/*
+			Una publicación que aún no ha salido se puede publicar inmediatamente, programar para una fecha o
+			devolver a borrador. Una vez publicada, su fecha ya no se puede cambiar.
+		*/
+		var cambiarFecha = !publicacion.EstaPublicada()
+		var nuevaFecha sql.NullString
+		if cambiarFecha {
+			if r.FormValue("publicar") == "on" {
+				nuevaFecha = sql.NullString{String: time.Now().UTC().Format("2006-01-02 15:04:05"), Valid: true}
+			} else if rawFecha := r.FormValue("fecha-publicacion"); rawFecha != "" {
+				fecha, err := time.ParseInLocation("2006-01-02T15:04", rawFecha, time.Local)
+				if err != nil {
+					log.Error("error validando fecha de publicación '%s': %s", rawFecha, err.Error())
+					s.handleError(r, w, 400, messages.ErrorValidacion)
+					return
+				}
+				nuevaFecha = sql.NullString{String: fecha.UTC().Format("2006-01-02 15:04:05"), Valid: true}
+			}
+		}
+
 		var tx *sql.Tx
 
 		if nt, err := database.GetDB().Begin(); err != nil {
@@ -68,67 +78,27 @@ func (s *Server) handleAdminEditPostAction() http.HandlerFunc {
 			tx = nt
 		}
 
-		if _, err := tx.Exec("DELETE FROM publicaciones_tags WHERE publicacion_id = ?", publicacionId); err != nil {
+		if err := s.guardarContenidoPublicacion(tx, publicacionId, fi, sess.Autor_id); err != nil {
 			e2 := tx.Rollback()
 			if e2 != nil {
 				s.handleError(r, w, 500, messages.ErrorDatos)
 			}
-			log.Error("error eliminando tags existentes: %s", err.Error())
+			log.Error("%s", err.Error())
 			s.handleError(r, w, 500, messages.ErrorDatos)
 			return
 		}
 
-		for _, t := range tags {
-			if _, err := tx.Exec("INSERT INTO publicaciones_tags (publicacion_id, tag_id) VALUES (?, ?)", publicacionId, t); err != nil {
+		if cambiarFecha {
+			query := `UPDATE publicaciones SET fecha_publicacion=? WHERE id=?`
+			if _, err := tx.Exec(query, nuevaFecha, publicacionId); err != nil {
 				e2 := tx.Rollback()
 				if e2 != nil {
 					s.handleError(r, w, 500, messages.ErrorDatos)
 				}
-				log.Error("error insertando nuevas tags: %s", err.Error())
-				s.handleError(r, w, 500, messages.ErrorDatos)
-				return
-			}
-		}
-
-		query := `UPDATE publicaciones SET titulo=?, resumen=?, contenido=?, alt_portada=? WHERE id=?`
-		if _, err := tx.Exec(query,
-			strings.TrimSpace(fi.Titulo),
-			strings.TrimSpace(fi.Resumen),
-			strings.TrimSpace(fi.Contenido),
-			strings.TrimSpace(fi.AltPortada),
-			publicacionId,
-		); err != nil {
-			e2 := tx.Rollback()
-			if e2 != nil {
-				s.handleError(r, w, 500, messages.ErrorDatos)
-			}
-			log.Error("error actualizando publicación: %s", err.Error())
-			s.handleError(r, w, 500, messages.ErrorDatos)
-			return
-		}
-
-		if r.FormValue("publicar") == "on" {
-			query := `UPDATE publicaciones SET fecha_publicacion=NOW() WHERE id=?`
-			if _, err := tx.Exec(query, publicacionId); err != nil {
 				log.Error("error actualizando fecha de publicación: %s", err.Error())
 				s.handleError(r, w, 500, messages.ErrorDatos)
 				return
 			}
-
-			var DOMAIN = os.Getenv("DOMAIN")
-			var indexnowurls = []string{
-				DOMAIN + "/",
-				DOMAIN + "/post/" + publicacionId,
-			}
-
-			for _, t := range tags {
-				indexnowurls = append(indexnowurls, DOMAIN+"/tags/"+t)
-			}
-
-			err = seo.BingIndexnowRequest(indexnowurls)
-			if err != nil {
-				log.Error("error llamando a indexnow: %s", err.Error())
-			}
 		}
 
 		if err := tx.Commit(); err != nil {
@@ -137,6 +107,12 @@ func (s *Server) handleAdminEditPostAction() http.HandlerFunc {
 			return
 		}
 
+		if nuevaFecha.Valid {
+			// Si se ha publicado ahora, que el programador notifique a IndexNow sin esperar
+			s.despertarProgramador()
+		}
+		s.despertarTrabajadores()
+
 		portada_file, _, err := r.FormFile("portada")
 		if err != nil && !errors.Is(err, http.ErrMissingFile) {
 			log.Error("error extrayendo imagen: %s", err.Error())
@@ -146,7 +122,11 @@ func (s *Server) handleAdminEditPostAction() http.HandlerFunc {
 
 		// Image uploaded
 		if !errors.Is(err, http.ErrMissingFile) {
-			encodeImagesAndSave(portada_file, publicacionId)
+			if err := s.encolarPortada(portada_file, publicacionId, focoDesdeFormulario(r.FormValue("foco-x"), r.FormValue("foco-y"))); err != nil {
+				log.Error("error guardando portada para procesar: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+				return
+			}
 		}
 
 		defer w.WriteHeader(303)
@@ -157,31 +137,3 @@ func (s *Server) handleAdminEditPostAction() http.HandlerFunc {
 		}
 	}
 }
-
-func encodeImagesAndSave(portada_file io.Reader, publicacion_id string) {
-	uppath := os.Getenv("UPLOAD_PATH")
-	var err error
-	log := logger.NewLogger("encodeImagesAndSave " + publicacion_id)
-
-	var portadaJpg, portadaWebp bytes.Buffer
-	if pj, pw, e2 := generateImagesFromImage(portada_file); errors.Is(e2, ErrImageFormatError) {
-		log.Error("error procesando imágenes: %s", err.Error())
-		return
-	} else if err != nil {
-		log.Error("error procesando imágenes: %s", err.Error())
-		return
-	} else {
-		portadaJpg = pj
-		portadaWebp = pw
-	}
-
-	if e2 := os.WriteFile(uppath+"/thumb/"+publicacion_id+".jpg", portadaJpg.Bytes(), os.ModePerm); e2 != nil {
-		log.Error("error guardando imagen jpg: %s", err.Error())
-		return
-	}
-
-	if e2 := os.WriteFile(uppath+"/images/"+publicacion_id+".webp", portadaWebp.Bytes(), os.ModePerm); e2 != nil {
-		log.Error("error guardando imagen webp: %s", err.Error())
-		return
-	}
-}
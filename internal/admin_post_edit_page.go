@@ -20,13 +20,14 @@ func (s *Server) handleAdminEditPostPage() http.HandlerFunc {
 	}
 
 	type returnParams struct {
-		Post    models.Publicacion
-		Tags    []tag
-		Session models.Session
+		Post      models.Publicacion
+		Publicada bool
+		Tags      []tag
+		Session   models.Session
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
 		sess := r.Context().Value(sessionContextKey("sess")).(models.Session)
 		postId := mux.Vars(r)["id"]
 
@@ -58,9 +59,10 @@ func (s *Server) handleAdminEditPostPage() http.HandlerFunc {
 		}
 
 		err = templates.Render(w, "admin-post-id.html", returnParams{
-			Post:    publicacion,
-			Tags:    tags,
-			Session: sess,
+			Post:      publicacion,
+			Publicada: publicacion.EstaPublicada(),
+			Tags:      tags,
+			Session:   sess,
 		})
 		if err != nil {
 			log.Error("error mostrando página: %s", err.Error())
@@ -33,7 +33,7 @@ func (s *Server) handleAdminListPost() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
 		sess := r.Context().Value(sessionContextKey("sess")).(models.Session)
 		db := database.GetDB()
 		posts := []ResumenPost{}
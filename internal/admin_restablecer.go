@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/service"
+	"vigo360.es/new/internal/templates"
+)
+
+type restablecerResponse struct {
+	Token   string
+	Enviado bool
+	Error   string
+}
+
+// Formulario para pedir un enlace de restablecimiento a partir del correo
+func (s *Server) handleAdminRestablecerSolicitudPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		err := templates.Render(w, "admin-restablecer.html", restablecerResponse{})
+		if err != nil {
+			log.Error("error renderizando la página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
+
+/*
+Envía el enlace si el correo es de un autor activo. La respuesta es la misma exista o no. Cada solicitud cuenta como
+un intento de inicio de sesión para el correo y para la IP, para que no se pueda llenar de enlaces el buzón de nadie.
+*/
+func (s *Server) handleAdminRestablecerSolicitudAction() http.HandlerFunc {
+	var as = service.NewAutorService(s.store.autor, s.store.sesion, s.store.correo)
+	var limite = service.NewLimiteLoginService(s.store.limiteLogin)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		var email = r.PostFormValue("email")
+
+		var ip = ipCliente(r)
+		intento, err := limite.Intentar(email, ip)
+		if err != nil {
+			log.Error("error comprobando los intentos de restablecimiento: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		if intento.Espera > 0 {
+			log.Warning("se rechaza restablecer la contraseña de '%s' desde %s, debe esperar %s", email, ip, intento.Espera)
+			anotarEvento(r, "restablecer_limitado")
+			var segundos = int(math.Ceil(intento.Espera.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(segundos))
+			w.WriteHeader(http.StatusTooManyRequests)
+			err := templates.Render(w, "admin-restablecer.html", restablecerResponse{
+				Error: "Se han pedido demasiados enlaces. Vuelve a intentarlo dentro de " + describirEspera(segundos) + ".",
+			})
+			if err != nil {
+				log.Notice("error mostrando página: %s", err.Error())
+			}
+			return
+		}
+
+		if err := as.SolicitarRestablecimiento(email); err != nil {
+			log.Error("error solicitando restablecimiento: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		err = templates.Render(w, "admin-restablecer.html", restablecerResponse{Enviado: true})
+		if err != nil {
+			log.Error("error renderizando la página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
+
+// Formulario para elegir una contraseña nueva con un token de invitación o restablecimiento
+func (s *Server) handleAdminRestablecerPage() http.HandlerFunc {
+	var as = service.NewAutorService(s.store.autor, s.store.sesion, s.store.correo)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		token := mux.Vars(r)["token"]
+
+		if _, err := as.ComprobarToken(token); errors.Is(err, service.Err_TokenInvalido) {
+			log.Warning("token de restablecimiento no válido")
+			s.handleError(r, w, 404, messages.ErrorMessage("El enlace no es válido, ya se ha usado o ha caducado."))
+			return
+		} else if err != nil {
+			log.Error("error comprobando token: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		err := templates.Render(w, "admin-restablecer.html", restablecerResponse{Token: token})
+		if err != nil {
+			log.Error("error renderizando la página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
+
+func (s *Server) handleAdminRestablecerAction() http.HandlerFunc {
+	var as = service.NewAutorService(s.store.autor, s.store.sesion, s.store.correo)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		token := mux.Vars(r)["token"]
+
+		var fallo string
+		if r.PostFormValue("nueva") != r.PostFormValue("repetir") {
+			fallo = "Las contraseñas no coinciden."
+		} else if err := as.Restablecer(token, r.PostFormValue("nueva")); errors.Is(err, service.Err_ContraseñaInvalida) {
+			fallo = "La contraseña debe tener al menos 10 caracteres y como mucho 72 bytes, que son menos caracteres si lleva tildes o emojis."
+		} else if errors.Is(err, service.Err_TokenInvalido) {
+			log.Warning("token de restablecimiento no válido")
+			s.handleError(r, w, 404, messages.ErrorMessage("El enlace no es válido, ya se ha usado o ha caducado."))
+			return
+		} else if err != nil {
+			log.Error("error restableciendo contraseña: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if fallo != "" {
+			err := templates.Render(w, "admin-restablecer.html", restablecerResponse{Token: token, Error: fallo})
+			if err != nil {
+				log.Error("error renderizando la página: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorRender)
+			}
+			return
+		}
+
+		log.Information("contraseña restablecida con un token")
+		w.Header().Add("Location", "/admin/login")
+		w.WriteHeader(303)
+	}
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"vigo360.es/new/internal/models"
+)
+
+// Ningún correo es de un autor, así que el restablecimiento no llega a enviar nada
+func (s *memAutorStore) ObtenerPorEmail(email string) (models.Autor, error) {
+	return models.Autor{}, sql.ErrNoRows
+}
+
+// Pide un enlace de restablecimiento para el correo desde la IP indicada, como si llegase a través del nginx local
+func (p *pruebaLogin) restablecer(email string, ip string) *httptest.ResponseRecorder {
+	var form = url.Values{"email": {email}}
+	var r = httptest.NewRequest(http.MethodPost, "/admin/restablecer", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Forwarded-For", ip)
+	r.RemoteAddr = "127.0.0.1:40000"
+	r = r.WithContext(context.WithValue(r.Context(), ridContextKey("rid"), "prueba"))
+
+	var w = httptest.NewRecorder()
+	p.server.handleAdminRestablecerSolicitudAction()(w, r)
+	return w
+}
+
+func TestRestablecerLimitePorCuenta(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+
+	// Las tres primeras no esperan, y la cuarta deja la cuenta esperando
+	for i := 0; i < 4; i++ {
+		if w := p.restablecer("nadie@vigo360.es", "203.0.113."+strconv.Itoa(i+1)); w.Code != http.StatusOK {
+			t.Fatalf("la solicitud %d debería aceptarse, se obtuvo %d", i+1, w.Code)
+		}
+	}
+
+	w := p.restablecer("Nadie@vigo360.es ", "203.0.113.50")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("se esperaba el correo limitado aunque cambie la IP, se obtuvo %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("falta Retry-After")
+	}
+
+	if w := p.restablecer("otra@vigo360.es", "203.0.113.50"); w.Code != http.StatusOK {
+		t.Fatalf("otro correo no debería estar limitado, se obtuvo %d", w.Code)
+	}
+}
+
+func TestRestablecerLimitePorIp(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+
+	// Un correo distinto cada vez, sin esperas pendientes, hasta pasar los intentos libres de la IP
+	for i := 0; i < 11; i++ {
+		p.limite.expirar()
+		p.restablecer("correo"+strconv.Itoa(i)+"@vigo360.es", "203.0.113.7")
+	}
+
+	if w := p.restablecer("nuevo@vigo360.es", "203.0.113.7"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("se esperaba la IP limitada, se obtuvo %d", w.Code)
+	}
+	if w := p.restablecer("nuevo@vigo360.es", "203.0.113.8"); w.Code != http.StatusOK {
+		t.Fatalf("desde otra IP se debería poder pedir el enlace, se obtuvo %d", w.Code)
+	}
+}
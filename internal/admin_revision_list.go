@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/diff"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+// Ruta del editor de una publicación o trabajo, bajo la que cuelgan sus revisiones
+func rutaEditorRevision(tipo models.TipoRevision, entidad_id string) string {
+	if tipo == models.RevisionTrabajo {
+		return "/admin/works/" + entidad_id
+	}
+	return "/admin/post/" + entidad_id
+}
+
+// Obtiene el título actual de la publicación o trabajo, o sql.ErrNoRows si no existe
+func (s *Server) tituloEntidadRevision(tipo models.TipoRevision, entidad_id string) (string, error) {
+	if tipo == models.RevisionTrabajo {
+		trabajo, err := s.store.trabajo.ObtenerPorId(entidad_id, false)
+		return trabajo.Titulo, err
+	}
+	publicacion, err := s.store.publicacion.ObtenerPorId(entidad_id, false)
+	return publicacion.Titulo, err
+}
+
+func (s *Server) handleAdminListRevisiones(tipo models.TipoRevision) http.HandlerFunc {
+	type comparacion struct {
+		Campo  string
+		Lineas []diff.Linea
+	}
+
+	type returnParams struct {
+		Titulo      string
+		Ruta        string
+		Revisiones  []models.Revision
+		A           models.Revision
+		B           models.Revision
+		Diferencias []comparacion
+		Session     models.Session
+	}
+
+	// Busca una revisión por el id pasado en la URL, o devuelve la de la posición por defecto si no se indica
+	var elegir = func(revisiones []models.Revision, rawId string, porDefecto int) (models.Revision, bool) {
+		if rawId == "" {
+			return revisiones[min(porDefecto, len(revisiones)-1)], true
+		}
+		id, err := strconv.Atoi(rawId)
+		if err != nil {
+			return models.Revision{}, false
+		}
+		for _, rev := range revisiones {
+			if rev.Id == id {
+				return rev, true
+			}
+		}
+		return models.Revision{}, false
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		entidadId := mux.Vars(r)["id"]
+
+		titulo, err := s.tituloEntidadRevision(tipo, entidadId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				log.Error("%s %s no encontrado: %s", tipo, entidadId, err.Error())
+				s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			} else {
+				log.Error("error recuperando %s: %s", tipo, err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+			}
+			return
+		}
+
+		revisiones, err := s.store.revision.Listar(tipo, entidadId)
+		if err != nil {
+			log.Error("error recuperando revisiones: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		var params = returnParams{
+			Titulo:      titulo,
+			Ruta:        rutaEditorRevision(tipo, entidadId),
+			Revisiones:  revisiones,
+			Diferencias: make([]comparacion, 0),
+			Session:     sess,
+		}
+
+		if len(revisiones) > 0 {
+			// Por defecto se compara la penúltima revisión con la última
+			a, okA := elegir(revisiones, r.URL.Query().Get("a"), 1)
+			b, okB := elegir(revisiones, r.URL.Query().Get("b"), 0)
+			if !okA || !okB {
+				log.Error("revisiones a comparar no válidas: %s", r.URL.RawQuery)
+				s.handleError(r, w, 404, messages.ErrorNoResultados)
+				return
+			}
+			params.A = a
+			params.B = b
+
+			for _, c := range []comparacion{
+				{Campo: "Título", Lineas: diff.Lineas(a.Titulo, b.Titulo)},
+				{Campo: "Resumen", Lineas: diff.Lineas(a.Resumen, b.Resumen)},
+				{Campo: "Texto alternativo de portada", Lineas: diff.Lineas(a.Alt_portada, b.Alt_portada)},
+				{Campo: "Contenido", Lineas: diff.Lineas(a.Contenido, b.Contenido)},
+			} {
+				if diff.HayCambios(c.Lineas) {
+					params.Diferencias = append(params.Diferencias, c)
+				}
+			}
+		}
+
+		err = templates.Render(w, "admin-revisiones.html", params)
+		if err != nil {
+			log.Error("error mostrando página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
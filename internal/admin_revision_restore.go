@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/database"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+)
+
+func (s *Server) handleAdminRestaurarRevision(tipo models.TipoRevision) http.HandlerFunc {
+	var tabla = map[models.TipoRevision]string{
+		models.RevisionPublicacion: "publicaciones",
+		models.RevisionTrabajo:     "trabajos",
+	}[tipo]
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		entidadId := mux.Vars(r)["id"]
+
+		revisionId, err := strconv.Atoi(mux.Vars(r)["revision"])
+		if err != nil {
+			log.Error("id de revisión no válido: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorIdInvalido)
+			return
+		}
+
+		revision, err := s.store.revision.Obtener(tipo, entidadId, revisionId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				log.Error("revisión %d de %s no encontrada", revisionId, entidadId)
+				s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			} else {
+				log.Error("error recuperando revisión: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+			}
+			return
+		}
+
+		var tx *sql.Tx
+		if nt, err := database.GetDB().Begin(); err != nil {
+			log.Error("error comenzando transacción: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		} else {
+			tx = nt
+		}
+
+		query := `UPDATE ` + tabla + ` SET titulo=?, resumen=?, contenido=?, alt_portada=? WHERE id=?`
+		if _, err := tx.Exec(query, revision.Titulo, revision.Resumen, revision.Contenido, revision.Alt_portada, entidadId); err != nil {
+			_ = tx.Rollback()
+			log.Error("error restaurando revisión: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		// La restauración queda registrada como una revisión más, así que también se puede deshacer
+		revision.Autor_id = sess.Autor_id
+		if err := s.store.revision.Guardar(tx, revision); err != nil {
+			_ = tx.Rollback()
+			log.Error("error guardando revisión: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Error("error haciendo commit: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if tipo == models.RevisionPublicacion {
+			// El trigger de publicaciones ha encolado su envío al índice de búsqueda
+			s.despertarTrabajadores()
+		}
+
+		log.Information("%s restauró la revisión %d de %s", sess.Autor_id, revision.Id, entidadId)
+		w.Header().Add("Location", rutaEditorRevision(tipo, entidadId))
+		w.WriteHeader(303)
+	}
+}
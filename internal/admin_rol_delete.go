@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+)
+
+// Elimina un rol. Los autores que lo tenían pierden sus permisos salvo que se los dé otro rol
+func (s *Server) handleAdminEliminarRol() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		rolId := mux.Vars(r)["id"]
+
+		err := s.store.rol.Eliminar(rolId)
+		if errors.Is(err, models.ErrSinGestorPermisos) {
+			log.Error("se rechaza eliminar el rol %s: %s", rolId, err.Error())
+			s.handleError(r, w, 409, errorSinGestorPermisos)
+			return
+		} else if err != nil {
+			log.Error("error eliminando rol %s: %s", rolId, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s eliminó el rol %s", sess.Autor_id, rolId)
+		w.Header().Add("Location", "/admin/roles")
+		w.WriteHeader(303)
+	}
+}
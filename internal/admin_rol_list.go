@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"net/http"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+// Página para definir roles y asignarlos a autores
+func (s *Server) handleAdminListRoles() http.HandlerFunc {
+	type autorRoles struct {
+		models.Autor
+		Roles map[string]bool
+	}
+
+	type response struct {
+		Permisos []models.Permiso
+		Roles    []models.Rol
+		Autores  []autorRoles
+		Session  models.Session
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		permisos, err := s.store.rol.ListarPermisos()
+		if err != nil {
+			log.Error("error listando permisos: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		roles, err := s.store.rol.Listar()
+		if err != nil {
+			log.Error("error listando roles: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		autores, err := s.store.autor.Listar()
+		if err != nil {
+			log.Error("error listando autores: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		rolesPorAutor, err := s.store.rol.RolesPorAutor()
+		if err != nil {
+			log.Error("error listando roles de autores: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		var res = response{
+			Permisos: permisos,
+			Roles:    roles,
+			Autores:  make([]autorRoles, 0, len(autores)),
+			Session:  sess,
+		}
+		for _, a := range autores {
+			var ar = autorRoles{Autor: a, Roles: make(map[string]bool)}
+			for _, rol := range rolesPorAutor[a.Id] {
+				ar.Roles[rol] = true
+			}
+			res.Autores = append(res.Autores, ar)
+		}
+
+		err = templates.Render(w, "admin-roles.html", res)
+		if err != nil {
+			log.Error("error renderizando la página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
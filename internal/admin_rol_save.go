@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+)
+
+var errorSinGestorPermisos messages.ErrorMessage = "Al menos un autor debe conservar el permiso para gestionar roles."
+
+// Crea un rol o, si ya existe uno con ese id, cambia su nombre, descripción, permisos y si exige la verificación en dos pasos
+func (s *Server) handleAdminGuardarRol() http.HandlerFunc {
+	type GuardarRolFormInput struct {
+		Id          string `validate:"required,max=40"`
+		Nombre      string `validate:"required,max=80"`
+		Descripcion string `validate:"max=255"`
+	}
+
+	var rolIdRegexp = regexp.MustCompile(`^[a-z0-9\-_]{1,40}$`)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		fi := GuardarRolFormInput{
+			Id:          strings.TrimSpace(r.FormValue("id")),
+			Nombre:      strings.TrimSpace(r.FormValue("nombre")),
+			Descripcion: strings.TrimSpace(r.FormValue("descripcion")),
+		}
+		if err := validator.New().Struct(fi); err != nil || !rolIdRegexp.MatchString(fi.Id) {
+			log.Error("error validando el formulario de rol '%s'", fi.Id)
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		existentes, err := s.store.rol.ListarPermisos()
+		if err != nil {
+			log.Error("error listando permisos: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		var rol = models.Rol{Id: fi.Id, Nombre: fi.Nombre, Descripcion: fi.Descripcion, Exige_2fa: r.Form.Has("exige_2fa"), Permisos: make(map[string]bool)}
+		for _, p := range existentes {
+			rol.Permisos[p.Id] = r.Form.Has("permiso-" + p.Id)
+		}
+
+		err = s.store.rol.Guardar(rol)
+		if errors.Is(err, models.ErrSinGestorPermisos) {
+			log.Error("se rechaza guardar el rol %s: %s", rol.Id, err.Error())
+			s.handleError(r, w, 409, errorSinGestorPermisos)
+			return
+		} else if err != nil {
+			log.Error("error guardando rol %s: %s", rol.Id, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s guardó el rol %s", sess.Autor_id, rol.Id)
+		w.Header().Add("Location", "/admin/roles")
+		w.WriteHeader(303)
+	}
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+)
+
+func (s *Server) handleAdminCreateSerie() http.HandlerFunc {
+	type entrada struct {
+		SerieId string
+		Titulo  string `validate:"required,min=3,max=50"`
+	}
+
+	var serieIdRegexp = regexp.MustCompile(`^[A-Za-z0-9\-_]{3,40}$`)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("error leyendo datos de formulario: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorFormulario)
+			return
+		}
+
+		fi := entrada{
+			SerieId: r.FormValue("serie-id"),
+			Titulo:  r.FormValue("serie-titulo"),
+		}
+
+		if !serieIdRegexp.MatchString(fi.SerieId) {
+			log.Error("error validando id")
+			s.handleError(r, w, 400, messages.ErrorIdInvalido)
+			return
+		}
+
+		if err := validator.New().Struct(fi); err != nil {
+			log.Error("error validando título: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		if _, err := s.store.serie.Obtener(fi.SerieId); err == nil {
+			log.Error("ya existe una serie con id %s", fi.SerieId)
+			s.handleError(r, w, 400, messages.ErrorIdDuplicado)
+			return
+		}
+
+		if err := s.store.serie.Crear(models.Serie{Id: fi.SerieId, Titulo: fi.Titulo}); err != nil {
+			log.Error("error creando serie: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		w.Header().Add("Location", "/admin/series/"+fi.SerieId)
+		w.WriteHeader(303)
+	}
+}
@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+var errorPublicacionEnOtraSerie messages.ErrorMessage = "La publicación ya pertenece a otra serie, quítala de esa serie antes de añadirla a esta."
+
+func (s *Server) handleAdminEditSerieAction() http.HandlerFunc {
+	type entrada struct {
+		Titulo string `validate:"required,min=3,max=50"`
+	}
+
+	type parte struct {
+		PublicacionId string
+		Posicion      int
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		serieId := mux.Vars(r)["id"]
+
+		serie, err := s.store.serie.Obtener(serieId)
+		if err != nil {
+			log.Error("no se encontró la serie a editar: %s", err.Error())
+			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("no se pudo extraer datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		fi := entrada{
+			Titulo: strings.TrimSpace(r.FormValue("serie-titulo")),
+		}
+
+		if err := validator.New().Struct(fi); err != nil {
+			log.Error("error validando el formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		var (
+			ids        = r.Form["publicacion"]
+			posiciones = r.Form["posicion"]
+			quitar     = r.Form["quitar"]
+			partes     = make([]parte, 0)
+		)
+
+		if len(ids) != len(posiciones) {
+			log.Error("hay %d publicaciones y %d posiciones", len(ids), len(posiciones))
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		for i, id := range ids {
+			if slices.Contains(quitar, id) {
+				continue
+			}
+
+			posicion, err := strconv.Atoi(posiciones[i])
+			if err != nil {
+				log.Error("la posición '%s' no es un número", posiciones[i])
+				s.handleError(r, w, 400, messages.ErrorValidacion)
+				return
+			}
+			partes = append(partes, parte{PublicacionId: id, Posicion: posicion})
+		}
+
+		sort.SliceStable(partes, func(i, j int) bool {
+			return partes[i].Posicion < partes[j].Posicion
+		})
+
+		serie.Titulo = fi.Titulo
+		serie.Publicaciones = make(models.Publicaciones, 0)
+		for _, p := range partes {
+			serie.Publicaciones = append(serie.Publicaciones, models.Publicacion{Id: p.PublicacionId})
+		}
+
+		if nueva := r.FormValue("nueva"); nueva != "" {
+			existe, err := s.store.publicacion.Existe(nueva)
+			if err != nil || !existe {
+				log.Error("la publicación %s no existe", nueva)
+				s.handleError(r, w, 400, messages.ErrorValidacion)
+				return
+			}
+			serie.Publicaciones = append(serie.Publicaciones, models.Publicacion{Id: nueva})
+		}
+
+		if err := s.store.serie.Actualizar(serie); errors.Is(err, repository.ErrPublicacionEnOtraSerie) {
+			log.Warning("no se actualizó la serie %s: %s", serie.Id, err.Error())
+			s.handleError(r, w, 400, errorPublicacionEnOtraSerie)
+			return
+		} else if err != nil {
+			log.Error("error actualizando serie: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		w.Header().Add("Location", r.URL.Path)
+		w.WriteHeader(303)
+	}
+}
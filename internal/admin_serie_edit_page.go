@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+func (s *Server) handleAdminEditSeriePage() http.HandlerFunc {
+	type returnParams struct {
+		Serie       models.Serie
+		Disponibles models.Publicaciones
+		Session     models.Session
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		serieId := mux.Vars(r)["id"]
+
+		serie, err := s.store.serie.Obtener(serieId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				log.Error("serie no encontrada: %s", err.Error())
+				s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			} else {
+				log.Error("error recuperando serie: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+			}
+			return
+		}
+
+		publicaciones, err := s.store.publicacion.Listar()
+		if err != nil {
+			log.Error("error recuperando publicaciones: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		// Solo se pueden añadir publicaciones que no estén ya en otra serie
+		var disponibles = make(models.Publicaciones, 0)
+		for _, p := range publicaciones {
+			if p.Serie_id == "" {
+				disponibles = append(disponibles, p)
+			}
+		}
+
+		err = templates.Render(w, "admin-series-id.html", returnParams{
+			Serie:       serie,
+			Disponibles: disponibles,
+			Session:     sess,
+		})
+		if err != nil {
+			log.Error("error mostrando página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"net/http"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+func (s *Server) handleAdminListSeries() http.HandlerFunc {
+	type response struct {
+		Series  []models.Serie
+		Session models.Session
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		series, err := s.store.serie.Listar()
+		if err != nil {
+			log.Error("error recuperando listado de series: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		err = templates.Render(w, "admin-series.html", &response{
+			Series:  series,
+			Session: sess,
+		})
+
+		if err != nil {
+			log.Error("error mostrando página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
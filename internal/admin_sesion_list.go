@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"net/http"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+// Lista las sesiones abiertas del autor. Quien tenga sesiones_gestionar puede ver las de otro autor con ?autor=
+func (s *Server) handleAdminListSesiones() http.HandlerFunc {
+	type response struct {
+		Sesiones []models.Sesion
+		Autor    string
+		Autores  []models.Autor
+		Actual   int
+		Aviso    string
+		Session  models.Session
+	}
+
+	var avisos = map[string]string{
+		"revocada": "Se ha cerrado la sesión.",
+		"otras":    "Se han cerrado las demás sesiones.",
+		"todas":    "Se han cerrado todas las sesiones del autor.",
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		var autor = sess.Autor_id
+		if a := r.URL.Query().Get("autor"); a != "" && a != autor {
+			if !sess.Permisos["sesiones_gestionar"] {
+				log.Error("%s no tiene permiso para ver las sesiones de %s", sess.Autor_id, a)
+				s.handleError(r, w, 403, messages.ErrorSinPermiso)
+				return
+			}
+			autor = a
+		}
+
+		sesiones, err := s.store.sesion.Listar(autor)
+		if err != nil {
+			log.Error("error listando sesiones: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		actual, err := s.store.sesion.ObtenerId(sess.Id)
+		if err != nil {
+			log.Error("error obteniendo la sesión actual: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		var autores []models.Autor
+		if sess.Permisos["sesiones_gestionar"] {
+			autores, err = s.store.autor.Listar()
+			if err != nil {
+				log.Error("error listando autores: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+				return
+			}
+		}
+
+		err = templates.Render(w, "admin-sesiones.html", response{
+			Sesiones: sesiones,
+			Autor:    autor,
+			Autores:  autores,
+			Actual:   actual,
+			Aviso:    avisos[r.URL.Query().Get("aviso")],
+			Session:  sess,
+		})
+		if err != nil {
+			log.Error("error renderizando la página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+)
+
+var errorSesionNoEncontrada messages.ErrorMessage = "No se encontró la sesión, puede que ya esté cerrada"
+
+// Dirección de la página de sesiones, indicando el autor solo si no es el de la sesión actual
+func urlSesiones(sess models.Session, autor string, aviso string) string {
+	var q = url.Values{}
+	if autor != sess.Autor_id {
+		q.Set("autor", autor)
+	}
+	q.Set("aviso", aviso)
+	return "/admin/sesiones?" + q.Encode()
+}
+
+// Revoca una sesión concreta, propia o, con sesiones_gestionar, de cualquier autor
+func (s *Server) handleAdminRevocarSesion() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		sesion, err := s.store.sesion.Obtener(id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				log.Error("no existe la sesión %d", id)
+				s.handleError(r, w, 404, errorSesionNoEncontrada)
+			} else {
+				log.Error("error obteniendo sesión: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+			}
+			return
+		}
+
+		if sesion.Autor_id != sess.Autor_id && !sess.Permisos["sesiones_gestionar"] {
+			log.Error("%s no tiene permiso para revocar sesiones de %s", sess.Autor_id, sesion.Autor_id)
+			s.handleError(r, w, 403, messages.ErrorSinPermiso)
+			return
+		}
+
+		if err := s.store.sesion.Revocar(id); err != nil {
+			log.Error("error revocando sesión: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s ha revocado la sesión %d de %s", sess.Autor_id, id, sesion.Autor_id)
+		w.Header().Add("Location", urlSesiones(sess, sesion.Autor_id, "revocada"))
+		w.WriteHeader(303)
+	}
+}
+
+/*
+Revoca todas las sesiones del autor salvo la actual. Con sesiones_gestionar y el campo autor de otro autor,
+revoca todas las suyas.
+*/
+func (s *Server) handleAdminRevocarOtrasSesiones() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		if err := r.ParseForm(); err != nil {
+			log.Error("error recuperando datos del formulario: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorFormulario)
+			return
+		}
+
+		var autor, excepto, aviso = r.PostFormValue("autor"), sess.Id, "otras"
+		if autor == "" {
+			autor = sess.Autor_id
+		}
+		if autor != sess.Autor_id {
+			if !sess.Permisos["sesiones_gestionar"] {
+				log.Error("%s no tiene permiso para revocar sesiones de %s", sess.Autor_id, autor)
+				s.handleError(r, w, 403, messages.ErrorSinPermiso)
+				return
+			}
+			excepto, aviso = "", "todas"
+		}
+
+		if err := s.store.sesion.RevocarTodas(autor, excepto); err != nil {
+			log.Error("error revocando sesiones: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s ha revocado las sesiones de %s", sess.Autor_id, autor)
+		w.Header().Add("Location", urlSesiones(sess, autor, aviso))
+		w.WriteHeader(303)
+	}
+}
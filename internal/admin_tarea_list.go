@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"net/http"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+func (s *Server) handleAdminListTareas() http.HandlerFunc {
+	type estado struct {
+		Estado   models.EstadoTarea
+		Nombre   string
+		Cantidad int
+	}
+
+	type response struct {
+		Estados []estado
+		Estado  models.EstadoTarea
+		Tareas  []models.Tarea
+	}
+
+	var estados = []estado{
+		{Estado: models.TareaFallida, Nombre: "Fallidas"},
+		{Estado: models.TareaPendiente, Nombre: "Pendientes"},
+		{Estado: models.TareaEnCurso, Nombre: "En curso"},
+		{Estado: models.TareaCompletada, Nombre: "Completadas"},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		cuenta, err := s.store.tarea.Contar()
+		if err != nil {
+			log.Error("error contando tareas: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		var res = response{
+			Estados: make([]estado, 0, len(estados)),
+			Estado:  models.TareaFallida,
+		}
+		for _, e := range estados {
+			e.Cantidad = cuenta[e.Estado]
+			res.Estados = append(res.Estados, e)
+			if string(e.Estado) == r.URL.Query().Get("estado") {
+				res.Estado = e.Estado
+			}
+		}
+
+		res.Tareas, err = s.store.tarea.Listar(res.Estado, 100)
+		if err != nil {
+			log.Error("error listando tareas: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		err = templates.Render(w, "admin-tareas.html", res)
+		if err != nil {
+			log.Error("error renderizando la página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+)
+
+// Devuelve a la cola una tarea que agotó sus intentos
+func (s *Server) handleAdminReintentarTarea() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			log.Error("id de tarea no válido: %s", err.Error())
+			s.handleError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		if err := s.store.tarea.Reintentar(id); err != nil {
+			log.Error("error reintentando tarea %d: %s", id, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		s.despertarTrabajadores()
+
+		log.Information("%s reintentó la tarea %d", sess.Autor_id, id)
+		w.Header().Add("Location", "/admin/tareas")
+		w.WriteHeader(303)
+	}
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"bytes"
 	"database/sql"
 	_ "embed"
 	"github.com/go-playground/validator/v10"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"regexp"
 	"vigo360.es/new/internal/database"
+	"vigo360.es/new/internal/imagenes"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
 	"vigo360.es/new/internal/models"
@@ -30,7 +32,7 @@ func (s *Server) handleAdminCreateWork() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
 		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
 
 		var artAutor = sess.Autor_id
@@ -102,6 +104,11 @@ func (s *Server) handleAdminCreateWork() http.HandlerFunc {
 			return
 		}
 
+		// Las versiones de la portada por defecto se generan en segundo plano como las de cualquier otra
+		if err := s.encolarPortada(bytes.NewReader(defaultImageJPG), fi.WorkId, imagenes.FocoCentro); err != nil {
+			log.Error("error encolando portada por defecto: %s", err.Error())
+		}
+
 		w.Header().Add("Location", "/admin/works/"+fi.WorkId)
 		w.WriteHeader(303)
 	}
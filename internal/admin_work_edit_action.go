@@ -11,6 +11,7 @@ import (
 	"vigo360.es/new/internal/database"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
 )
 
 func (s *Server) handleAdminEditWorkAction() http.HandlerFunc {
@@ -22,7 +23,8 @@ func (s *Server) handleAdminEditWorkAction() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
 		trabajoId := mux.Vars(r)["id"]
 
 		_, err := s.store.trabajo.ObtenerPorId(trabajoId, false)
@@ -79,6 +81,25 @@ func (s *Server) handleAdminEditWorkAction() http.HandlerFunc {
 			return
 		}
 
+		err = s.store.revision.Guardar(tx, models.Revision{
+			Tipo:        models.RevisionTrabajo,
+			Entidad_id:  trabajoId,
+			Titulo:      strings.TrimSpace(fi.Titulo),
+			Resumen:     strings.TrimSpace(fi.Resumen),
+			Contenido:   strings.TrimSpace(fi.Contenido),
+			Alt_portada: strings.TrimSpace(fi.AltPortada),
+			Autor_id:    sess.Autor_id,
+		})
+		if err != nil {
+			e2 := tx.Rollback()
+			if e2 != nil {
+				s.handleError(r, w, 500, messages.ErrorDatos)
+			}
+			log.Error("error guardando revisión: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
 		if r.FormValue("publicar") == "on" {
 			// TODO: Update this above with the others
 			query := `UPDATE trabajos SET fecha_publicacion=NOW() WHERE id=?`
@@ -104,7 +125,11 @@ func (s *Server) handleAdminEditWorkAction() http.HandlerFunc {
 
 		// Image uploaded
 		if !errors.Is(err, http.ErrMissingFile) {
-			encodeImagesAndSave(portada_file, trabajoId)
+			if err := s.encolarPortada(portada_file, trabajoId, focoDesdeFormulario(r.FormValue("foco-x"), r.FormValue("foco-y"))); err != nil {
+				log.Error("error guardando portada para procesar: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+				return
+			}
 		}
 
 		defer w.WriteHeader(303)
@@ -19,7 +19,7 @@ func (s *Server) handleAdminEditWorkPage() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
 		sess := r.Context().Value(sessionContextKey("sess")).(models.Session)
 		postId := mux.Vars(r)["id"]
 
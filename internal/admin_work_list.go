@@ -31,7 +31,7 @@ func (s *Server) handleAdminListWorks() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
 		sess := r.Context().Value(sessionContextKey("sess")).(models.Session)
 		db := database.GetDB()
 		trabajos := []ResumenTrabajo{}
@@ -0,0 +1,219 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+)
+
+/*
+API pública de solo lectura en /api/v1. Los nombres de los campos JSON forman parte del contrato con
+la aplicación móvil y las webs asociadas, así que no se cambian ni se eliminan dentro de una versión:
+si hace falta algo distinto se añade un campo nuevo o se crea /api/v2.
+*/
+
+const (
+	apiPorPaginaDefecto = 20
+	apiPorPaginaMaximo  = 100
+)
+
+var errorApiParametro messages.ErrorMessage = "Alguno de los parámetros de la petición no es válido."
+var errorApiRetirada messages.ErrorMessage = "Esta publicación ha sido retirada por motivos legales."
+
+var errParametroApi = errors.New("parámetro de paginación no válido")
+
+type apiAutorResumen struct {
+	Id     string `json:"id"`
+	Nombre string `json:"nombre"`
+	Url    string `json:"url"`
+}
+
+type apiAutor struct {
+	Id        string `json:"id"`
+	Nombre    string `json:"nombre"`
+	Biografia string `json:"biografia"`
+	Url       string `json:"url"`
+}
+
+type apiTagResumen struct {
+	Id     string `json:"id"`
+	Nombre string `json:"nombre"`
+}
+
+type apiTag struct {
+	Id            string `json:"id"`
+	Nombre        string `json:"nombre"`
+	Publicaciones int    `json:"publicaciones"`
+	Url           string `json:"url"`
+}
+
+type apiPublicacion struct {
+	Id                  string          `json:"id"`
+	Titulo              string          `json:"titulo"`
+	Resumen             string          `json:"resumen"`
+	Fecha_publicacion   string          `json:"fecha_publicacion"`
+	Fecha_actualizacion string          `json:"fecha_actualizacion"`
+	Autor               apiAutorResumen `json:"autor"`
+	Tags                []apiTagResumen `json:"tags"`
+	Serie_id            string          `json:"serie_id,omitempty"`
+	Portada             string          `json:"portada"`
+	Alt_portada         string          `json:"alt_portada"`
+	Url                 string          `json:"url"`
+	// Solo en el detalle de una publicación
+	Contenido_html string `json:"contenido_html,omitempty"`
+}
+
+type apiTrabajo struct {
+	Id                  string          `json:"id"`
+	Titulo              string          `json:"titulo"`
+	Resumen             string          `json:"resumen"`
+	Fecha_publicacion   string          `json:"fecha_publicacion"`
+	Fecha_actualizacion string          `json:"fecha_actualizacion"`
+	Autor               apiAutorResumen `json:"autor"`
+	Url                 string          `json:"url"`
+}
+
+type apiComentario struct {
+	Id             string `json:"id"`
+	Padre_id       string `json:"padre_id,omitempty"`
+	Nombre         string `json:"nombre"`
+	Es_autor       bool   `json:"es_autor"`
+	Autor_original bool   `json:"autor_original"`
+	Contenido      string `json:"contenido"`
+	Contenido_html string `json:"contenido_html"`
+	Fecha_creacion string `json:"fecha_creacion"`
+}
+
+// Respuesta de los listados paginados
+type apiPagina[T any] struct {
+	Datos      []T `json:"datos"`
+	Pagina     int `json:"pagina"`
+	Por_pagina int `json:"por_pagina"`
+	Paginas    int `json:"paginas"`
+	Total      int `json:"total"`
+}
+
+// Convierte una fecha de MySQL a RFC3339, o la deja vacía si no tiene
+func fechaApi(fecha string) string {
+	t, err := time.Parse("2006-01-02 15:04:05", fecha)
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func nuevoApiAutorResumen(a models.Autor) apiAutorResumen {
+	return apiAutorResumen{
+		Id:     a.Id,
+		Nombre: a.Nombre,
+		Url:    fullCanonica("/autores/" + a.Id),
+	}
+}
+
+func nuevoApiPublicacion(p models.Publicacion) apiPublicacion {
+	var tags = make([]apiTagResumen, 0, len(p.Tags))
+	for _, t := range p.Tags {
+		// ObtenerPorId devuelve una etiqueta vacía si la publicación no tiene ninguna
+		if t.Id != "" {
+			tags = append(tags, apiTagResumen{Id: t.Id, Nombre: t.Nombre})
+		}
+	}
+
+	return apiPublicacion{
+		Id:                  p.Id,
+		Titulo:              p.Titulo,
+		Resumen:             p.Resumen,
+		Fecha_publicacion:   fechaApi(p.Fecha_publicacion),
+		Fecha_actualizacion: fechaApi(p.Fecha_actualizacion),
+		Autor:               nuevoApiAutorResumen(p.Autor),
+		Tags:                tags,
+		Serie_id:            p.Serie_id,
+		Portada:             fullCanonica("/static/images/" + p.Id + ".webp"),
+		Alt_portada:         p.Alt_portada,
+		Url:                 fullCanonica("/post/" + p.Id),
+	}
+}
+
+/*
+Lee ?pagina= y ?por_pagina= y recorta la lista. Una página más allá del final devuelve una lista vacía,
+para que los clientes puedan iterar hasta no recibir datos sin tratar un error.
+*/
+func paginarApi[T any](r *http.Request, datos []T) (apiPagina[T], error) {
+	var pagina, porPagina = 1, apiPorPaginaDefecto
+	var err error
+
+	if raw := r.URL.Query().Get("pagina"); raw != "" {
+		if pagina, err = strconv.Atoi(raw); err != nil || pagina < 1 {
+			return apiPagina[T]{}, errParametroApi
+		}
+	}
+	if raw := r.URL.Query().Get("por_pagina"); raw != "" {
+		if porPagina, err = strconv.Atoi(raw); err != nil || porPagina < 1 || porPagina > apiPorPaginaMaximo {
+			return apiPagina[T]{}, errParametroApi
+		}
+	}
+
+	var inicio = getMinimo((pagina-1)*porPagina, len(datos))
+	var fin = getMinimo(inicio+porPagina, len(datos))
+
+	var resultado = make([]T, fin-inicio)
+	copy(resultado, datos[inicio:fin])
+
+	return apiPagina[T]{
+		Datos:      resultado,
+		Pagina:     pagina,
+		Por_pagina: porPagina,
+		Paginas:    (len(datos) + porPagina - 1) / porPagina,
+		Total:      len(datos),
+	}, nil
+}
+
+/*
+Escribe la respuesta en JSON con un ETag calculado sobre el propio cuerpo. Si el cliente ya tiene esa
+versión (If-None-Match) se responde 304 sin cuerpo.
+*/
+func escribirApi(w http.ResponseWriter, r *http.Request, datos any) error {
+	// Sin escapar <, > y & para que contenido_html se pueda leer tal cual
+	var buf bytes.Buffer
+	var encoder = json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(datos); err != nil {
+		return err
+	}
+	var resbytes = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+
+	var suma = sha256.Sum256(resbytes)
+	var etag = `"` + hex.EncodeToString(suma[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if coincideEtag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	_, err := w.Write(resbytes)
+	return err
+}
+
+func coincideEtag(cabecera string, etag string) bool {
+	for _, candidato := range strings.Split(cabecera, ",") {
+		candidato = strings.TrimPrefix(strings.TrimSpace(candidato), "W/")
+		if candidato == etag || candidato == "*" {
+			return true
+		}
+	}
+	return false
+}
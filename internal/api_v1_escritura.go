@@ -0,0 +1,416 @@
+package internal
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/imagenes"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+)
+
+/*
+Escritura de publicaciones con un token de la API, para publicar desde un script. Las peticiones y respuestas son
+JSON salvo la portada, que se envía tal cual en el cuerpo. Cada operación necesita su alcance (ver withAlcance).
+*/
+
+const (
+	// Un artículo largo en Markdown ocupa unos pocos cientos de KB
+	maxCuerpoApi   = 2 << 20
+	maxPortadaApi  = 25 << 20
+	alternativaApi = "CAMBIAME"
+)
+
+var errorApiCuerpo messages.ErrorMessage = "El cuerpo de la petición no es un JSON válido o tiene campos desconocidos."
+var errorApiIncompleta messages.ErrorMessage = "La publicación necesita título, resumen, contenido y texto alternativo de la portada antes de publicarse."
+var errorApiYaPublicada messages.ErrorMessage = "La publicación ya está publicada y su fecha no se puede cambiar."
+var errorApiFecha messages.ErrorMessage = "La fecha tiene que estar en formato RFC 3339, como 2006-01-02T15:04:05+02:00, y no puede haber pasado. Para publicar ahora, no se envía fecha."
+var errorApiNoPublicada messages.ErrorMessage = "La publicación ya es un borrador o está retirada por motivos legales."
+var errorApiTagDesconocida messages.ErrorMessage = "Alguna de las etiquetas no existe."
+var errorApiPortada messages.ErrorMessage = "La portada tiene que ser una imagen JPEG, PNG, WebP o AVIF de como mucho 25 MB."
+
+// Publicación con los datos que hacen falta para editarla, incluidos los borradores
+type apiPublicacionEditable struct {
+	Id          string   `json:"id"`
+	Titulo      string   `json:"titulo"`
+	Resumen     string   `json:"resumen"`
+	Contenido   string   `json:"contenido"`
+	Alt_portada string   `json:"alt_portada"`
+	Tags        []string `json:"tags"`
+	// borrador, programada, publicada o retirada
+	Estado            string `json:"estado"`
+	Fecha_publicacion string `json:"fecha_publicacion,omitempty"`
+	Url               string `json:"url"`
+}
+
+type apiEntradaContenido struct {
+	Titulo      string   `json:"titulo"`
+	Resumen     string   `json:"resumen"`
+	Contenido   string   `json:"contenido"`
+	Alt_portada string   `json:"alt_portada"`
+	Tags        []string `json:"tags"`
+}
+
+func (e apiEntradaContenido) contenido() contenidoPublicacion {
+	var tags = e.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return contenidoPublicacion{
+		Titulo:     e.Titulo,
+		Resumen:    e.Resumen,
+		Contenido:  e.Contenido,
+		AltPortada: e.Alt_portada,
+		Tags:       tags,
+	}
+}
+
+func nuevoApiPublicacionEditable(p models.Publicacion) apiPublicacionEditable {
+	var estado = "borrador"
+	switch {
+	case p.Legally_retired_at != "":
+		estado = "retirada"
+	case p.EstaPublicada():
+		estado = "publicada"
+	case p.Fecha_publicacion != "":
+		estado = "programada"
+	}
+
+	var tags = make([]string, 0, len(p.Tags))
+	for _, t := range p.Tags {
+		if t.Id != "" {
+			tags = append(tags, t.Id)
+		}
+	}
+
+	return apiPublicacionEditable{
+		Id:                p.Id,
+		Titulo:            p.Titulo,
+		Resumen:           p.Resumen,
+		Contenido:         p.Contenido,
+		Alt_portada:       p.Alt_portada,
+		Tags:              tags,
+		Estado:            estado,
+		Fecha_publicacion: fechaApi(p.Fecha_publicacion),
+		Url:               fullCanonica("/post/" + p.Id),
+	}
+}
+
+// Lee el cuerpo JSON de la petición sin aceptar campos que no se esperan, para que una errata no se ignore
+func leerJsonApi(w http.ResponseWriter, r *http.Request, destino any) error {
+	var decoder = json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCuerpoApi))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(destino)
+}
+
+// Responde con el estado actual de la publicación, tras haberla modificado
+func (s *Server) responderPublicacionEditable(w http.ResponseWriter, r *http.Request, id string, status int) {
+	log := logger.DesdeContexto(r.Context())
+
+	publicacion, err := s.store.publicacion.ObtenerPorId(id, false)
+	if err != nil {
+		log.Error("error recuperando publicación %s: %s", id, err.Error())
+		s.handleJsonError(r, w, 500, messages.ErrorDatos)
+		return
+	}
+
+	resbytes, err := json.MarshalIndent(nuevoApiPublicacionEditable(publicacion), "", "\t")
+	if err != nil {
+		log.Error("error escribiendo json de respuesta: %s", err.Error())
+		s.handleJsonError(r, w, 500, messages.ErrorRender)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(resbytes)
+}
+
+// Obtiene una publicación cualquiera sea su estado, o responde 404
+func (s *Server) obtenerPublicacionEditable(w http.ResponseWriter, r *http.Request) (models.Publicacion, bool) {
+	log := logger.DesdeContexto(r.Context())
+
+	publicacion, err := s.store.publicacion.ObtenerPorId(mux.Vars(r)["id"], false)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.handleJsonError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+		return models.Publicacion{}, false
+	} else if err != nil {
+		log.Error("error recuperando publicación: %s", err.Error())
+		s.handleJsonError(r, w, 500, messages.ErrorDatos)
+		return models.Publicacion{}, false
+	}
+	return publicacion, true
+}
+
+func (s *Server) handleApiObtenerEdicion() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if publicacion, ok := s.obtenerPublicacionEditable(w, r); ok {
+			s.responderPublicacionEditable(w, r, publicacion.Id, 200)
+		}
+	}
+}
+
+/*
+Crea un borrador con {"id", "titulo"}. Si además se envía el resto del contenido se guarda en la misma petición,
+con las mismas comprobaciones que al editarla.
+*/
+func (s *Server) handleApiCrearPublicacion() http.HandlerFunc {
+	type entrada struct {
+		Id string `json:"id"`
+		apiEntradaContenido
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		var e entrada
+		if err := leerJsonApi(w, r, &e); err != nil {
+			log.Warning("cuerpo no válido creando publicación: %s", err.Error())
+			s.handleJsonError(r, w, 400, errorApiCuerpo)
+			return
+		}
+
+		if !postIdRegexp.MatchString(e.Id) {
+			s.handleJsonError(r, w, 400, messages.ErrorIdInvalido)
+			return
+		}
+		if err := validator.New().Var(e.Titulo, "required,min=3,max=80"); err != nil {
+			s.handleJsonError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+
+		var conContenido = e.Resumen != "" || e.Contenido != "" || e.Alt_portada != "" || len(e.Tags) > 0
+		if conContenido {
+			if err := validator.New().Struct(e.contenido()); err != nil {
+				log.Warning("error validando publicación: %s", err.Error())
+				s.handleJsonError(r, w, 400, messages.ErrorValidacion)
+				return
+			}
+			if ok, err := s.comprobarTags(e.Tags); err != nil {
+				log.Error("error recuperando tags: %s", err.Error())
+				s.handleJsonError(r, w, 500, messages.ErrorDatos)
+				return
+			} else if !ok {
+				s.handleJsonError(r, w, 400, errorApiTagDesconocida)
+				return
+			}
+		}
+
+		if err := s.crearPublicacion(e.Id, e.Titulo, sess.Autor_id); errors.Is(err, errPublicacionDuplicada) {
+			s.handleJsonError(r, w, 409, messages.ErrorIdDuplicado)
+			return
+		} else if errors.Is(err, errPortadaNoEncolada) {
+			log.Error("%s", err.Error())
+		} else if err != nil {
+			log.Error("error creando artículo: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		if conContenido {
+			if err := s.guardarContenido(e.Id, e.contenido(), sess.Autor_id); err != nil {
+				log.Error("error guardando contenido de %s: %s", e.Id, err.Error())
+				s.handleJsonError(r, w, 500, messages.ErrorDatos)
+				return
+			}
+		}
+
+		log.Information("%s creó la publicación %s desde la API", sess.Autor_id, e.Id)
+		w.Header().Add("Location", "/api/v1/publicaciones/"+e.Id+"/edicion")
+		s.responderPublicacionEditable(w, r, e.Id, 201)
+	}
+}
+
+// Sustituye todo el contenido de una publicación. Los campos que no se envíen se quedan vacíos y no pasan la validación
+func (s *Server) handleApiEditarPublicacion() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		publicacion, ok := s.obtenerPublicacionEditable(w, r)
+		if !ok {
+			return
+		}
+
+		var e apiEntradaContenido
+		if err := leerJsonApi(w, r, &e); err != nil {
+			log.Warning("cuerpo no válido editando %s: %s", publicacion.Id, err.Error())
+			s.handleJsonError(r, w, 400, errorApiCuerpo)
+			return
+		}
+		if err := validator.New().Struct(e.contenido()); err != nil {
+			log.Warning("error validando publicación: %s", err.Error())
+			s.handleJsonError(r, w, 400, messages.ErrorValidacion)
+			return
+		}
+		if ok, err := s.comprobarTags(e.Tags); err != nil {
+			log.Error("error recuperando tags: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		} else if !ok {
+			s.handleJsonError(r, w, 400, errorApiTagDesconocida)
+			return
+		}
+
+		if err := s.guardarContenido(publicacion.Id, e.contenido(), sess.Autor_id); err != nil {
+			log.Error("error guardando contenido de %s: %s", publicacion.Id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s editó la publicación %s desde la API", sess.Autor_id, publicacion.Id)
+		s.responderPublicacionEditable(w, r, publicacion.Id, 200)
+	}
+}
+
+// Tiempo que puede haber pasado desde la fecha enviada al publicar antes de rechazarla
+const margenFechaApi = time.Minute
+
+/*
+Publica ahora, o programa con {"fecha": "2006-01-02T15:04:05+02:00"}, una publicación que aún no ha salido. Una fecha
+pasada se rechaza en lugar de publicar ahora, por si era un error. Como en el panel, una vez publicada su fecha ya no
+se puede cambiar.
+*/
+func (s *Server) handleApiPublicarPublicacion() http.HandlerFunc {
+	type entrada struct {
+		Fecha string `json:"fecha"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		publicacion, ok := s.obtenerPublicacionEditable(w, r)
+		if !ok {
+			return
+		}
+
+		var e entrada
+		if err := leerJsonApi(w, r, &e); err != nil && r.ContentLength != 0 {
+			log.Warning("cuerpo no válido publicando %s: %s", publicacion.Id, err.Error())
+			s.handleJsonError(r, w, 400, errorApiCuerpo)
+			return
+		}
+
+		var fecha = time.Now()
+		if e.Fecha != "" {
+			f, err := time.Parse(time.RFC3339, e.Fecha)
+			// Se admite un minuto de margen para quien envía la hora actual y tarda en llegar
+			if err != nil || f.Before(fecha.Add(-margenFechaApi)) {
+				log.Warning("fecha no válida publicando %s: %s", publicacion.Id, e.Fecha)
+				s.handleJsonError(r, w, 400, errorApiFecha)
+				return
+			}
+			if f.After(fecha) {
+				fecha = f
+			}
+		}
+
+		var actual = contenidoPublicacion{
+			Titulo:     publicacion.Titulo,
+			Resumen:    publicacion.Resumen,
+			Contenido:  publicacion.Contenido,
+			AltPortada: publicacion.Alt_portada,
+		}
+		if err := validator.New().Struct(actual); err != nil || publicacion.Alt_portada == alternativaApi {
+			s.handleJsonError(r, w, 422, errorApiIncompleta)
+			return
+		}
+
+		if err := s.store.publicacion.Publicar(publicacion.Id, fecha); errors.Is(err, sql.ErrNoRows) {
+			s.handleJsonError(r, w, 409, errorApiYaPublicada)
+			return
+		} else if err != nil {
+			log.Error("error publicando %s: %s", publicacion.Id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		// Si se ha publicado ahora, que el programador notifique a IndexNow sin esperar
+		s.despertarProgramador()
+		s.despertarTrabajadores()
+
+		log.Information("%s publicó %s desde la API para %s", sess.Autor_id, publicacion.Id, fecha.Format(time.RFC3339))
+		s.responderPublicacionEditable(w, r, publicacion.Id, 200)
+	}
+}
+
+/*
+Devuelve a borrador una publicación publicada o programada, que deja de aparecer en la web y se puede volver a
+publicar. La retirada por motivos legales, que es permanente y hace que la página responda 451, no está en la API.
+*/
+func (s *Server) handleApiDespublicarPublicacion() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		publicacion, ok := s.obtenerPublicacionEditable(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.store.publicacion.Despublicar(publicacion.Id); errors.Is(err, sql.ErrNoRows) {
+			s.handleJsonError(r, w, 409, errorApiNoPublicada)
+			return
+		} else if err != nil {
+			log.Error("error despublicando %s: %s", publicacion.Id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		s.despertarTrabajadores()
+
+		log.Notice("%s devolvió la publicación %s a borrador desde la API", sess.Autor_id, publicacion.Id)
+		s.responderPublicacionEditable(w, r, publicacion.Id, 200)
+	}
+}
+
+/*
+Sube una portada nueva, enviando la imagen tal cual en el cuerpo. El punto de interés para los recortes se indica en
+porcentaje con ?foco_x= y ?foco_y=. Las versiones se generan en segundo plano, así que responde 202.
+*/
+func (s *Server) handleApiSubirPortada() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		publicacion, ok := s.obtenerPublicacionEditable(w, r)
+		if !ok {
+			return
+		}
+
+		datos, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPortadaApi))
+		var demasiado *http.MaxBytesError
+		if errors.As(err, &demasiado) {
+			s.handleJsonError(r, w, 413, errorApiPortada)
+			return
+		} else if err != nil {
+			log.Error("error leyendo portada de %s: %s", publicacion.Id, err.Error())
+			s.handleJsonError(r, w, 400, errorApiPortada)
+			return
+		}
+
+		// Se comprueba ya para poder avisar al script, en vez de que falle la tarea en segundo plano
+		if _, err := imagenes.Decodificar(datos); err != nil {
+			log.Warning("portada no válida para %s: %s", publicacion.Id, err.Error())
+			s.handleJsonError(r, w, 415, errorApiPortada)
+			return
+		}
+
+		var foco = focoDesdeFormulario(r.URL.Query().Get("foco_x"), r.URL.Query().Get("foco_y"))
+		if err := s.encolarPortada(bytes.NewReader(datos), publicacion.Id, foco); err != nil {
+			log.Error("error guardando portada de %s para procesar: %s", publicacion.Id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		log.Information("%s subió una portada para %s desde la API", sess.Autor_id, publicacion.Id)
+		s.responderPublicacionEditable(w, r, publicacion.Id, 202)
+	}
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/models"
+)
+
+// Guarda la fecha con la que se publicó cada publicación
+type memPublicarStore struct {
+	memPublicacionStore
+	publicadas map[string]time.Time
+}
+
+func (s *memPublicarStore) Publicar(id string, fecha time.Time) error {
+	s.publicadas[id] = fecha
+	return nil
+}
+
+func (s *memPublicarStore) Despublicar(id string) error {
+	if _, ok := s.publicadas[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(s.publicadas, id)
+	return nil
+}
+
+func publicarDesdeApi(t *testing.T, cuerpo string) (*httptest.ResponseRecorder, *memPublicarStore) {
+	t.Helper()
+	var pstore = &memPublicarStore{
+		memPublicacionStore: memPublicacionStore{publicaciones: map[string]models.Publicacion{
+			"faro": {Id: "faro", Titulo: "El faro", Resumen: "Una visita", Contenido: "Texto", Alt_portada: "Un faro"},
+		}},
+		publicadas: map[string]time.Time{},
+	}
+	var s = &Server{store: &Container{publicacion: pstore}}
+
+	var r = httptest.NewRequest(http.MethodPost, "/api/v1/publicaciones/faro/publicar", strings.NewReader(cuerpo))
+	r = mux.SetURLVars(r, map[string]string{"id": "faro"})
+	r = r.WithContext(context.WithValue(r.Context(), ridContextKey("rid"), "prueba"))
+
+	var w = httptest.NewRecorder()
+	s.handleApiPublicarPublicacion()(w, r)
+	return w, pstore
+}
+
+func TestApiPublicarRechazaFechaPasada(t *testing.T) {
+	var ayer = time.Now().Add(-24 * time.Hour).Format(time.RFC3339)
+	w, pstore := publicarDesdeApi(t, `{"fecha": "`+ayer+`"}`)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "RFC 3339") {
+		t.Fatalf("se esperaba 400 con el formato aceptado, se obtuvo %d %s", w.Code, w.Body.String())
+	}
+	if len(pstore.publicadas) != 0 {
+		t.Fatal("una fecha pasada no debe publicar la publicación")
+	}
+}
+
+func TestApiPublicarRechazaFormatoIncorrecto(t *testing.T) {
+	w, pstore := publicarDesdeApi(t, `{"fecha": "2099-01-02 10:00"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "RFC 3339") || len(pstore.publicadas) != 0 {
+		t.Fatalf("se esperaba 400 con el formato aceptado, se obtuvo %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestApiPublicarProgramaFechaFutura(t *testing.T) {
+	var fecha = time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	_, pstore := publicarDesdeApi(t, `{"fecha": "`+fecha.Format(time.RFC3339)+`"}`)
+	if !pstore.publicadas["faro"].Equal(fecha) {
+		t.Fatalf("se esperaba programarla para %s, se obtuvo %s", fecha, pstore.publicadas["faro"])
+	}
+}
+
+func TestApiPublicarSinFechaPublicaAhora(t *testing.T) {
+	_, pstore := publicarDesdeApi(t, ``)
+	if f, ok := pstore.publicadas["faro"]; !ok || time.Since(f) > time.Minute {
+		t.Fatalf("se esperaba publicarla ahora, se obtuvo %s", f)
+	}
+}
+
+func TestApiDespublicarVuelveABorrador(t *testing.T) {
+	_, pstore := publicarDesdeApi(t, ``)
+	var s = &Server{store: &Container{publicacion: pstore}}
+
+	var despublicar = func() *httptest.ResponseRecorder {
+		var r = httptest.NewRequest(http.MethodPost, "/api/v1/publicaciones/faro/despublicar", nil)
+		r = mux.SetURLVars(r, map[string]string{"id": "faro"})
+		r = r.WithContext(context.WithValue(r.Context(), ridContextKey("rid"), "prueba"))
+		var w = httptest.NewRecorder()
+		s.handleApiDespublicarPublicacion()(w, r)
+		return w
+	}
+
+	if w := despublicar(); w.Code != http.StatusOK {
+		t.Fatalf("se esperaba 200, se obtuvo %d %s", w.Code, w.Body.String())
+	}
+	if _, ok := pstore.publicadas["faro"]; ok {
+		t.Fatal("la publicación debería haber vuelto a borrador")
+	}
+	if w := despublicar(); w.Code != http.StatusConflict {
+		t.Fatalf("un borrador no se puede despublicar, se esperaba 409 y se obtuvo %d", w.Code)
+	}
+}
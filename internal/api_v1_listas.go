@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+)
+
+// Lista todas las etiquetas, ordenadas por nombre
+func (s *Server) handleApiListarTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		tags, err := s.store.tag.Listar()
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Error("error recuperando tags: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		// El store los devuelve desde un mapa, así que sin ordenar cambiaría el ETag en cada petición
+		sort.Slice(tags, func(i, j int) bool { return tags[i].Nombre < tags[j].Nombre })
+
+		var resultado = make([]apiTag, 0, len(tags))
+		for _, t := range tags {
+			resultado = append(resultado, apiTag{
+				Id:            t.Id,
+				Nombre:        t.Nombre,
+				Publicaciones: t.Publicaciones,
+				Url:           fullCanonica("/tags/" + t.Id),
+			})
+		}
+
+		if err := escribirApi(w, r, resultado); err != nil {
+			log.Error("error escribiendo json de respuesta: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
+
+// Lista los autores, sin su correo electrónico ni ningún otro dato interno
+func (s *Server) handleApiListarAutores() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		autores, err := s.store.autor.Listar()
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Error("error recuperando autores: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		var resultado = make([]apiAutor, 0, len(autores))
+		for _, a := range autores {
+			resultado = append(resultado, apiAutor{
+				Id:        a.Id,
+				Nombre:    a.Nombre,
+				Biografia: a.Biografia,
+				Url:       fullCanonica("/autores/" + a.Id),
+			})
+		}
+
+		if err := escribirApi(w, r, resultado); err != nil {
+			log.Error("error escribiendo json de respuesta: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
+
+// Lista los trabajos ya publicados, paginados. Se puede filtrar con ?autor=
+func (s *Server) handleApiListarTrabajos() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		trabajos, err := s.store.trabajo.Listar()
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Error("error recuperando trabajos: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		var autor = r.URL.Query().Get("autor")
+		var resultado = make([]apiTrabajo, 0, len(trabajos))
+		for _, t := range trabajos.FiltrarPublicos() {
+			if autor != "" && t.Autor.Id != autor {
+				continue
+			}
+			resultado = append(resultado, apiTrabajo{
+				Id:                  t.Id,
+				Titulo:              t.Titulo,
+				Resumen:             t.Resumen,
+				Fecha_publicacion:   fechaApi(t.Fecha_publicacion),
+				Fecha_actualizacion: fechaApi(t.Fecha_actualizacion),
+				Autor:               nuevoApiAutorResumen(t.Autor),
+				Url:                 fullCanonica("/trabajos/" + t.Id),
+			})
+		}
+
+		pagina, err := paginarApi(r, resultado)
+		if err != nil {
+			s.handleJsonError(r, w, 400, errorApiParametro)
+			return
+		}
+
+		if err := escribirApi(w, r, pagina); err != nil {
+			log.Error("error escribiendo json de respuesta: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
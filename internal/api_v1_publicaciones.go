@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+// Lista las publicaciones públicas, de la más reciente a la más antigua. Se puede filtrar con ?tag= y ?autor=
+func (s *Server) handleApiListarPublicaciones() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		var (
+			publicaciones models.Publicaciones
+			err           error
+			tag           = r.URL.Query().Get("tag")
+			autor         = r.URL.Query().Get("autor")
+		)
+		switch {
+		case tag != "":
+			publicaciones, err = s.store.publicacion.ListarPorTag(tag)
+		case autor != "":
+			publicaciones, err = s.store.publicacion.ListarPorAutor(autor)
+		default:
+			publicaciones, err = s.store.publicacion.Listar()
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Error("error recuperando publicaciones: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		publicaciones = publicaciones.FiltrarPublicas().FiltrarRetiradas()
+
+		var resultado = make([]apiPublicacion, 0, len(publicaciones))
+		for _, p := range publicaciones {
+			// Con ?tag= y ?autor= a la vez se aplican los dos filtros
+			if autor != "" && p.Autor.Id != autor {
+				continue
+			}
+			resultado = append(resultado, nuevoApiPublicacion(p))
+		}
+
+		pagina, err := paginarApi(r, resultado)
+		if err != nil {
+			s.handleJsonError(r, w, 400, errorApiParametro)
+			return
+		}
+
+		if err := escribirApi(w, r, pagina); err != nil {
+			log.Error("error escribiendo json de respuesta: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
+
+/*
+Obtiene una publicación pública, en la misma forma que en el listado y con el contenido ya convertido a HTML.
+Las programadas para el futuro no existen; las retiradas por motivos legales devuelven 451 sin contenido.
+*/
+func (s *Server) obtenerPublicacionApi(w http.ResponseWriter, r *http.Request) (models.Publicacion, bool) {
+	log := logger.DesdeContexto(r.Context())
+
+	publicacion, err := s.store.publicacion.ObtenerPorId(mux.Vars(r)["id"], true)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.handleJsonError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+		} else {
+			log.Error("error recuperando publicación: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+		}
+		return models.Publicacion{}, false
+	}
+
+	if !publicacion.EstaPublicada() {
+		s.handleJsonError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+		return models.Publicacion{}, false
+	}
+
+	if publicacion.Legally_retired_at != "" {
+		s.handleJsonError(r, w, 451, errorApiRetirada)
+		return models.Publicacion{}, false
+	}
+
+	return publicacion, true
+}
+
+func (s *Server) handleApiObtenerPublicacion() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		publicacion, ok := s.obtenerPublicacionApi(w, r)
+		if !ok {
+			return
+		}
+
+		var resultado = nuevoApiPublicacion(publicacion)
+		html, err := templates.Markdown(publicacion.Contenido)
+		if err != nil {
+			log.Error("error convirtiendo markdown de %s: %s", publicacion.Id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorRender)
+			return
+		}
+		resultado.Contenido_html = html
+
+		if err := escribirApi(w, r, resultado); err != nil {
+			log.Error("error escribiendo json de respuesta: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
+
+// Lista los comentarios aprobados de una publicación en orden cronológico. Las respuestas llevan padre_id
+func (s *Server) handleApiListarComentarios() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+
+		publicacion, ok := s.obtenerPublicacionApi(w, r)
+		if !ok {
+			return
+		}
+
+		comentarios, err := s.store.comentario.ListarPublicos(publicacion.Id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Error("error recuperando comentarios de %s: %s", publicacion.Id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		var resultado = make([]apiComentario, 0, len(comentarios))
+		for _, c := range comentarios {
+			html, err := templates.Markdown(c.Contenido)
+			if err != nil {
+				log.Error("error convirtiendo markdown del comentario %s: %s", c.Id, err.Error())
+				s.handleJsonError(r, w, 500, messages.ErrorRender)
+				return
+			}
+
+			resultado = append(resultado, apiComentario{
+				Id:             c.Id,
+				Padre_id:       c.Padre_id,
+				Nombre:         c.Nombre,
+				Es_autor:       c.Es_autor,
+				Autor_original: c.Autor_original,
+				Contenido:      c.Contenido,
+				Contenido_html: html,
+				Fecha_creacion: fechaApi(c.Fecha_creacion),
+			})
+		}
+
+		if err := escribirApi(w, r, resultado); err != nil {
+			log.Error("error escribiendo json de respuesta: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
@@ -14,7 +14,7 @@ func (s *Server) getSession(token string) (models.Session, error) {
 	var session models.Session
 	// TODO: Refactor esto
 	var db = database.GetDB()
-	err := db.QueryRowx("SELECT sessid as id, iniciada, id as autor_id, nombre as autor_nombre, rol as autor_rol FROM sesiones LEFT JOIN autores ON sesiones.autor_id = autores.id WHERE sessid = ? AND revocada = false;", token).StructScan(&session)
+	err := db.QueryRowx("SELECT sessid as id, iniciada, id as autor_id, nombre as autor_nombre, rol as autor_rol FROM sesiones LEFT JOIN autores ON sesiones.autor_id = autores.id WHERE sessid = ? AND revocada = false AND autores.activo = true;", token).StructScan(&session)
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
 			return models.Session{}, models.ErrInvalidSession
@@ -34,20 +34,10 @@ func (s *Server) getSession(token string) (models.Session, error) {
 		return models.Session{}, models.ErrExpiredSession
 	}
 
-	session.Permisos = make(map[string]bool)
-	perms, err := db.Queryx("SELECT permiso_id FROM permisos_usuarios WHERE autor_id = ?;", session.Autor_id)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+	session.Permisos, err = s.store.rol.PermisosDeAutor(session.Autor_id)
+	if err != nil {
 		return models.Session{}, models.ErrUnablePermissions
 	}
 
-	for perms.Next() {
-		var p string
-		err = perms.Scan(&p)
-		if err != nil {
-			continue
-		}
-		session.Permisos[p] = true
-	}
-
 	return session, nil
 }
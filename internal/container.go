@@ -2,26 +2,58 @@ package internal
 
 import (
 	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/correo"
 	"vigo360.es/new/internal/repository"
+	"vigo360.es/new/internal/search"
 )
 
 // Un container incluye los repositorios para todos los tipos a los que va a acceder el servidor
 type Container struct {
-	autor       repository.AutorStore
-	aviso       repository.AvisoStore
-	publicacion repository.PublicacionStore
-	tag         repository.TagStore
-	trabajo     repository.TrabajoStore
-	comentario  repository.ComentarioStore
+	autor         repository.AutorStore
+	aviso         repository.AvisoStore
+	publicacion   repository.PublicacionStore
+	tag           repository.TagStore
+	trabajo       repository.TrabajoStore
+	comentario    repository.ComentarioStore
+	serie         repository.SerieStore
+	revision      repository.RevisionStore
+	tarea         repository.TareaStore
+	media         repository.MediaStore
+	adjunto       repository.AdjuntoStore
+	rol           repository.RolStore
+	sesion        repository.SesionStore
+	segundoFactor repository.SegundoFactorStore
+	limiteLogin   repository.LimiteLoginStore
+	tokenApi      repository.TokenApiStore
+	registro      repository.RegistroStore
+
+	busqueda search.SearchIndex
+	correo   correo.Correo
 }
 
 func NewMysqlContainer(db *sqlx.DB) *Container {
+	var publicacion = repository.NewMysqlPublicacionStore(db)
+
 	return &Container{
-		autor:       repository.NewMysqlAutorStore(db),
-		aviso:       repository.NewMysqlAvisoStore(db),
-		publicacion: repository.NewMysqlPublicacionStore(db),
-		tag:         repository.NewMysqlTagStore(db),
-		trabajo:     repository.NewMysqlTrabajoStore(db),
-		comentario:  repository.NewMysqlComentarioStore(db),
+		autor:         repository.NewMysqlAutorStore(db),
+		aviso:         repository.NewMysqlAvisoStore(db),
+		publicacion:   publicacion,
+		tag:           repository.NewMysqlTagStore(db),
+		trabajo:       repository.NewMysqlTrabajoStore(db),
+		comentario:    repository.NewMysqlComentarioStore(db),
+		serie:         repository.NewMysqlSerieStore(db),
+		revision:      repository.NewMysqlRevisionStore(db),
+		tarea:         repository.NewMysqlTareaStore(db),
+		media:         repository.NewMysqlMediaStore(db),
+		adjunto:       repository.NewMysqlAdjuntoStore(db),
+		rol:           repository.NewMysqlRolStore(db),
+		sesion:        repository.NewMysqlSesionStore(db),
+		segundoFactor: repository.NewMysqlSegundoFactorStore(db),
+		limiteLogin:   repository.NewMysqlLimiteLoginStore(db),
+		tokenApi:      repository.NewMysqlTokenApiStore(db),
+		registro:      repository.NewMysqlRegistroStore(db),
+
+		busqueda: search.NewFromEnv(publicacion),
+		correo:   correo.NewFromEnv(),
 	}
 }
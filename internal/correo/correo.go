@@ -0,0 +1,78 @@
+// Envío de correos del panel, como invitaciones y enlaces para restablecer la contraseña
+package correo
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"vigo360.es/new/internal/logger"
+)
+
+type Correo interface {
+	// Envía un correo de texto plano
+	Enviar(para string, asunto string, cuerpo string) error
+}
+
+/*
+Crea el servicio de correo configurado. Si hay SMTP_HOST se envía por SMTP; si no, los correos se escriben en el
+log del servidor para que alguien con acceso pueda copiar los enlaces.
+*/
+func NewFromEnv() Correo {
+	if os.Getenv("SMTP_HOST") == "" {
+		return LogCorreo{}
+	}
+
+	var puerto = os.Getenv("SMTP_PORT")
+	if puerto == "" {
+		puerto = "587"
+	}
+	return SmtpCorreo{
+		Servidor:   net.JoinHostPort(os.Getenv("SMTP_HOST"), puerto),
+		Usuario:    os.Getenv("SMTP_USUARIO"),
+		Contraseña: os.Getenv("SMTP_CONTRASENA"),
+		Remitente:  os.Getenv("SMTP_REMITENTE"),
+	}
+}
+
+// Escribe los correos en el log en lugar de enviarlos
+type LogCorreo struct{}
+
+func (LogCorreo) Enviar(para string, asunto string, cuerpo string) error {
+	// Cada línea del log lleva su prioridad, así que el cuerpo se escribe en una sola
+	var log = logger.NewLogger("correo")
+	log.Notice("no hay servidor de correo configurado, correo para %s (%s): %s", para, asunto, strings.ReplaceAll(cuerpo, "\n", " "))
+	return nil
+}
+
+type SmtpCorreo struct {
+	Servidor   string // host:puerto
+	Usuario    string
+	Contraseña string
+	Remitente  string
+}
+
+func (c SmtpCorreo) Enviar(para string, asunto string, cuerpo string) error {
+	if strings.ContainsAny(para+asunto, "\r\n") {
+		return fmt.Errorf("destinatario o asunto no válidos")
+	}
+
+	var auth smtp.Auth
+	if c.Usuario != "" {
+		host, _, _ := net.SplitHostPort(c.Servidor)
+		auth = smtp.PlainAuth("", c.Usuario, c.Contraseña, host)
+	}
+
+	var mensaje strings.Builder
+	fmt.Fprintf(&mensaje, "From: %s\r\n", c.Remitente)
+	fmt.Fprintf(&mensaje, "To: %s\r\n", para)
+	fmt.Fprintf(&mensaje, "Subject: %s\r\n", asunto)
+	fmt.Fprintf(&mensaje, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	mensaje.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	mensaje.WriteString(strings.ReplaceAll(cuerpo, "\n", "\r\n"))
+
+	return smtp.SendMail(c.Servidor, auth, c.Remitente, []string{para}, []byte(mensaje.String()))
+}
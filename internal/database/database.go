@@ -1,6 +1,7 @@
 package database
 
 import (
+	"net/url"
 	"os"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -10,37 +11,53 @@ import (
 
 var db *sqlx.DB
 
-func start() {
+// Conecta con la base de datos de las variables de entorno. Hay que llamarla antes de GetDB
+func Conectar() error {
+	if db != nil {
+		return nil
+	}
+
 	logger := logger.NewLogger("BBDD")
-	var dsn string = os.Getenv("DB_USER") + ":" + os.Getenv("DB_PASS") + "@tcp(" + os.Getenv("DB_HOST") + ")/" + os.Getenv("DB_BASE")
+	// Las variables de sesión van en el DSN porque el driver las aplica a cada conexión nueva del pool. Un SET solo
+	// afectaría a la conexión que lo ejecutase, y las fechas se comparan con NOW() en UTC
+	var variables = url.Values{
+		"lc_time_names": {"'es_ES'"},
+		"time_zone":     {"'+00:00'"},
+	}
+	var dsn string = os.Getenv("DB_USER") + ":" + os.Getenv("DB_PASS") + "@tcp(" + os.Getenv("DB_HOST") + ")/" + os.Getenv("DB_BASE") + "?" + variables.Encode()
 	var err error
 	conn, err := sqlx.Connect("mysql", dsn)
 	if err != nil {
-		logger.Critical("error connecting to mysql: %s", err.Error())
+		return logger.Critical("error connecting to mysql: %s", err.Error())
 	}
 
 	logger.Information("database connection established")
 
 	err = conn.Ping()
 	if err != nil {
-		logger.Critical("couldn't ping database: %s", err.Error())
+		return logger.Critical("couldn't ping database: %s", err.Error())
 	}
 
-	_, err = conn.Exec("SET lc_time_names = 'es_ES';")
-	if err != nil {
-		logger.Critical("error configuring database locale: %s", err.Error())
-	}
-	_, err = conn.Exec("SET @@session.time_zone='+00:00';")
-	if err != nil {
-		logger.Critical("error configuring database timezone: %s", err.Error())
-	}
-	logger.Information("database configured")
 	db = conn
+	return nil
 }
 
 func GetDB() *sqlx.DB {
 	if db == nil {
-		start()
+		// Solo pasa si main no ha llamado a Conectar, y sin base de datos no se puede hacer nada
+		if err := Conectar(); err != nil {
+			panic(err)
+		}
 	}
 	return db
 }
+
+// Cierra la conexión al apagar el servidor, cuando ya no quedan peticiones ni tareas que la usen
+func Cerrar() error {
+	if db == nil {
+		return nil
+	}
+	var err = db.Close()
+	db = nil
+	return err
+}
@@ -0,0 +1,101 @@
+// Package diff compara dos textos línea a línea
+package diff
+
+import "strings"
+
+type TipoLinea int
+
+const (
+	LineaIgual TipoLinea = iota
+	LineaEliminada
+	LineaAñadida
+)
+
+type Linea struct {
+	Tipo  TipoLinea
+	Texto string
+}
+
+/*
+Lineas devuelve las diferencias entre los textos a y b, como la secuencia de líneas que hay que mantener, eliminar de
+a o añadir de b para pasar del primero al segundo. Usa la subsecuencia común más larga, así que es cuadrático en el
+número de líneas, lo que basta para el tamaño de un artículo.
+*/
+func Lineas(a string, b string) []Linea {
+	var (
+		la = dividir(a)
+		lb = dividir(b)
+		n  = len(la)
+		m  = len(lb)
+	)
+
+	// lcs[i][j] es la longitud de la subsecuencia común más larga entre la[i:] y lb[j:]
+	var lcs = make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if la[i] == lb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var resultado = make([]Linea, 0, n+m)
+	var i, j = 0, 0
+	for i < n && j < m {
+		switch {
+		case la[i] == lb[j]:
+			resultado = append(resultado, Linea{Tipo: LineaIgual, Texto: la[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			resultado = append(resultado, Linea{Tipo: LineaEliminada, Texto: la[i]})
+			i++
+		default:
+			resultado = append(resultado, Linea{Tipo: LineaAñadida, Texto: lb[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		resultado = append(resultado, Linea{Tipo: LineaEliminada, Texto: la[i]})
+	}
+	for ; j < m; j++ {
+		resultado = append(resultado, Linea{Tipo: LineaAñadida, Texto: lb[j]})
+	}
+
+	return resultado
+}
+
+// Indica si hay alguna línea distinta entre ambos textos
+func HayCambios(lineas []Linea) bool {
+	for _, l := range lineas {
+		if l.Tipo != LineaIgual {
+			return true
+		}
+	}
+	return false
+}
+
+func dividir(texto string) []string {
+	if texto == "" {
+		return []string{}
+	}
+	texto = strings.ReplaceAll(texto, "\r\n", "\n")
+	return strings.Split(texto, "\n")
+}
+
+// Símbolo con el que se muestra la línea, al estilo de diff -u
+func (l Linea) Prefijo() string {
+	switch l.Tipo {
+	case LineaEliminada:
+		return "-"
+	case LineaAñadida:
+		return "+"
+	default:
+		return " "
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Package frontmatter convierte publicaciones en ficheros Markdown con una cabecera YAML y viceversa, para hacer copias
+que se puedan guardar en git e importar en bloque. No sabe nada de la base de datos: solo da formato y lo interpreta.
+*/
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const separador = "---"
+
+var ErrSinCabecera = errors.New("el fichero no empieza por una cabecera YAML entre líneas ---")
+
+// Datos de la publicación que van en la cabecera. Las fechas van en RFC3339 y pueden estar vacías
+type Cabecera struct {
+	Id                  string   `yaml:"id"`
+	Titulo              string   `yaml:"titulo"`
+	Resumen             string   `yaml:"resumen"`
+	Alt_portada         string   `yaml:"alt_portada"`
+	Tags                []string `yaml:"tags"`
+	Autor               string   `yaml:"autor"`
+	Fecha_publicacion   string   `yaml:"fecha_publicacion,omitempty"`
+	Fecha_actualizacion string   `yaml:"fecha_actualizacion,omitempty"`
+}
+
+type Documento struct {
+	Cabecera  Cabecera
+	Contenido string
+}
+
+// Nombre de fichero con el que se exporta una publicación
+func NombreFichero(id string) string {
+	return id + ".md"
+}
+
+func Codificar(doc Documento) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(separador + "\n")
+
+	if doc.Cabecera.Tags == nil {
+		doc.Cabecera.Tags = []string{}
+	}
+	var encoder = yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc.Cabecera); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(separador + "\n\n")
+	buf.WriteString(strings.TrimSpace(doc.Contenido))
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+/*
+Decodificar separa la cabecera del contenido. Acepta finales de línea de Windows y rechaza campos desconocidos en la
+cabecera, para que una errata no haga que se ignore un dato sin avisar.
+*/
+func Decodificar(datos []byte) (Documento, error) {
+	var texto = strings.ReplaceAll(string(datos), "\r\n", "\n")
+	texto = strings.TrimPrefix(texto, "\ufeff")
+
+	if !strings.HasPrefix(texto, separador+"\n") {
+		return Documento{}, ErrSinCabecera
+	}
+	texto = texto[len(separador)+1:]
+
+	var fin = strings.Index(texto, "\n"+separador+"\n")
+	var cabecera, contenido string
+	switch {
+	case fin >= 0:
+		cabecera, contenido = texto[:fin], texto[fin+len(separador)+2:]
+	case strings.HasSuffix(texto, "\n"+separador):
+		cabecera = strings.TrimSuffix(texto, "\n"+separador)
+	default:
+		return Documento{}, ErrSinCabecera
+	}
+
+	var doc Documento
+	var decoder = yaml.NewDecoder(strings.NewReader(cabecera))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&doc.Cabecera); err != nil {
+		return Documento{}, fmt.Errorf("error leyendo la cabecera: %w", err)
+	}
+	doc.Contenido = strings.TrimSpace(contenido)
+	return doc, nil
+}
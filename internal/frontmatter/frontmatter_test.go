@@ -0,0 +1,111 @@
+package frontmatter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodificarDecodificar(t *testing.T) {
+	var casos = map[string]Documento{
+		"completo": {
+			Cabecera: Cabecera{
+				Id:                  "ciclovia-castrelos",
+				Titulo:              "La ciclovía de Castrelos",
+				Resumen:             "Un paseo por el parque",
+				Alt_portada:         "Vista del pazo",
+				Tags:                []string{"urbanismo", "parques"},
+				Autor:               "ana",
+				Fecha_publicacion:   "2024-05-01T10:00:00Z",
+				Fecha_actualizacion: "2024-05-02T08:30:00Z",
+			},
+			Contenido: "# Castrelos\n\nPrimer párrafo.",
+		},
+		"varias lineas": {
+			Cabecera: Cabecera{
+				Id:      "varias-lineas",
+				Titulo:  "Título",
+				Resumen: "Primera línea\nsegunda línea\n\ny otro párrafo",
+				Tags:    []string{},
+			},
+			Contenido: "Texto",
+		},
+		"separador en el contenido": {
+			Cabecera:  Cabecera{Id: "separador", Titulo: "Con reglas", Tags: []string{}},
+			Contenido: "Antes\n\n---\n\nDespués\n---\nal final",
+		},
+		"comillas y dos puntos": {
+			Cabecera: Cabecera{
+				Id:          "comillas",
+				Titulo:      `Vigo: "la ciudad" de 'Olivo'`,
+				Resumen:     "clave: valor # no es un comentario",
+				Alt_portada: "- empieza como una lista",
+				Tags:        []string{"a: b"},
+				Autor:       "yes",
+			},
+			Contenido: "Contenido: con dos puntos",
+		},
+		"campos vacios": {
+			Cabecera:  Cabecera{Id: "vacio", Tags: []string{}},
+			Contenido: "",
+		},
+	}
+
+	for nombre, doc := range casos {
+		datos, err := Codificar(doc)
+		if err != nil {
+			t.Errorf("%s: error codificando: %s", nombre, err.Error())
+			continue
+		}
+		obtenido, err := Decodificar(datos)
+		if err != nil {
+			t.Errorf("%s: error decodificando:\n%s\n%s", nombre, datos, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(obtenido, doc) {
+			t.Errorf("%s: se esperaba\n%#v\nse obtuvo\n%#v\ndesde\n%s", nombre, doc, obtenido, datos)
+		}
+	}
+}
+
+func TestDecodificarSinTags(t *testing.T) {
+	// Al codificar, unas etiquetas nil se escriben como lista vacía
+	datos, err := Codificar(Documento{Cabecera: Cabecera{Id: "sin-tags"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Decodificar(datos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Cabecera.Tags == nil || len(doc.Cabecera.Tags) != 0 {
+		t.Errorf("se esperaba una lista vacía, se obtuvo %#v", doc.Cabecera.Tags)
+	}
+}
+
+func TestDecodificarFormato(t *testing.T) {
+	var windows = "\ufeff---\r\nid: windows\r\ntitulo: Título\r\n---\r\n\r\nLínea 1\r\nLínea 2\r\n"
+	doc, err := Decodificar([]byte(windows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Cabecera.Id != "windows" || doc.Contenido != "Línea 1\nLínea 2" {
+		t.Errorf("documento inesperado: %#v", doc)
+	}
+
+	if doc, err := Decodificar([]byte("---\nid: solo-cabecera\n---")); err != nil || doc.Cabecera.Id != "solo-cabecera" || doc.Contenido != "" {
+		t.Errorf("una cabecera sin contenido debería aceptarse: %#v, %v", doc, err)
+	}
+
+	for _, texto := range []string{"", "id: x\n", "Texto\n---\nid: x\n---\n", "---\nid: x\n"} {
+		if _, err := Decodificar([]byte(texto)); !errors.Is(err, ErrSinCabecera) {
+			t.Errorf("%q: se esperaba %v, se obtuvo %v", texto, ErrSinCabecera, err)
+		}
+	}
+
+	_, err = Decodificar([]byte("---\nid: x\ntitlo: errata\n---\n"))
+	if err == nil || !strings.Contains(err.Error(), "titlo") {
+		t.Errorf("un campo desconocido debería rechazarse, se obtuvo %v", err)
+	}
+}
@@ -20,7 +20,7 @@ func (s *Server) handleAdminDashboardPage() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		sess := r.Context().Value(sessionContextKey("sess")).(models.Session)
 
 		// TODO: Convertir esto en llamada a repositorio
@@ -12,12 +12,24 @@ import (
 func (s *Server) handleAdminListComentarios() http.HandlerFunc {
 	type Response struct {
 		Comentarios []models.Comentario
+		Estado      models.EstadoComentario
+	}
+
+	var estados = map[string]models.EstadoComentario{
+		"pendiente": models.EstadoPendiente,
+		"aprobado":  models.EstadoAprobado,
+		"rechazado": models.EstadoRechazado,
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
-		//sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
-		comentarios, err := s.store.comentario.ListarPorEstado(models.EstadoPendiente)
+		log := logger.DesdeContexto(r.Context())
+
+		estado, ok := estados[r.URL.Query().Get("estado")]
+		if !ok {
+			estado = models.EstadoPendiente
+		}
+
+		comentarios, err := s.store.comentario.ListarPorEstado(estado)
 		if err != nil {
 			log.Error("Error recuperando comentarios: " + err.Error())
 			s.handleError(r, w, 500, messages.ErrorDatos)
@@ -26,6 +38,7 @@ func (s *Server) handleAdminListComentarios() http.HandlerFunc {
 
 		err = templates.Render(w, "admin-comentarios.html", Response{
 			Comentarios: comentarios,
+			Estado:      estado,
 		})
 
 		if err != nil {
@@ -9,9 +9,9 @@ import (
 
 	"github.com/thanhpk/randstr"
 	"golang.org/x/crypto/bcrypt"
-	"vigo360.es/new/internal/database"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/service"
 )
 
 func (s *Server) handle_login_action() http.HandlerFunc {
@@ -22,21 +22,21 @@ func (s *Server) handle_login_action() http.HandlerFunc {
 		}
 
 		if errors.Is(err, bcrypt.ErrHashTooShort) {
-			// TODO: Refactor esto
-			fmt.Printf("<3>contraseña demasiado corta")
+			log := logger.NewLogger("login")
+			log.Error("el hash de la contraseña es demasiado corto")
 		}
 
 		return false
 	}
 
-	type LoginRow struct {
-		Id         string
-		Nombre     string
-		Contraseña string
-	}
+	// Hash de una contraseña cualquiera, para tardar lo mismo con usuarios que no existen y no revelar cuáles sí
+	hashFalso, _ := bcrypt.GenerateFromPassword([]byte(randstr.String(20)), bcrypt.DefaultCost)
+
+	var sf = service.NewSegundoFactorService(s.store.segundoFactor)
+	var limite = service.NewLimiteLoginService(s.store.limiteLogin)
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		var sc, err = r.Cookie("sess")
 		if err == nil {
 			sess, err := s.getSession(sc.Value)
@@ -54,66 +54,142 @@ func (s *Server) handle_login_action() http.HandlerFunc {
 			return
 		}
 
-		var db = database.GetDB()
 		param_userid := r.PostFormValue("userid")
 		param_password := r.PostFormValue("password")
 
-		row := LoginRow{}
-
 		if param_userid == "" || param_password == "" {
 			logger.Error("falta usuario o contraseña")
 			s.handle_login_page(param_userid)(w, r)
 			return
 		}
 
-		// Fetch from database. If error is no user found, show the error document. If a different error is thrown, show 500
-		err = db.QueryRowx("SELECT id, nombre, contraseña FROM autores WHERE id=?;", param_userid).StructScan(&row)
-
+		var ip = ipCliente(r)
+		intento, err := limite.Intentar(param_userid, ip)
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				logger.Error("ningún usuario coincide con '%s'", param_userid)
-				s.handle_login_page(param_userid)(w, r)
+			logger.Error("error comprobando los intentos de inicio de sesión: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		if intento.Espera > 0 {
+			logger.Warning("se rechaza el inicio de sesión de '%s' desde %s, debe esperar %s", param_userid, ip, intento.Espera)
+			anotarEvento(r, "login_limitado")
+			s.handle_login_limitado(param_userid, intento.Espera)(w, r)
+			return
+		}
+
+		var fallo = func(motivo string) {
+			logger.Error("%s", motivo)
+			if intento.Bloqueo {
+				logger.Warning("se bloquea temporalmente el inicio de sesión de '%s' o de %s", param_userid, ip)
+				anotarEvento(r, "login_bloqueo")
 			} else {
-				logger.Error("error recuperando usuario: %s", err.Error())
-				s.handleError(r, w, 500, messages.ErrorDatos)
+				anotarEvento(r, "login_fallido")
 			}
+			s.handle_login_page(param_userid)(w, r)
+		}
+
+		// If error is no user found, show the error document. If a different error is thrown, show 500
+		autor, err := s.store.autor.Obtener(param_userid)
+		if errors.Is(err, sql.ErrNoRows) {
+			_ = bcrypt.CompareHashAndPassword(hashFalso, []byte(param_password))
+			fallo(fmt.Sprintf("ningún usuario coincide con '%s'", param_userid))
+			return
+		} else if err != nil {
+			logger.Error("error recuperando usuario: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
 			return
 		}
 
-		pass := comprobarContraseña(param_password, row.Contraseña)
+		hash, err := s.store.autor.ObtenerContraseña(autor.Id)
+		if err != nil {
+			logger.Error("error recuperando usuario: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
 
-		if !pass {
-			logger.Error("la contraseña introducida para '%s' es inválida", param_userid)
-			s.handle_login_page(param_userid)(w, r)
+		if !comprobarContraseña(param_password, hash) {
+			fallo(fmt.Sprintf("la contraseña introducida para '%s' es inválida", param_userid))
+			return
 		}
 
-		token := randstr.String(20)
+		if !autor.Activo {
+			fallo(fmt.Sprintf("el usuario '%s' está desactivado", param_userid))
+			return
+		}
 
-		if _, err := db.Exec("INSERT INTO sesiones VALUES (?, NOW(), false, ?)", token, param_userid); err != nil {
-			logger.Error("error guardando nueva sesión: %s")
+		necesario, err := sf.Necesario(autor.Id)
+		if err != nil {
+			logger.Error("error comprobando la verificación en dos pasos de '%s': %s", autor.Id, err.Error())
 			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
 		}
-
-		http.SetCookie(w, &http.Cookie{
-			Name:     "sess",
-			Value:    token,
-			Path:     "/",
-			MaxAge:   60 * 60 * 24 * 365,
-			Domain:   r.URL.Host,
-			HttpOnly: true,
-			SameSite: http.SameSiteStrictMode,
-			Secure:   true,
-		})
-
-		var next = "/admin/dashboard"
-		if n := r.URL.Query().Get("next"); n != "" {
-			unescapedNext, err := url.QueryUnescape(n)
-			if err == nil {
-				next = unescapedNext
+		if necesario {
+			// Los intentos de la cuenta no se olvidan hasta que también acierte el código
+			pendiente, err := sf.IniciarLogin(autor.Id)
+			if err != nil {
+				logger.Error("error guardando el inicio de sesión pendiente: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+				return
 			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     cookieLoginPendiente,
+				Value:    pendiente,
+				Path:     "/admin/login",
+				MaxAge:   10 * 60,
+				Domain:   r.URL.Host,
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+				Secure:   true,
+			})
+			w.Header().Add("Location", "/admin/login/2fa?"+r.URL.RawQuery)
+			w.WriteHeader(303)
+			return
+		}
+
+		if err := limite.Exito(autor.Id, ip); err != nil {
+			logger.Error("error olvidando los intentos de inicio de sesión: %s", err.Error())
+		}
+
+		if err := s.iniciarSesion(w, r, autor.Id); err != nil {
+			logger.Error("error guardando nueva sesión: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
 		}
 
 		defer w.WriteHeader(303)
-		defer w.Header().Add("Location", next)
+		defer w.Header().Add("Location", destinoLogin(r))
+	}
+}
+
+// Guarda una sesión nueva para el autor y envía la cookie
+func (s *Server) iniciarSesion(w http.ResponseWriter, r *http.Request, autor_id string) error {
+	token := randstr.String(20)
+
+	if err := s.store.sesion.Crear(token, autor_id, r.UserAgent(), ipCliente(r)); err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "sess",
+		Value:    token,
+		Path:     "/",
+		MaxAge:   60 * 60 * 24 * 365,
+		Domain:   r.URL.Host,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   true,
+	})
+	return nil
+}
+
+// Página a la que volver tras iniciar sesión, indicada en ?next=
+func destinoLogin(r *http.Request) string {
+	var next = "/admin/dashboard"
+	if n := r.URL.Query().Get("next"); n != "" {
+		unescapedNext, err := url.QueryUnescape(n)
+		if err == nil {
+			next = unescapedNext
+		}
 	}
+	return next
 }
@@ -0,0 +1,343 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+// Stores en memoria con lo justo para el inicio de sesión. Los métodos que no se implementan no se deben llamar
+
+type memAutorStore struct {
+	repository.AutorStore
+	autores map[string]models.Autor
+	hashes  map[string]string
+}
+
+func (s *memAutorStore) Obtener(id string) (models.Autor, error) {
+	autor, ok := s.autores[id]
+	if !ok {
+		return models.Autor{}, sql.ErrNoRows
+	}
+	return autor, nil
+}
+
+func (s *memAutorStore) ObtenerContraseña(id string) (string, error) {
+	return s.hashes[id], nil
+}
+
+type memSesionStore struct {
+	repository.SesionStore
+	creadas []models.Sesion
+}
+
+func (s *memSesionStore) Crear(sessid string, autor_id string, user_agent string, ip string) error {
+	s.creadas = append(s.creadas, models.Sesion{Autor_id: autor_id, User_agent: user_agent, Ip: ip})
+	return nil
+}
+
+type memSegundoFactorStore struct {
+	repository.SegundoFactorStore
+	secretos map[string]string
+	logins   map[string]string
+}
+
+func (s *memSegundoFactorStore) Obtener(autor_id string) (models.SegundoFactor, error) {
+	return models.SegundoFactor{Autor_id: autor_id, Secreto: s.secretos[autor_id]}, nil
+}
+
+func (s *memSegundoFactorStore) Exigido(autor_id string) (bool, error) {
+	return false, nil
+}
+
+func (s *memSegundoFactorStore) CrearLogin(hash string, autor_id string, validez time.Duration) error {
+	s.logins[hash] = autor_id
+	return nil
+}
+
+type memLimiteLoginStore struct {
+	mu       sync.Mutex
+	intentos map[string]models.IntentosLogin
+}
+
+func (s *memLimiteLoginStore) Obtener(clave string) (models.IntentosLogin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.intentos[clave], nil
+}
+
+func (s *memLimiteLoginStore) Actualizar(clave string, anterior models.IntentosLogin, nuevo models.IntentosLogin) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var actual = s.intentos[clave]
+	if actual.Intentos != anterior.Intentos || !actual.Ultimo.Equal(anterior.Ultimo) {
+		return false, nil
+	}
+	s.intentos[clave] = nuevo
+	return true, nil
+}
+
+func (s *memLimiteLoginStore) Limpiar(clave string, antiguos time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range s.intentos {
+		if k == clave || v.Ultimo.Before(antiguos) {
+			delete(s.intentos, k)
+		}
+	}
+	return nil
+}
+
+// Adelanta el reloj de los intentos para que ninguna espera siga vigente, sin olvidar cuántos hubo
+func (s *memLimiteLoginStore) expirar() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range s.intentos {
+		v.Bloqueado_hasta = time.Time{}
+		s.intentos[k] = v
+	}
+}
+
+type pruebaLogin struct {
+	server  *Server
+	sesion  *memSesionStore
+	dos     *memSegundoFactorStore
+	limite  *memLimiteLoginStore
+	handler http.HandlerFunc
+}
+
+func nuevaPruebaLogin(t *testing.T) *pruebaLogin {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("contraseña-buena"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var p = &pruebaLogin{
+		sesion: &memSesionStore{},
+		dos:    &memSegundoFactorStore{secretos: map[string]string{}, logins: map[string]string{}},
+		limite: &memLimiteLoginStore{intentos: map[string]models.IntentosLogin{}},
+	}
+	var autores = &memAutorStore{autores: map[string]models.Autor{}, hashes: map[string]string{}}
+	for _, id := range []string{"ana", "bea", "carla", "dora"} {
+		autores.autores[id] = models.Autor{Id: id, Nombre: id, Activo: true}
+		autores.hashes[id] = string(hash)
+	}
+	autores.autores["eva"] = models.Autor{Id: "eva", Nombre: "eva", Activo: false}
+	autores.hashes["eva"] = string(hash)
+
+	p.server = &Server{store: &Container{
+		autor:         autores,
+		sesion:        p.sesion,
+		segundoFactor: p.dos,
+		limiteLogin:   p.limite,
+	}}
+	p.handler = p.server.handle_login_action()
+	return p
+}
+
+// Envía el formulario de inicio de sesión y devuelve la respuesta y el evento que se guardaría en el registro
+func (p *pruebaLogin) enviar(usuario string, contraseña string, xff string) (*httptest.ResponseRecorder, string) {
+	// Como si llegase a través del nginx local, el único que puede poner X-Forwarded-For sin configurar nada
+	return p.enviarDesde("127.0.0.1:40000", usuario, contraseña, xff)
+}
+
+// Como enviar, con la conexión desde la dirección indicada
+func (p *pruebaLogin) enviarDesde(remota string, usuario string, contraseña string, xff string) (*httptest.ResponseRecorder, string) {
+	var form = url.Values{"userid": {usuario}, "password": {contraseña}}
+	var r = httptest.NewRequest(http.MethodPost, "/admin/login", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("User-Agent", "prueba")
+	r.Header.Set("X-Forwarded-For", xff)
+	r.RemoteAddr = remota
+
+	var evento string
+	var ctx = context.WithValue(r.Context(), ridContextKey("rid"), "prueba")
+	ctx = context.WithValue(ctx, eventoContextKey("evento"), &evento)
+
+	var w = httptest.NewRecorder()
+	p.handler(w, r.WithContext(ctx))
+	return w, evento
+}
+
+func tieneCookie(w *httptest.ResponseRecorder, nombre string) bool {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == nombre && c.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLoginContraseñaIncorrectaNoCreaSesion(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+
+	w, evento := p.enviar("ana", "contraseña-mala", "10.0.0.1")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "contraseña inválidos") {
+		t.Fatalf("se esperaba la página de inicio de sesión con el error, se obtuvo %d", w.Code)
+	}
+	if len(p.sesion.creadas) != 0 || tieneCookie(w, "sess") {
+		t.Fatal("una contraseña incorrecta no debe crear una sesión")
+	}
+	if evento != "login_fallido" {
+		t.Fatalf("se esperaba el evento login_fallido, se obtuvo %q", evento)
+	}
+}
+
+func TestLoginAutorDesactivadoNoCreaSesion(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+
+	w, _ := p.enviar("eva", "contraseña-buena", "10.0.0.1")
+	if len(p.sesion.creadas) != 0 || tieneCookie(w, "sess") {
+		t.Fatal("un autor desactivado no debe poder iniciar sesión")
+	}
+}
+
+func TestLoginCorrecto(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+
+	w, _ := p.enviar("ana", "contraseña-buena", "1.2.3.4, 10.0.0.1")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/admin/dashboard" {
+		t.Fatalf("se esperaba una redirección al panel, se obtuvo %d a %q", w.Code, w.Header().Get("Location"))
+	}
+	if !tieneCookie(w, "sess") || len(p.sesion.creadas) != 1 {
+		t.Fatal("se esperaba una sesión nueva")
+	}
+	if s := p.sesion.creadas[0]; s.Autor_id != "ana" || s.Ip != "10.0.0.1" || s.User_agent != "prueba" {
+		t.Fatalf("la sesión no se guardó con los datos de la petición: %+v", s)
+	}
+}
+
+func TestLoginEsperaExponencial(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+
+	for i := 0; i < 3; i++ {
+		if w, _ := p.enviar("ana", "contraseña-mala", "10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("el intento %d no debería tener que esperar, se obtuvo %d", i+1, w.Code)
+		}
+	}
+
+	// El cuarto fallo ya impone un segundo de espera, que se aplica incluso con la contraseña correcta
+	p.enviar("ana", "contraseña-mala", "10.0.0.1")
+	w, evento := p.enviar("ana", "contraseña-buena", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("se esperaba 429 con Retry-After 1, se obtuvo %d con %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if len(p.sesion.creadas) != 0 {
+		t.Fatal("no se debe crear una sesión mientras hay que esperar")
+	}
+	if evento != "login_limitado" {
+		t.Fatalf("se esperaba el evento login_limitado, se obtuvo %q", evento)
+	}
+
+	// Cada fallo siguiente dobla la espera
+	p.limite.expirar()
+	p.enviar("ana", "contraseña-mala", "10.0.0.1")
+	if w, _ := p.enviar("ana", "contraseña-buena", "10.0.0.1"); w.Header().Get("Retry-After") != "2" {
+		t.Fatalf("se esperaba Retry-After 2, se obtuvo %q", w.Header().Get("Retry-After"))
+	}
+}
+
+func TestLoginBloqueoCuenta(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+
+	var evento string
+	for i := 0; i < 10; i++ {
+		p.limite.expirar()
+		_, evento = p.enviar("ana", "contraseña-mala", "10.0.0.1")
+	}
+	if evento != "login_bloqueo" {
+		t.Fatalf("el décimo fallo debería bloquear la cuenta, se obtuvo el evento %q", evento)
+	}
+
+	w, _ := p.enviar("ana", "contraseña-buena", "10.0.0.2")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "900" {
+		t.Fatalf("se esperaba la cuenta bloqueada 15 minutos, se obtuvo %d con %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Otra cuenta desde la misma IP no se ve afectada
+	if w, _ := p.enviar("bea", "contraseña-buena", "10.0.0.1"); w.Code != http.StatusSeeOther {
+		t.Fatalf("otra cuenta debería poder iniciar sesión, se obtuvo %d", w.Code)
+	}
+}
+
+func TestLoginLimitePorIp(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+
+	// El cliente puede poner lo que quiera delante en X-Forwarded-For, pero la última IP la añade nginx
+	var cuentas = []string{"ana", "bea", "carla", "dora"}
+	for i := 0; i < 11; i++ {
+		p.limite.expirar()
+		p.enviar(cuentas[i%len(cuentas)], "contraseña-mala", "192.0.2."+string(rune('a'+i))+", 10.0.0.1")
+	}
+
+	w, _ := p.enviar("dora", "contraseña-buena", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("se esperaba la IP limitada tras 11 fallos con distintas cuentas, se obtuvo %d", w.Code)
+	}
+	if w, _ := p.enviar("dora", "contraseña-buena", "10.0.0.2"); w.Code != http.StatusSeeOther {
+		t.Fatalf("desde otra IP se debería poder iniciar sesión, se obtuvo %d", w.Code)
+	}
+}
+
+func TestLoginLimitePorIpNoSeEvitaCambiandoXff(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+
+	// Sin pasar por un proxy de confianza, X-Forwarded-For no cuenta y todas vienen de la misma IP
+	var cuentas = []string{"ana", "bea", "carla", "dora"}
+	for i := 0; i < 11; i++ {
+		p.limite.expirar()
+		p.enviarDesde("198.51.100.7:5000", cuentas[i%len(cuentas)], "contraseña-mala", "10.0.0."+strconv.Itoa(i+1))
+	}
+
+	w, _ := p.enviarDesde("198.51.100.7:5000", "dora", "contraseña-buena", "10.0.0.99")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("se esperaba la IP limitada aunque cambie X-Forwarded-For, se obtuvo %d", w.Code)
+	}
+	if w, _ := p.enviarDesde("198.51.100.8:5000", "dora", "contraseña-buena", ""); w.Code != http.StatusSeeOther {
+		t.Fatalf("desde otra IP se debería poder iniciar sesión, se obtuvo %d", w.Code)
+	}
+}
+
+func TestLoginExitoOlvidaFallosDeLaCuenta(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+
+	for i := 0; i < 3; i++ {
+		p.enviar("ana", "contraseña-mala", "10.0.0.1")
+	}
+	if w, _ := p.enviar("ana", "contraseña-buena", "10.0.0.1"); w.Code != http.StatusSeeOther {
+		t.Fatalf("se esperaba poder iniciar sesión, se obtuvo %d", w.Code)
+	}
+	for i := 0; i < 3; i++ {
+		if w, _ := p.enviar("ana", "contraseña-mala", "10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("tras iniciar sesión los fallos deberían volver a empezar, el intento %d obtuvo %d", i+1, w.Code)
+		}
+	}
+}
+
+func TestLoginSegundoFactorNoCreaSesion(t *testing.T) {
+	var p = nuevaPruebaLogin(t)
+	p.dos.secretos["ana"] = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	w, _ := p.enviar("ana", "contraseña-buena", "10.0.0.1")
+	if w.Code != http.StatusSeeOther || !strings.HasPrefix(w.Header().Get("Location"), "/admin/login/2fa") {
+		t.Fatalf("se esperaba una redirección al segundo paso, se obtuvo %d a %q", w.Code, w.Header().Get("Location"))
+	}
+	if tieneCookie(w, "sess") || len(p.sesion.creadas) != 0 {
+		t.Fatal("no se debe crear la sesión antes del segundo paso")
+	}
+	if !tieneCookie(w, cookieLoginPendiente) || len(p.dos.logins) != 1 {
+		t.Fatal("se esperaba un inicio de sesión pendiente")
+	}
+}
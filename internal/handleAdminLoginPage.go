@@ -1,7 +1,11 @@
 package internal
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
@@ -12,10 +16,11 @@ func (s *Server) handle_login_page(prefill string) http.HandlerFunc {
 	type response struct {
 		LoginError  bool
 		PrefillName string
+		Espera      string
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		var sc, err = r.Cookie("sess")
 		if err == nil {
 			sess, err := s.getSession(sc.Value)
@@ -44,3 +49,37 @@ func (s *Server) handle_login_page(prefill string) http.HandlerFunc {
 		}
 	}
 }
+
+// Página de inicio de sesión para quien ha superado los intentos permitidos y debe esperar
+func (s *Server) handle_login_limitado(prefill string, espera time.Duration) http.HandlerFunc {
+	type response struct {
+		LoginError  bool
+		PrefillName string
+		Espera      string
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logger.DesdeContexto(r.Context())
+
+		var segundos = int(math.Ceil(espera.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(segundos))
+		w.WriteHeader(http.StatusTooManyRequests)
+
+		err := templates.Render(w, "admin-login.html", response{
+			PrefillName: prefill,
+			Espera:      describirEspera(segundos),
+		})
+		if err != nil {
+			logger.Notice("error mostrando página: %s", err.Error())
+		}
+	}
+}
+
+func describirEspera(segundos int) string {
+	if segundos == 1 {
+		return "1 segundo"
+	} else if segundos < 120 {
+		return fmt.Sprintf("%d segundos", segundos)
+	}
+	return fmt.Sprintf("%d minutos", (segundos+59)/60)
+}
@@ -21,7 +21,7 @@ func revokeSession(sessid string) error {
 
 func (s *Server) handleAdminLogoutAction() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
 		if err := revokeSession(sess.Id); err != nil {
 			logger.Error("error revocando sesión: %s", err.Error())
@@ -42,6 +42,6 @@ func (s *Server) handleAdminLogoutAction() http.HandlerFunc {
 
 		logger.Information("revoked session with id %s", sess.Id)
 		w.Header().Add("Location", "/admin/login")
-		w.WriteHeader(302)
+		w.WriteHeader(303)
 	}
 }
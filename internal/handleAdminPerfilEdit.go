@@ -12,6 +12,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/nfnt/resize"
 	"vigo360.es/new/internal/database"
+	"vigo360.es/new/internal/imagenes"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
 	"vigo360.es/new/internal/models"
@@ -26,7 +27,7 @@ func (s *Server) handleAdminPerfilEdit() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
 
 		_, err := s.store.autor.Obtener(sess.Autor_id)
@@ -80,14 +81,14 @@ func (s *Server) handleAdminPerfilEdit() http.HandlerFunc {
 				return
 			}
 
-			image, err := imagenDesdeMime(photoBytes)
+			image, err := imagenes.Decodificar(photoBytes)
 			if err != nil {
 				logger.Error("error extrayendo el tipo MIME de la imagen: %s", err.Error())
 				s.handleJsonError(r, w, 500, messages.ErrorFormulario)
 				return
 			}
 
-			image = resize.Resize(256, 256, image, resize.Bicubic)
+			image = resize.Resize(256, 256, imagenes.Recortar(image, 1, imagenes.FocoCentro), resize.Bicubic)
 
 			var fotoEscribir bytes.Buffer
 			err = jpeg.Encode(&fotoEscribir, image, &jpeg.Options{Quality: 95})
@@ -11,11 +11,19 @@ import (
 
 func (s *Server) handleAdminPerfilView() http.HandlerFunc {
 	type respuesta struct {
-		Autor models.Autor
+		Autor      models.Autor
+		Contraseña string
+	}
+
+	var resultadosContraseña = map[string]string{
+		"cambiada":   "Contraseña cambiada. Se han cerrado el resto de sesiones.",
+		"distintas":  "Las contraseñas nuevas no coinciden.",
+		"incorrecta": "La contraseña actual no es correcta.",
+		"invalida":   "La contraseña nueva debe tener al menos 10 caracteres y como mucho 72 bytes, que son menos caracteres si lleva tildes o emojis.",
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		sess := r.Context().Value(sessionContextKey("sess")).(models.Session)
 		autor, err := s.store.autor.Obtener(sess.Autor_id)
 
@@ -25,7 +33,8 @@ func (s *Server) handleAdminPerfilView() http.HandlerFunc {
 		}
 
 		templates.Render(w, "admin-perfil.html", respuesta{
-			Autor: autor,
+			Autor:      autor,
+			Contraseña: resultadosContraseña[r.URL.Query().Get("contrasena")],
 		})
 	}
 }
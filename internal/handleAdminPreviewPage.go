@@ -1,7 +1,6 @@
 package internal
 
 import (
-	"fmt"
 	"net/http"
 	"time"
 
@@ -22,7 +21,7 @@ func (s *Server) handleAdminPreviewPage() http.HandlerFunc {
 	//}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 
 		sess := r.Context().Value(sessionContextKey("sess")).(models.Session)
 		autor, err := s.store.autor.Obtener(sess.Autor_id)
@@ -49,8 +48,7 @@ func (s *Server) handleAdminPreviewPage() http.HandlerFunc {
 		})
 
 		if err != nil {
-			// TODO: Remplazar esto
-			fmt.Printf("%s\n", err.Error())
+			logger.Error("error renderizando la página: %s", err.Error())
 			s.handleError(r, w, 500, messages.ErrorRender)
 			return
 		}
@@ -2,16 +2,24 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"strings"
 
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/service"
 )
 
 type sessionContextKey string
 
+// Añade el autor a los campos de los mensajes que se escriban en el log durante la petición
+func anotarAutor(r *http.Request, sess models.Session) {
+	logger.Anotar(r.Context(), "autor", sess.Autor_id)
+}
+
 func (s *Server) withAuth(h http.HandlerFunc) http.HandlerFunc {
 	var gotoLogin = func(w http.ResponseWriter, rawnext string) {
 		w.Header().Add("Location", "/admin/login?next="+url.QueryEscape(rawnext))
@@ -19,7 +27,7 @@ func (s *Server) withAuth(h http.HandlerFunc) http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		var sc, err = r.Cookie("sess")
 		if err != nil {
 			logger.Error("error obteniendo cookie de sesión: %s", err.Error())
@@ -32,13 +40,49 @@ func (s *Server) withAuth(h http.HandlerFunc) http.HandlerFunc {
 			gotoLogin(w, r.URL.Path)
 			return
 		}
+		if err := s.store.sesion.Tocar(sess.Id, ipCliente(r)); err != nil {
+			logger.Warning("error actualizando el último uso de la sesión: %s", err.Error())
+		}
+		anotarAutor(r, sess)
 		newContext := context.WithValue(r.Context(), sessionContextKey("sess"), sess)
 		r = r.WithContext(newContext)
 		h(w, r)
 	}
 }
 
+/*
+withPermission exige, además de una sesión válida, que el autor tenga el permiso indicado, ya sea por uno de sus
+roles o asignado directamente. Las rutas de /admin/async responden con un error en JSON.
+*/
+func (s *Server) withPermission(permiso string, h http.HandlerFunc) http.HandlerFunc {
+	return s.withAuth(func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+		if !sess.Permisos[permiso] {
+			logger := logger.DesdeContexto(r.Context())
+			logger.Error("%s no tiene el permiso %s para %s", sess.Autor_id, permiso, r.URL.Path)
+			if strings.HasPrefix(r.URL.Path, "/admin/async") {
+				s.handleJsonError(r, w, 403, messages.ErrorSinPermiso)
+			} else {
+				s.handleError(r, w, 403, messages.ErrorSinPermiso)
+			}
+			return
+		}
+		h(w, r)
+	})
+}
+
+type tokenApiContextKey string
+
+var errorAlcanceApi messages.ErrorMessage = "El token no tiene el alcance necesario para esta operación."
+
+/*
+withJsonAuth acepta en la cabecera Authorization: Bearer tanto un token de la API como un token de sesión. Con un
+token de la API, los permisos de la sesión son los del autor en ese momento y el token queda en el contexto para
+que withAlcance compruebe sus alcances.
+*/
 func (s *Server) withJsonAuth(h http.HandlerFunc) http.HandlerFunc {
+	var tokens = service.NewTokenApiService(s.store.tokenApi)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		var authParts = strings.Split(r.Header.Get("Authorization"), "Bearer ")
 		if len(authParts) != 2 {
@@ -50,13 +94,75 @@ func (s *Server) withJsonAuth(h http.HandlerFunc) http.HandlerFunc {
 			s.handleJsonError(r, w, 401, messages.ErrorSinAutenticar)
 			return
 		}
-		sess, err := s.getSession(authValue)
+
+		if !strings.HasPrefix(authValue, service.PrefijoTokenApi) {
+			sess, err := s.getSession(authValue)
+			if err != nil {
+				s.handleJsonError(r, w, 403, messages.ErrorSinAutenticar)
+				return
+			}
+			anotarAutor(r, sess)
+			newContext := context.WithValue(r.Context(), sessionContextKey("sess"), sess)
+			r = r.WithContext(newContext)
+			h(w, r)
+			return
+		}
+
+		log := logger.DesdeContexto(r.Context())
+		token, err := tokens.Autenticar(authValue)
+		if errors.Is(err, service.Err_TokenApiInvalido) {
+			anotarEvento(r, "token_api_rechazado")
+			s.handleJsonError(r, w, 401, messages.ErrorSinAutenticar)
+			return
+		} else if err != nil {
+			log.Error("error comprobando token de la API: %s", err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+		if err := s.store.tokenApi.Tocar(token.Id); err != nil {
+			log.Warning("error actualizando el último uso del token %d: %s", token.Id, err.Error())
+		}
+
+		permisos, err := s.store.rol.PermisosDeAutor(token.Autor_id)
 		if err != nil {
-			s.handleJsonError(r, w, 403, messages.ErrorSinAutenticar)
+			log.Error("error obteniendo permisos de %s: %s", token.Autor_id, err.Error())
+			s.handleJsonError(r, w, 500, messages.ErrorDatos)
 			return
 		}
+
+		var sess = models.Session{
+			Autor_id:     token.Autor_id,
+			Autor_nombre: token.Autor_nombre,
+			Permisos:     permisos,
+		}
+		anotarAutor(r, sess)
+		logger.Anotar(r.Context(), "token_api", token.Prefijo)
 		newContext := context.WithValue(r.Context(), sessionContextKey("sess"), sess)
+		newContext = context.WithValue(newContext, tokenApiContextKey("token"), token)
 		r = r.WithContext(newContext)
 		h(w, r)
 	}
 }
+
+/*
+withAlcance exige, además de autenticarse con withJsonAuth, que el autor tenga el permiso que corresponde al alcance
+y, si entra con un token de la API, que el token tenga ese alcance.
+*/
+func (s *Server) withAlcance(alcance models.AlcanceApi, h http.HandlerFunc) http.HandlerFunc {
+	return s.withJsonAuth(func(w http.ResponseWriter, r *http.Request) {
+		logger := logger.DesdeContexto(r.Context())
+		sess, _ := r.Context().Value(sessionContextKey("sess")).(models.Session)
+
+		if !sess.Permisos[alcance.Permiso()] {
+			logger.Error("%s no tiene el permiso %s para %s", sess.Autor_id, alcance.Permiso(), r.URL.Path)
+			s.handleJsonError(r, w, 403, messages.ErrorSinPermiso)
+			return
+		}
+		if token, ok := r.Context().Value(tokenApiContextKey("token")).(models.TokenApi); ok && !token.Tiene(alcance) {
+			logger.Error("el token %d de %s no tiene el alcance %s para %s", token.Id, sess.Autor_id, alcance, r.URL.Path)
+			s.handleJsonError(r, w, 403, errorAlcanceApi)
+			return
+		}
+		h(w, r)
+	})
+}
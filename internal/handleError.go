@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/templates"
 
 	"vigo360.es/new/internal/messages"
@@ -44,7 +45,8 @@ func (s *Server) handleError(r *http.Request, w http.ResponseWriter, status int,
 			RequestedUrl: r.URL.String(),
 			Time:         time.Now().Format("2006-01-02 15:04:05"),
 		})
-		fmt.Println(rid, err)
+		log := logger.DesdeContexto(r.Context())
+		log.Error("error renderizando la página de error: %s", err.Error())
 	}
 }
 
@@ -54,6 +56,7 @@ func (s *Server) handleJsonError(r *http.Request, w http.ResponseWriter, status
 	_, err := fmt.Fprintf(w, `{ "error": "%s", "rid": "%s" }`, message, rid)
 	if err != nil {
 		_, _ = fmt.Fprintf(w, `{ "error": "%s", "rid": "%s" }`, messages.ErrorFatal, rid)
-		fmt.Println(rid, err)
+		log := logger.DesdeContexto(r.Context())
+		log.Error("error escribiendo el error en json: %s", err.Error())
 	}
 }
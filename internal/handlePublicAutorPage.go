@@ -21,7 +21,7 @@ func (s *Server) handlePublicAutorPage() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		var req_autor = mux.Vars(r)["id"]
 
 		var autor models.Autor
@@ -2,11 +2,8 @@ package internal
 
 import (
 	"net/http"
-	"os"
 	"strings"
 
-	"github.com/algolia/algoliasearch-client-go/v3/algolia/opt"
-	"github.com/algolia/algoliasearch-client-go/v3/algolia/search"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
 	"vigo360.es/new/internal/templates"
@@ -29,16 +26,8 @@ func (s *Server) handlePublicBusqueda() http.HandlerFunc {
 		Meta       PageMeta
 	}
 
-	var algoliaApiKey string = os.Getenv("ALGOLIA_API_KEY")
-	var algoliaAppId string = os.Getenv("ALGOLIA_APPLICATION")
-	var algoliaIndexName string = os.Getenv("ALGOLIA_INDEX")
-
-	var searchOptions = []interface{}{
-		opt.Filters("fecha_publicacion != null"),
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
 		var resultados = make([]resultado, 0)
 
 		var termino = r.URL.Query().Get("termino")
@@ -47,50 +36,28 @@ func (s *Server) handlePublicBusqueda() http.HandlerFunc {
 		if termino == "" {
 			w.Header().Add("Location", "/")
 			w.WriteHeader(302)
+			return
 		}
 
-		client := search.NewClient(algoliaAppId, algoliaApiKey)
-		index := client.InitIndex(algoliaIndexName)
-
-		result, err := index.Search(termino, searchOptions)
+		documentos, err := s.store.busqueda.Buscar(termino)
 		if err != nil {
 			log.Error("error recuperando publicaciones: %s", err.Error())
 			s.handleError(r, w, 500, messages.ErrorDatos)
 			return
 		}
 
-		for _, hit := range result.Hits {
+		for _, doc := range documentos {
 			resultados = append(resultados, resultado{
-				Id:                hit["id"].(string),
-				Titulo:            hit["titulo"].(string),
-				Autor_nombre:      hit["autor"].(string),
-				Alt_portada:       hit["alt_portada"].(string),
-				Resumen:           hit["resumen"].(string),
-				Uri:               "/post/" + hit["id"].(string),
-				Fecha_publicacion: hit["fecha_publicacion"].(string),
+				Id:                doc.Id,
+				Titulo:            doc.Titulo,
+				Autor_nombre:      doc.Autor,
+				Alt_portada:       doc.Alt_portada,
+				Resumen:           doc.Resumen,
+				Uri:               "/post/" + doc.Id,
+				Fecha_publicacion: doc.Fecha_publicacion,
 			})
 		}
 
-		//publicaciones, err := s.store.publicacion.Buscar(termino)
-		//publicaciones = publicaciones.FiltrarPublicas().FiltrarRetiradas()
-		//if err != nil {
-		//	log.Error("error recuperando publicaciones: %s", err.Error())
-		//	s.handleError(r, w, 500, messages.ErrorDatos)
-		//	return
-		//}
-
-		//for _, pub := range publicaciones {
-		//	resultados = append(resultados, resultado{
-		//		Id:                pub.Id,
-		//		Titulo:            pub.Titulo,
-		//		Autor_nombre:      pub.Autor.Nombre,
-		//		Alt_portada:       pub.Alt_portada,
-		//		Resumen:           pub.Resumen,
-		//		Uri:               "/post/" + pub.Id,
-		//		Fecha_publicacion: pub.Fecha_publicacion,
-		//	})
-		//}
-
 		err = templates.Render(w, "search.html", response{
 			Resultados: resultados,
 			Termino:    termino,
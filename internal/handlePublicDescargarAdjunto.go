@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/service"
+)
+
+/*
+Sirve un adjunto de un trabajo con su tipo MIME y como descarga con su nombre de archivo. http.ServeContent se
+encarga de las peticiones por rangos y de If-Modified-Since, y la suma SHA-256 se usa como ETag si se conoce.
+Los archivos están en ADJUNTOS_PATH, fuera de lo que sirve nginx, así que esta es la única forma de descargarlos.
+*/
+func (s *Server) handlePublicDescargarAdjunto() http.HandlerFunc {
+	return s.descargarAdjunto(true)
+}
+
+// Como handlePublicDescargarAdjunto, pero también de trabajos sin publicar, para verlos desde el editor
+func (s *Server) handleAdminDescargarAdjunto() http.HandlerFunc {
+	return s.descargarAdjunto(false)
+}
+
+func (s *Server) descargarAdjunto(soloPublicados bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.DesdeContexto(r.Context())
+		var as = service.NewAdjuntoService(s.store.adjunto, s.store.trabajo, os.Getenv("ADJUNTOS_PATH"))
+		vars := mux.Vars(r)
+
+		adjunto, err := s.store.adjunto.ObtenerPorNombre(vars["trabajoid"], vars["archivo"])
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Error("adjunto %s de %s no encontrado", vars["archivo"], vars["trabajoid"])
+			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			return
+		} else if err != nil {
+			log.Error("error recuperando adjunto: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		// Los trabajos sin publicar no exponen sus adjuntos
+		if _, err := s.store.trabajo.ObtenerPorId(adjunto.Trabajo_id, soloPublicados); err != nil {
+			log.Error("trabajo %s no disponible: %s", adjunto.Trabajo_id, err.Error())
+			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			return
+		}
+
+		archivo, err := os.Open(as.Ruta(adjunto))
+		if err != nil {
+			log.Error("error abriendo adjunto %s: %s", adjunto.Nombre_archivo, err.Error())
+			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			return
+		}
+		defer archivo.Close()
+
+		info, err := archivo.Stat()
+		if err != nil {
+			log.Error("error leyendo adjunto %s: %s", adjunto.Nombre_archivo, err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		w.Header().Set("Content-Type", adjunto.Mime)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": adjunto.Nombre_archivo}))
+		if adjunto.Sha256 != "" {
+			w.Header().Set("ETag", fmt.Sprintf(`"%s"`, adjunto.Sha256))
+		}
+		http.ServeContent(w, r, adjunto.Nombre_archivo, info.ModTime(), archivo)
+	}
+}
@@ -1,11 +1,13 @@
 package internal
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/metricas"
 	"vigo360.es/new/internal/models"
 	"vigo360.es/new/internal/service"
 )
@@ -14,7 +16,7 @@ func (s *Server) handlePublicEnviarComentario() http.HandlerFunc {
 	var cs = service.NewComentarioService(s.store.comentario, s.store.publicacion)
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 
 		r.ParseForm()
 		var publicacion_id = mux.Vars(r)["postid"]
@@ -53,11 +55,21 @@ func (s *Server) handlePublicEnviarComentario() http.HandlerFunc {
 
 		if err != nil {
 			logger.Error("error guardando comentario: %s", err.Error())
+			if errors.Is(err, service.Err_ComentarioErrorBaseDatos) {
+				metricas.Comentarios.Incrementar("error")
+			} else {
+				metricas.Comentarios.Incrementar("invalido")
+			}
 			s.handleError(r, w, 400, messages.ErrorDatos)
 			return
 		}
 
 		logger.Information("guardado comentario con ID %s", nc.Id)
+		if nc.Estado == models.EstadoAprobado {
+			metricas.Comentarios.Incrementar("aprobado")
+		} else {
+			metricas.Comentarios.Incrementar("pendiente")
+		}
 
 		w.Header().Add("Location", r.URL.Path)
 		defer w.WriteHeader(http.StatusSeeOther)
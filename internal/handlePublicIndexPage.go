@@ -32,7 +32,7 @@ func (s *Server) handlePublicIndex() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
 		posts, err := s.store.publicacion.Listar()
 		if err != nil && !errors.Is(err, sql.ErrNoRows) {
 			log.Error("error recuperando datos: %s", err.Error())
@@ -61,7 +61,7 @@ func (s *Server) handlePublicIndex() http.HandlerFunc {
 		var limite = getMinimo(inicio+9, len(posts))
 
 		if inicio >= len(posts) || inicio < 0 {
-			log.Error("con %d publicaciones no existe la página %s", len(posts), pagina)
+			log.Error("con %d publicaciones no existe la página %d", len(posts), pagina)
 			s.handleError(r, w, 404, messages.ErrorNoResultados)
 			return
 		}
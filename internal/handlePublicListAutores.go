@@ -25,11 +25,11 @@ func (s *Server) handlePublicListAutores() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		autores, err := s.store.autor.Listar()
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				logger.Error("no se encontró ningún autor", err.Error())
+				logger.Error("no se encontró ningún autor: %s", err.Error())
 				s.handleError(r, w, 404, "No se ha encontrado ningún autor ¿está el servidor bien configurado?")
 			} else {
 				logger.Error("error inesperado recuperando datos: %s", err.Error())
@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"net/http"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+func (s *Server) handlePublicListSeries() http.HandlerFunc {
+	type response struct {
+		Series []models.Serie
+		Meta   PageMeta
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logger.DesdeContexto(r.Context())
+		series, err := s.store.serie.Listar()
+		if err != nil {
+			logger.Error("error obteniendo series: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
+		// Solo se muestran las series con al menos una publicación visible
+		var publicas = make([]models.Serie, 0)
+		for _, serie := range series {
+			serie.Publicaciones = serie.Publicaciones.FiltrarPublicas().FiltrarRetiradas()
+			if len(serie.Publicaciones) > 0 {
+				publicas = append(publicas, serie)
+			}
+		}
+
+		err = templates.Render(w, "series.html", response{
+			Series: publicas,
+			Meta: PageMeta{
+				Titulo:      "Series",
+				Descripcion: "Series de artículos de Vigo360 publicados en varias partes",
+				Canonica:    fullCanonica("/series"),
+				BaseUrl:     baseUrl(),
+			},
+		})
+
+		if err != nil {
+			logger.Error("error generando página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
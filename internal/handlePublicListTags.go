@@ -18,7 +18,7 @@ func (s *Server) handlePublicListTags() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		var tags, err = s.store.tag.Listar()
 		for i, t := range tags {
 			if t.Publicaciones < 1 {
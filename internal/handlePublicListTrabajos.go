@@ -18,7 +18,7 @@ func (s *Server) handlePublicListTrabajos() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		trabajos, err := s.store.trabajo.Listar()
 
 		if err != nil && !errors.Is(err, sql.ErrNoRows) {
@@ -29,7 +29,7 @@ func (s *Server) handlePublicNodbPage() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		var (
 			page = r.URL.Path[1:]
 			meta PageMeta
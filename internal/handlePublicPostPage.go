@@ -20,6 +20,9 @@ func (s *Server) handlePublicPostPage() http.HandlerFunc {
 		LoggedIn        bool
 		Comentarios     []service.ComentarioTree
 		Recommendations []Sugerencia
+		Serie           models.Serie
+		SerieAnterior   *models.Publicacion
+		SerieSiguiente  *models.Publicacion
 		Meta            PageMeta
 		HcaptchaClient  string
 	}
@@ -27,7 +30,7 @@ func (s *Server) handlePublicPostPage() http.HandlerFunc {
 	var cs = service.NewComentarioService(s.store.comentario, s.store.publicacion)
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		log := logger.DesdeContexto(r.Context())
 		var sc, err = r.Cookie("sess")
 		var loggedIn = false
 		if err == nil {
@@ -62,6 +65,21 @@ func (s *Server) handlePublicPostPage() http.HandlerFunc {
 			recommendations = nr
 		}
 
+		var (
+			serie          models.Serie
+			serieAnterior  *models.Publicacion
+			serieSiguiente *models.Publicacion
+		)
+		if post.Serie_id != "" {
+			if ns, err := s.store.serie.Obtener(post.Serie_id); err != nil {
+				log.Error("error recuperando serie %s: %s", post.Serie_id, err.Error())
+			} else {
+				serie = ns
+				serie.Publicaciones = serie.Publicaciones.FiltrarPublicas().FiltrarRetiradas()
+				serieAnterior, serieSiguiente = serie.ObtenerAdyacentes(post.Id)
+			}
+		}
+
 		var keywords = ""
 		for _, t := range post.Tags {
 			keywords += t.Nombre + ","
@@ -84,6 +102,9 @@ func (s *Server) handlePublicPostPage() http.HandlerFunc {
 			Post:            post,
 			LoggedIn:        loggedIn,
 			Recommendations: recommendations,
+			Serie:           serie,
+			SerieAnterior:   serieAnterior,
+			SerieSiguiente:  serieSiguiente,
 			Comentarios:     ct,
 			HcaptchaClient:  os.Getenv("HCAPTCHA_SITEKEY"),
 			Meta: PageMeta{
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/templates"
+)
+
+func (s *Server) handlePublicSeriePage() http.HandlerFunc {
+	type response struct {
+		Serie models.Serie
+		Meta  PageMeta
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := logger.DesdeContexto(r.Context())
+		req_serieid := mux.Vars(r)["serieid"]
+
+		serie, err := s.store.serie.Obtener(req_serieid)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				logger.Error("no se encontró la serie: %s", err.Error())
+				s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			} else {
+				logger.Error("error recuperando la serie: %s", err.Error())
+				s.handleError(r, w, 500, messages.ErrorDatos)
+			}
+			return
+		}
+
+		serie.Publicaciones = serie.Publicaciones.FiltrarPublicas().FiltrarRetiradas()
+		if len(serie.Publicaciones) == 0 {
+			logger.Error("la serie %s no tiene publicaciones públicas", serie.Id)
+			s.handleError(r, w, 404, messages.ErrorPaginaNoEncontrada)
+			return
+		}
+
+		err = templates.Render(w, "series-id.html", response{
+			Serie: serie,
+			Meta: PageMeta{
+				Titulo:      serie.Titulo,
+				Descripcion: "Serie de artículos de Vigo360: " + serie.Titulo,
+				Canonica:    fullCanonica("/series/" + serie.Id),
+				Miniatura:   fullCanonica("/static/thumb/" + serie.Publicaciones[0].Id + ".jpg"),
+				BaseUrl:     baseUrl(),
+			},
+		})
+
+		if err != nil {
+			logger.Error("error generando página: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorRender)
+		}
+	}
+}
@@ -24,7 +24,7 @@ type SitemapPage struct {
 
 func (s *Server) handlePublicSitemap() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		var pages = []SitemapQuery{}
 
 		autores, err := s.store.autor.Listar()
@@ -48,6 +48,13 @@ func (s *Server) handlePublicSitemap() http.HandlerFunc {
 			return
 		}
 
+		series, err := s.store.serie.Listar()
+		if err != nil {
+			logger.Error("error recuperando series: %s", err.Error())
+			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
+		}
+
 		publicaciones, err := s.store.publicacion.Listar()
 		if err != nil {
 			logger.Error("error recuperando publicaciones: %s", err.Error())
@@ -63,6 +70,7 @@ func (s *Server) handlePublicSitemap() http.HandlerFunc {
 		pages = append(pages, SitemapQuery{Uri: "/autores", Changefreq: "monthly", Priority: "0.5"})
 		pages = append(pages, SitemapQuery{Uri: "/trabajos", Changefreq: "monthly", Priority: "0.5"})
 		pages = append(pages, SitemapQuery{Uri: "/tags", Changefreq: "monthly", Priority: "0.5"})
+		pages = append(pages, SitemapQuery{Uri: "/series", Changefreq: "monthly", Priority: "0.5"})
 
 		for _, autor := range autores {
 			pages = append(pages, SitemapQuery{Uri: "/autores/" + autor.Id, Changefreq: "weekly", Priority: "0.3"})
@@ -70,6 +78,12 @@ func (s *Server) handlePublicSitemap() http.HandlerFunc {
 		for _, tag := range tags {
 			pages = append(pages, SitemapQuery{Uri: "/tags/" + tag.Id, Changefreq: "weekly", Priority: "0.3"})
 		}
+		for _, serie := range series {
+			if len(serie.Publicaciones.FiltrarPublicas().FiltrarRetiradas()) == 0 {
+				continue
+			}
+			pages = append(pages, SitemapQuery{Uri: "/series/" + serie.Id, Changefreq: "weekly", Priority: "0.3"})
+		}
 
 		for _, trabajo := range trabajos {
 			pages = append(pages, SitemapQuery{Uri: "/trabajos/" + trabajo.Id, Changefreq: "monthly", Priority: "0.3"})
@@ -20,7 +20,7 @@ func (s *Server) handlePublicTagPage() http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		req_tagid := mux.Vars(r)["tagid"]
 
 		tag, err := s.store.tag.Obtener(req_tagid)
@@ -6,7 +6,6 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
-	"vigo360.es/new/internal/database"
 	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
 	"vigo360.es/new/internal/models"
@@ -14,14 +13,8 @@ import (
 )
 
 func (s *Server) handlePublicTrabajoPage() http.HandlerFunc {
-	// TODO: Refactor esto
-	type Adjunto struct {
-		Nombre_archivo string
-		Titulo         string
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := logger.NewLogger(r.Context().Value(ridContextKey("rid")).(string))
+		logger := logger.DesdeContexto(r.Context())
 		trabajoid := mux.Vars(r)["trabajoid"]
 
 		var trabajo models.Trabajo
@@ -39,18 +32,16 @@ func (s *Server) handlePublicTrabajoPage() http.HandlerFunc {
 			trabajo = nt
 		}
 
-		var adjuntos = make([]Adjunto, 0)
-		// TODO: Refactor esto
-		err := database.GetDB().Select(&adjuntos, "SELECT nombre_archivo, titulo FROM adjuntos WHERE trabajo_id = ?;", trabajo.Id)
-
-		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		adjuntos, err := s.store.adjunto.Listar(trabajo.Id)
+		if err != nil {
 			logger.Error("error recuperando adjuntos: %s", err.Error())
 			s.handleError(r, w, 500, messages.ErrorDatos)
+			return
 		}
 
 		err = templates.Render(w, "trabajos-id.html", struct {
 			Trabajo  models.Trabajo
-			Adjuntos []Adjunto
+			Adjuntos []models.Adjunto
 			Meta     PageMeta
 		}{
 			Trabajo:  trabajo,
@@ -1,8 +1,13 @@
 package internal
 
 import (
+	"fmt"
 	"html/template"
+	"net"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
 )
 
 func baseUrl() template.URL {
@@ -19,3 +24,78 @@ func getMinimo(x int, y int) int {
 	}
 	return y
 }
+
+/*
+Lee PROXIES_CONFIABLES, IPs o rangos CIDR separados por comas de los proxies de los que se acepta X-Forwarded-For,
+además de loopback, donde está el nginx de la instalación habitual. main la llama al arrancar para validarla.
+*/
+func ProxiesConfiablesDesdeEnv() ([]*net.IPNet, error) {
+	var proxies = make([]*net.IPNet, 0)
+	for _, valor := range strings.Split(os.Getenv("PROXIES_CONFIABLES"), ",") {
+		if valor = strings.TrimSpace(valor); valor == "" {
+			continue
+		}
+		if !strings.Contains(valor, "/") {
+			if ip := net.ParseIP(valor); ip != nil && ip.To4() != nil {
+				valor += "/32"
+			} else {
+				valor += "/128"
+			}
+		}
+		_, red, err := net.ParseCIDR(valor)
+		if err != nil {
+			return nil, fmt.Errorf("PROXIES_CONFIABLES tiene una IP o rango no válido: %s", valor)
+		}
+		proxies = append(proxies, red)
+	}
+	return proxies, nil
+}
+
+// Se leen una sola vez. main ya ha comprobado que son válidos antes de servir peticiones
+var proxiesConfiables = sync.OnceValue(func() []*net.IPNet {
+	proxies, _ := ProxiesConfiablesDesdeEnv()
+	return proxies
+})
+
+func esProxyConfiable(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+	for _, red := range proxiesConfiables() {
+		if red.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+IP desde la que se hace la petición. Cada proxy añade la IP que ve al final de X-Forwarded-For, y lo que haya antes lo
+puede haber puesto el cliente, así que solo se hace caso a la cabecera si la conexión viene de un proxy de confianza, y
+se toma la última IP que no sea de uno de ellos.
+*/
+func ipCliente(r *http.Request) string {
+	var remota = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remota = host
+	}
+	if !esProxyConfiable(net.ParseIP(remota)) {
+		return remota
+	}
+
+	var ips = strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(ips) - 1; i >= 0; i-- {
+		var ip = strings.TrimSpace(ips[i])
+		if ip == "" {
+			continue
+		}
+		if !esProxyConfiable(net.ParseIP(ip)) {
+			return ip
+		}
+		remota = ip
+	}
+	return remota
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIpCliente(t *testing.T) {
+	var anterior = proxiesConfiables
+	t.Cleanup(func() { proxiesConfiables = anterior })
+	t.Setenv("PROXIES_CONFIABLES", "203.0.113.0/24, 2001:db8::1")
+	proxies, err := ProxiesConfiablesDesdeEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxiesConfiables = func() []*net.IPNet { return proxies }
+
+	var casos = []struct {
+		nombre string
+		remota string
+		xff    string
+		ip     string
+	}{
+		{"sin proxy", "198.51.100.7:5000", "", "198.51.100.7"},
+		{"cliente que falsea la cabecera", "198.51.100.7:5000", "10.9.8.7", "198.51.100.7"},
+		{"nginx local", "127.0.0.1:5000", "1.2.3.4, 198.51.100.7", "198.51.100.7"},
+		{"nginx local sin cabecera", "[::1]:5000", "", "::1"},
+		{"cadena de proxies configurados", "127.0.0.1:5000", "1.2.3.4, 198.51.100.7, 203.0.113.9", "198.51.100.7"},
+		{"proxy configurado por IPv6", "[2001:db8::1]:5000", "198.51.100.7", "198.51.100.7"},
+		{"solo proxies", "127.0.0.1:5000", "203.0.113.8, 203.0.113.9", "203.0.113.8"},
+	}
+	for _, c := range casos {
+		var r = httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = c.remota
+		if c.xff != "" {
+			r.Header.Set("X-Forwarded-For", c.xff)
+		}
+		if ip := ipCliente(r); ip != c.ip {
+			t.Errorf("%s: se esperaba %s, se obtuvo %s", c.nombre, c.ip, ip)
+		}
+	}
+}
+
+func TestProxiesConfiablesNoValidos(t *testing.T) {
+	t.Setenv("PROXIES_CONFIABLES", "10.0.0.0/8, nginx")
+	if _, err := ProxiesConfiablesDesdeEnv(); err == nil {
+		t.Fatal("un valor que no es una IP ni un rango debe fallar")
+	}
+}
@@ -0,0 +1,147 @@
+package imagenes
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Formato string
+
+const (
+	FormatoAvif Formato = "avif"
+	FormatoWebp Formato = "webp"
+	FormatoJpg  Formato = "jpg"
+)
+
+// Tipo MIME para el atributo type de <source>
+func (f Formato) Mime() string {
+	switch f {
+	case FormatoJpg:
+		return "image/jpeg"
+	default:
+		return "image/" + string(f)
+	}
+}
+
+type Recorte string
+
+const (
+	// Recorta a la proporción configurada quitando lo mismo por ambos lados
+	RecorteCentro Recorte = "centro"
+	// Recorta a la proporción configurada manteniendo el punto de foco de la portada lo más centrado posible
+	RecorteFoco Recorte = "foco"
+	// Mantiene la proporción original de la imagen
+	RecorteNinguno Recorte = "ninguno"
+)
+
+// Versiones de cada portada que se generan
+type Configuracion struct {
+	// Anchos en píxeles, de menor a mayor
+	Anchos []int
+	// Formatos de más a menos preferido. El último se usa como <img> para los navegadores sin <picture>
+	Formatos []Formato
+	// Proporción ancho/alto a la que se recortan las portadas, salvo con RecorteNinguno
+	Proporcion float64
+	Recorte    Recorte
+	Calidad    int
+}
+
+var ConfiguracionPorDefecto = Configuracion{
+	Anchos:     []int{400, 800, 1200},
+	Formatos:   []Formato{FormatoAvif, FormatoWebp, FormatoJpg},
+	Proporcion: 16.0 / 9.0,
+	Recorte:    RecorteFoco,
+	Calidad:    80,
+}
+
+var (
+	configuracion   = ConfiguracionPorDefecto
+	configuracionMu sync.RWMutex
+)
+
+// Cambia la configuración usada para generar portadas y para el <picture> de las plantillas
+func Configurar(c Configuracion) {
+	configuracionMu.Lock()
+	defer configuracionMu.Unlock()
+	configuracion = c
+}
+
+func ConfiguracionActual() Configuracion {
+	configuracionMu.RLock()
+	defer configuracionMu.RUnlock()
+	return configuracion
+}
+
+// Alto de una versión de la portada para el ancho dado, o 0 si depende de la imagen original
+func (c Configuracion) Alto(ancho int) int {
+	if c.Recorte == RecorteNinguno || c.Proporcion <= 0 {
+		return 0
+	}
+	return int(float64(ancho)/c.Proporcion + 0.5)
+}
+
+/*
+Lee la configuración de las variables IMAGENES_ANCHOS (p.ej. "400,800,1200"), IMAGENES_FORMATOS ("avif,webp,jpg"),
+IMAGENES_PROPORCION ("16:9"), IMAGENES_RECORTE ("centro", "foco" o "ninguno") e IMAGENES_CALIDAD (1-100). Las que
+no estén definidas toman el valor por defecto.
+*/
+func ConfiguracionDesdeEnv() (Configuracion, error) {
+	var c = ConfiguracionPorDefecto
+
+	if val := os.Getenv("IMAGENES_ANCHOS"); val != "" {
+		c.Anchos = nil
+		for _, a := range strings.Split(val, ",") {
+			ancho, err := strconv.Atoi(strings.TrimSpace(a))
+			if err != nil || ancho <= 0 {
+				return c, fmt.Errorf("IMAGENES_ANCHOS contiene un ancho no válido: %s", a)
+			}
+			c.Anchos = append(c.Anchos, ancho)
+		}
+		sort.Ints(c.Anchos)
+	}
+
+	if val := os.Getenv("IMAGENES_FORMATOS"); val != "" {
+		c.Formatos = nil
+		for _, f := range strings.Split(val, ",") {
+			switch formato := Formato(strings.TrimSpace(f)); formato {
+			case FormatoAvif, FormatoWebp, FormatoJpg:
+				c.Formatos = append(c.Formatos, formato)
+			default:
+				return c, fmt.Errorf("IMAGENES_FORMATOS contiene un formato no válido: %s", f)
+			}
+		}
+	}
+
+	if val := os.Getenv("IMAGENES_PROPORCION"); val != "" {
+		ancho, alto, ok := strings.Cut(val, ":")
+		a, errAncho := strconv.ParseFloat(ancho, 64)
+		b, errAlto := strconv.ParseFloat(alto, 64)
+		if !ok || errAncho != nil || errAlto != nil || a <= 0 || b <= 0 {
+			return c, fmt.Errorf("IMAGENES_PROPORCION debe tener la forma ancho:alto, no %s", val)
+		}
+		c.Proporcion = a / b
+	}
+
+	if val := os.Getenv("IMAGENES_RECORTE"); val != "" {
+		switch recorte := Recorte(val); recorte {
+		case RecorteCentro, RecorteFoco, RecorteNinguno:
+			c.Recorte = recorte
+		default:
+			return c, fmt.Errorf("IMAGENES_RECORTE debe ser centro, foco o ninguno, no %s", val)
+		}
+	}
+
+	if val := os.Getenv("IMAGENES_CALIDAD"); val != "" {
+		calidad, err := strconv.Atoi(val)
+		if err != nil || calidad < 1 || calidad > 100 {
+			return c, fmt.Errorf("IMAGENES_CALIDAD debe ser un número entre 1 y 100")
+		}
+		c.Calidad = calidad
+	}
+
+	return c, nil
+}
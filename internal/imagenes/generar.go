@@ -0,0 +1,277 @@
+package imagenes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chai2010/webp"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gen2brain/avif"
+	"github.com/nfnt/resize"
+	"vigo360.es/new/internal/metricas"
+)
+
+// Error que indica que el tipo MIME no es válido
+var ErrFormatoNoValido = errors.New("formato de imagen no válido")
+
+// Punto de la imagen que debe quedar visible al recortar, como fracción del ancho y del alto (de 0 a 1)
+type Foco struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+var FocoCentro = Foco{X: 0.5, Y: 0.5}
+
+// Tamaños de las portadas que usaba la versión anterior, que aún enlazan los datos estructurados y el panel
+const (
+	anchoPortadaClasica    = 1200
+	anchoMiniaturaClasica  = 800
+	carpetaVersiones       = "portadas"
+	proporcionClasica      = 16.0 / 9.0
+	velocidadAvif          = 8
+	extensionFocoOriginal  = ".foco.json"
+	permisosFicheroPortada = 0o644
+	// Los originales conservan los metadatos, como la ubicación, así que solo los lee el servidor
+	permisosFicheroOriginal    = 0o600
+	permisosDirectorioOriginal = 0o700
+)
+
+// Error que indica que el directorio de originales está dentro del que se sirve públicamente
+var ErrOriginalesPublicos = errors.New("el directorio de originales no puede estar dentro de UPLOAD_PATH, que se sirve en /static")
+
+// Ruta relativa a UPLOAD_PATH de una versión de una portada
+func RutaVersion(id string, ancho int, formato Formato) string {
+	return fmt.Sprintf("%s/%s-%d.%s", carpetaVersiones, id, ancho, formato)
+}
+
+// URL pública de una versión de una portada
+func Url(id string, ancho int, formato Formato) string {
+	return "/static/" + RutaVersion(id, ancho, formato)
+}
+
+// Recibe una imagen como bytes, detecta el tipo y la decodifica con el decodificador adecuado
+func Decodificar(datos []byte) (image.Image, error) {
+	ctype := mimetype.Detect(datos)
+
+	switch {
+	case ctype.Is("image/png"):
+		return png.Decode(bytes.NewReader(datos))
+	case ctype.Is("image/jpeg"):
+		return jpeg.Decode(bytes.NewReader(datos))
+	case ctype.Is("image/webp"):
+		return webp.Decode(bytes.NewReader(datos))
+	case ctype.Is("image/avif"):
+		return avif.Decode(bytes.NewReader(datos))
+	default:
+		return nil, ErrFormatoNoValido
+	}
+}
+
+// Recorta la imagen a la proporción indicada, dejando el foco lo más centrado que permitan los bordes
+func Recortar(img image.Image, proporcion float64, foco Foco) image.Image {
+	var limites = img.Bounds()
+	var ancho, alto = limites.Dx(), limites.Dy()
+	if proporcion <= 0 || ancho == 0 || alto == 0 {
+		return img
+	}
+
+	var anchoRecorte, altoRecorte = ancho, alto
+	if float64(ancho)/float64(alto) > proporcion {
+		anchoRecorte = int(float64(alto)*proporcion + 0.5)
+	} else {
+		altoRecorte = int(float64(ancho)/proporcion + 0.5)
+	}
+
+	var x = desplazamiento(foco.X, ancho, anchoRecorte)
+	var y = desplazamiento(foco.Y, alto, altoRecorte)
+	var rect = image.Rect(x, y, x+anchoRecorte, y+altoRecorte).Add(limites.Min)
+
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+
+	var recortada = image.NewRGBA(image.Rect(0, 0, anchoRecorte, altoRecorte))
+	draw.Draw(recortada, recortada.Bounds(), img, rect.Min, draw.Src)
+	return recortada
+}
+
+// Origen del recorte en un eje para que el foco quede en el centro sin salirse de la imagen
+func desplazamiento(foco float64, total int, recorte int) int {
+	var inicio = int(foco*float64(total)+0.5) - recorte/2
+	if inicio < 0 {
+		return 0
+	}
+	if inicio > total-recorte {
+		return total - recorte
+	}
+	return inicio
+}
+
+/*
+Genera en directorio todas las versiones configuradas de la portada, además de images/{id}.webp y thumb/{id}.jpg
+con la proporción clásica. Ninguna versión se deforma: si la imagen no tiene la proporción configurada, se recorta.
+*/
+func Generar(directorio string, id string, original []byte, foco Foco) error {
+	var c = ConfiguracionActual()
+
+	img, err := Decodificar(original)
+	if err != nil {
+		return err
+	}
+
+	var base = img
+	switch c.Recorte {
+	case RecorteCentro:
+		base = Recortar(img, c.Proporcion, FocoCentro)
+	case RecorteFoco:
+		base = Recortar(img, c.Proporcion, foco)
+	}
+
+	if err := os.MkdirAll(filepath.Join(directorio, carpetaVersiones), 0o755); err != nil {
+		return err
+	}
+
+	for _, ancho := range c.Anchos {
+		version := resize.Resize(uint(ancho), uint(c.Alto(ancho)), base, resize.Lanczos3)
+		for _, formato := range c.Formatos {
+			if err := guardarVersion(filepath.Join(directorio, RutaVersion(id, ancho, formato)), version, formato, c.Calidad); err != nil {
+				return fmt.Errorf("error generando %s de %dpx: %w", formato, ancho, err)
+			}
+		}
+	}
+
+	var clasica = Recortar(img, proporcionClasica, foco)
+	var portada = resize.Resize(anchoPortadaClasica, uint(anchoPortadaClasica/proporcionClasica), clasica, resize.Lanczos3)
+	if err := guardarVersion(filepath.Join(directorio, "images", id+".webp"), portada, FormatoWebp, c.Calidad); err != nil {
+		return fmt.Errorf("error generando imagen webp: %w", err)
+	}
+	var miniatura = resize.Resize(anchoMiniaturaClasica, uint(anchoMiniaturaClasica/proporcionClasica), portada, resize.Lanczos3)
+	if err := guardarVersion(filepath.Join(directorio, "thumb", id+".jpg"), miniatura, FormatoJpg, 90); err != nil {
+		return fmt.Errorf("error generando imagen jpg: %w", err)
+	}
+
+	return nil
+}
+
+func codificar(w io.Writer, img image.Image, formato Formato, calidad int) error {
+	switch formato {
+	case FormatoAvif:
+		return avif.Encode(w, img, avif.Options{Quality: calidad, Speed: velocidadAvif})
+	case FormatoWebp:
+		return webp.Encode(w, img, &webp.Options{Quality: float32(calidad)})
+	case FormatoJpg:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: calidad})
+	default:
+		return ErrFormatoNoValido
+	}
+}
+
+// Escribe primero a un fichero temporal, para no servir nunca una imagen a medio escribir
+func guardarVersion(ruta string, img image.Image, formato Formato, calidad int) error {
+	var buf bytes.Buffer
+	var inicio = time.Now()
+	if err := codificar(&buf, img, formato, calidad); err != nil {
+		return err
+	}
+	metricas.DuracionImagenes.Observar(time.Since(inicio).Seconds(), string(formato))
+
+	var temporal = ruta + ".tmp"
+	if err := os.WriteFile(temporal, buf.Bytes(), permisosFicheroPortada); err != nil {
+		return err
+	}
+	return os.Rename(temporal, ruta)
+}
+
+/*
+Comprueba que el directorio de originales no está dentro del directorio público, ni es el mismo, siguiendo los enlaces
+simbólicos de los que ya existen. Los originales tienen los metadatos intactos y hay portadas de borradores.
+*/
+func ComprobarOriginales(originales string, publico string) error {
+	var resolver = func(ruta string) (string, error) {
+		abs, err := filepath.Abs(ruta)
+		if err != nil {
+			return "", err
+		}
+		if real, err := filepath.EvalSymlinks(abs); err == nil {
+			return real, nil
+		}
+		return abs, nil
+	}
+
+	originales, err := resolver(originales)
+	if err != nil {
+		return err
+	}
+	publico, err = resolver(publico)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(publico, originales)
+	if err != nil {
+		return err
+	}
+	if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+		return ErrOriginalesPublicos
+	}
+	return nil
+}
+
+/*
+Guarda la portada tal cual se subió junto con su foco en el directorio de originales, ORIGINALES_PATH, para poder
+regenerar las versiones más adelante. Ese directorio no se sirve, así que no se quitan los metadatos.
+*/
+func GuardarOriginal(originales string, id string, original []byte, foco Foco) error {
+	if err := os.MkdirAll(originales, permisosDirectorioOriginal); err != nil {
+		return err
+	}
+
+	focoBytes, err := json.Marshal(foco)
+	if err != nil {
+		return err
+	}
+
+	var ruta = filepath.Join(originales, id)
+	if err := os.WriteFile(ruta, original, permisosFicheroOriginal); err != nil {
+		return err
+	}
+	return os.WriteFile(ruta+extensionFocoOriginal, focoBytes, permisosFicheroOriginal)
+}
+
+/*
+Lee la portada original y su foco del directorio de originales. Las portadas subidas antes de guardar originales no lo
+tienen, así que se usa la mayor versión clásica que exista en el directorio público.
+*/
+func LeerOriginal(originales string, publico string, id string) ([]byte, Foco, error) {
+	var ruta = filepath.Join(originales, id)
+
+	original, err := os.ReadFile(ruta)
+	if errors.Is(err, os.ErrNotExist) {
+		original, err = os.ReadFile(filepath.Join(publico, "images", id+".webp"))
+		return original, FocoCentro, err
+	}
+	if err != nil {
+		return nil, FocoCentro, err
+	}
+
+	var foco = FocoCentro
+	if focoBytes, err := os.ReadFile(ruta + extensionFocoOriginal); err == nil {
+		if err := json.Unmarshal(focoBytes, &foco); err != nil {
+			return nil, FocoCentro, err
+		}
+	}
+	return original, foco, nil
+}
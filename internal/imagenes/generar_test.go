@@ -0,0 +1,90 @@
+package imagenes
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComprobarOriginales(t *testing.T) {
+	var base = t.TempDir()
+	var publico = filepath.Join(base, "assets")
+	os.MkdirAll(filepath.Join(publico, "originales"), 0o755)
+	os.MkdirAll(filepath.Join(base, "assets-originales"), 0o755)
+	// Un enlace fuera del directorio público que apunta dentro de él
+	os.Symlink(filepath.Join(publico, "originales"), filepath.Join(base, "enlace"))
+
+	var casos = []struct {
+		originales string
+		valido     bool
+	}{
+		{filepath.Join(publico, "originales"), false},
+		{publico, false},
+		{publico + "/./originales/../originales", false},
+		{filepath.Join(publico, "no-existe"), false},
+		{filepath.Join(base, "enlace"), false},
+		{filepath.Join(base, "assets-originales"), true},
+		{filepath.Join(base, "originales"), true},
+		{base, true},
+	}
+	for _, c := range casos {
+		err := ComprobarOriginales(c.originales, publico)
+		if c.valido && err != nil {
+			t.Errorf("%s debería ser válido: %s", c.originales, err)
+		}
+		if !c.valido && !errors.Is(err, ErrOriginalesPublicos) {
+			t.Errorf("%s está dentro del directorio público y se aceptó", c.originales)
+		}
+	}
+}
+
+// Los valores de ejemplo se copian tal cual en muchas instalaciones, así que no pueden publicar los originales
+func TestOriginalesDeEjemploNoSePublican(t *testing.T) {
+	f, err := os.Open("../../.env.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var valores = map[string]string{}
+	var scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		if clave, valor, ok := strings.Cut(scanner.Text(), "="); ok {
+			valores[clave] = strings.Trim(valor, `"`)
+		}
+	}
+
+	if valores["ORIGINALES_PATH"] == "" || valores["UPLOAD_PATH"] == "" {
+		t.Fatal("faltan ORIGINALES_PATH o UPLOAD_PATH en .env.example")
+	}
+	if err := ComprobarOriginales(valores["ORIGINALES_PATH"], valores["UPLOAD_PATH"]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGuardarOriginalNoEscribeEnElDirectorioPublico(t *testing.T) {
+	var publico = t.TempDir()
+	var originales = filepath.Join(t.TempDir(), "originales")
+
+	if err := GuardarOriginal(originales, "faro", []byte("imagen"), Foco{X: 0.2, Y: 0.8}); err != nil {
+		t.Fatal(err)
+	}
+
+	original, foco, err := LeerOriginal(originales, publico, "faro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(original) != "imagen" || foco != (Foco{X: 0.2, Y: 0.8}) {
+		t.Fatalf("se leyó %q con foco %v", original, foco)
+	}
+
+	if entradas, _ := os.ReadDir(publico); len(entradas) != 0 {
+		t.Fatalf("el directorio público no debería tener nada: %v", entradas)
+	}
+	if info, _ := os.Stat(filepath.Join(originales, "faro")); info.Mode().Perm()&0o077 != 0 {
+		t.Fatalf("el original no debería ser legible por otros usuarios: %s", info.Mode())
+	}
+}
@@ -0,0 +1,48 @@
+package imagenes
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+)
+
+/*
+Genera un <picture> con todas las versiones de la portada para que el navegador elija formato y tamaño. sizes es el
+atributo del mismo nombre, el ancho con el que se muestra la imagen (p.ej. "(max-width: 960px) 100vw, 370px").
+*/
+func Picture(id string, alt string, sizes string, lazy bool) template.HTML {
+	var c = ConfiguracionActual()
+	if len(c.Anchos) == 0 || len(c.Formatos) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<picture>")
+
+	var ultimo = c.Formatos[len(c.Formatos)-1]
+	for _, formato := range c.Formatos[:len(c.Formatos)-1] {
+		fmt.Fprintf(&sb, `<source type="%s" srcset="%s" sizes="%s">`, formato.Mime(), srcset(c, id, formato), html.EscapeString(sizes))
+	}
+
+	// La versión intermedia sirve de src para los navegadores que no entienden srcset
+	var anchoSrc = c.Anchos[len(c.Anchos)/2]
+	fmt.Fprintf(&sb, `<img src="%s" srcset="%s" sizes="%s" alt="%s"`, html.EscapeString(Url(id, anchoSrc, ultimo)), srcset(c, id, ultimo), html.EscapeString(sizes), html.EscapeString(alt))
+	if alto := c.Alto(anchoSrc); alto > 0 {
+		fmt.Fprintf(&sb, ` width="%d" height="%d"`, anchoSrc, alto)
+	}
+	if lazy {
+		sb.WriteString(` loading="lazy"`)
+	}
+	sb.WriteString(` decoding="async"></picture>`)
+
+	return template.HTML(sb.String())
+}
+
+func srcset(c Configuracion, id string, formato Formato) string {
+	var candidatos = make([]string, 0, len(c.Anchos))
+	for _, ancho := range c.Anchos {
+		candidatos = append(candidatos, fmt.Sprintf("%s %dw", html.EscapeString(Url(id, ancho, formato)), ancho))
+	}
+	return strings.Join(candidatos, ", ")
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"strings"
+
+	"vigo360.es/new/internal/imagenes"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/models"
+)
+
+/*
+ImportarMedia añade a la mediateca las imágenes de extra/ que se subieron antes de que existiera, sin texto
+alternativo ni leyenda. Los nombres tenían la forma {publicacion}-{salt}.webp, así que se intenta recuperar la
+publicación de origen. Las imágenes que ya están en la mediateca se ignoran.
+*/
+func ImportarMedia(c *Container) error {
+	log := logger.NewLogger("importar-media")
+	var directorio = os.Getenv("UPLOAD_PATH") + "/extra"
+
+	existentes, err := c.media.Listar("")
+	if err != nil {
+		return fmt.Errorf("error listando mediateca: %w", err)
+	}
+	var yaImportadas = make(map[string]bool, len(existentes))
+	for _, m := range existentes {
+		yaImportadas[m.Nombre_archivo] = true
+	}
+
+	archivos, err := os.ReadDir(directorio)
+	if err != nil {
+		return fmt.Errorf("error leyendo %s: %w", directorio, err)
+	}
+
+	for _, de := range archivos {
+		var nombre = de.Name()
+		if de.IsDir() || yaImportadas[nombre] || !(strings.HasSuffix(nombre, ".webp") || strings.HasSuffix(nombre, ".jpg")) {
+			continue
+		}
+
+		contenido, err := os.ReadFile(directorio + "/" + nombre)
+		if err != nil {
+			log.Error("error leyendo %s: %s", nombre, err.Error())
+			continue
+		}
+		var img image.Image
+		if img, err = imagenes.Decodificar(contenido); err != nil {
+			log.Error("error decodificando %s: %s", nombre, err.Error())
+			continue
+		}
+
+		var media = models.Media{
+			Nombre_archivo: nombre,
+			Ancho:          img.Bounds().Dx(),
+			Alto:           img.Bounds().Dy(),
+			Bytes:          len(contenido),
+		}
+		if i := strings.LastIndex(nombre, "-"); i > 0 {
+			if _, err := c.publicacion.ObtenerPorId(nombre[:i], false); err == nil {
+				media.Publicacion_id = nombre[:i]
+			}
+		}
+
+		if _, err := c.media.Crear(media); err != nil {
+			log.Error("error guardando %s: %s", nombre, err.Error())
+			continue
+		}
+		log.Information("importada %s", nombre)
+	}
+
+	return nil
+}
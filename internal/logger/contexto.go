@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+type camposContextKey string
+
+// Campos de una petición que se van conociendo por el camino, como el autor tras comprobar la sesión o el estado
+type campos struct {
+	mu      sync.Mutex
+	claves  []string
+	valores map[string]any
+}
+
+// Devuelve un contexto en el que Anotar guarda campos para los loggers de DesdeContexto, empezando por los indicados
+func ConCampos(ctx context.Context, args ...any) context.Context {
+	var c = &campos{valores: map[string]any{}}
+	ctx = context.WithValue(ctx, camposContextKey("campos"), c)
+	for i := 0; i+1 < len(args); i += 2 {
+		if clave, ok := args[i].(string); ok {
+			Anotar(ctx, clave, args[i+1])
+		}
+	}
+	return ctx
+}
+
+// Añade o sustituye un campo de la petición. Solo afecta a los loggers creados después
+func Anotar(ctx context.Context, clave string, valor any) {
+	c, ok := ctx.Value(camposContextKey("campos")).(*campos)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, existe := c.valores[clave]; !existe {
+		c.claves = append(c.claves, clave)
+	}
+	c.valores[clave] = valor
+}
+
+// Logger con los campos anotados hasta ahora en la petición, en el orden en que se anotaron
+func DesdeContexto(ctx context.Context) Logger {
+	var l = base.Load()
+	if c, ok := ctx.Value(camposContextKey("campos")).(*campos); ok {
+		c.mu.Lock()
+		var args = make([]any, 0, 2*len(c.claves))
+		for _, clave := range c.claves {
+			args = append(args, clave, c.valores[clave])
+		}
+		c.mu.Unlock()
+		l = l.With(args...)
+	}
+	return Logger{l: l}
+}
@@ -1,40 +1,128 @@
 package logger
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
+	"sync/atomic"
 )
 
+// Niveles de syslog que no tiene slog
+const (
+	LevelNotice   = slog.Level(2)
+	LevelCritical = slog.Level(12)
+)
+
+var base atomic.Pointer[slog.Logger]
+
+func init() {
+	base.Store(slog.New(nuevoManejadorTexto(os.Stdout, slog.LevelInfo)))
+}
+
+/*
+Configurar elige el formato y el nivel mínimo de todos los loggers creados a partir de ahora. El formato es texto,
+con la prioridad de syslog al principio de cada línea para journald, o json. El nivel es debug, info, notice, warning
+o error. Los valores vacíos dejan texto e info.
+*/
+func Configurar(w io.Writer, formato string, nivel string) error {
+	var minimo slog.Level
+	switch strings.ToLower(nivel) {
+	case "debug":
+		minimo = slog.LevelDebug
+	case "", "info":
+		minimo = slog.LevelInfo
+	case "notice":
+		minimo = LevelNotice
+	case "warning":
+		minimo = slog.LevelWarn
+	case "error":
+		minimo = slog.LevelError
+	default:
+		return fmt.Errorf("nivel de log desconocido: %s", nivel)
+	}
+
+	var manejador slog.Handler
+	switch strings.ToLower(formato) {
+	case "", "texto":
+		manejador = nuevoManejadorTexto(w, minimo)
+	case "json":
+		manejador = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: minimo, ReplaceAttr: nombrarNivel})
+	default:
+		return fmt.Errorf("formato de log desconocido: %s", formato)
+	}
+
+	base.Store(slog.New(manejador))
+	return nil
+}
+
+// Sin esto slog llama a los niveles propios INFO+2 y ERROR+4
+func nombrarNivel(grupos []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.LevelKey || len(grupos) > 0 {
+		return a
+	}
+	switch a.Value.Any().(slog.Level) {
+	case LevelNotice:
+		a.Value = slog.StringValue("NOTICE")
+	case LevelCritical:
+		a.Value = slog.StringValue("CRITICAL")
+	}
+	return a
+}
+
 type Logger struct {
-	requestId string
+	l *slog.Logger
 }
 
+// Logger con el identificador de la petición o del proceso en segundo plano que escribe
 func NewLogger(requestId string) Logger {
-	return Logger{requestId: requestId}
+	return Logger{l: base.Load().With("rid", requestId)}
+}
+
+// Devuelve un logger que añade los campos indicados, en pares de clave y valor, a cada mensaje
+func (l Logger) With(args ...any) Logger {
+	return Logger{l: l.l.With(args...)}
 }
 
-// A huge error, execution can't continue
-func (l *Logger) Critical(format string, a ...interface{}) {
-	fmt.Printf("<2>["+l.requestId+"] "+format+"\n", a...)
-	os.Exit(1)
+func (l *Logger) log(nivel slog.Level, format string, a ...interface{}) {
+	if l.l == nil {
+		l.l = base.Load()
+	}
+	if !l.l.Enabled(context.Background(), nivel) {
+		return
+	}
+	l.l.Log(context.Background(), nivel, fmt.Sprintf(format, a...))
+}
+
+// A huge error, execution can't continue. Returns it so the caller can stop
+func (l *Logger) Critical(format string, a ...interface{}) error {
+	l.log(LevelCritical, format, a...)
+	return fmt.Errorf(format, a...)
 }
 
 // Not severe error
 func (l *Logger) Error(format string, a ...interface{}) {
-	fmt.Printf("<3>["+l.requestId+"] "+format+"\n", a...)
+	l.log(slog.LevelError, format, a...)
 }
 
 // An error might occur
 func (l *Logger) Warning(format string, a ...interface{}) {
-	fmt.Printf("<4>["+l.requestId+"] "+format+"\n", a...)
+	l.log(slog.LevelWarn, format, a...)
 }
 
 // Not an error, just unusual
 func (l *Logger) Notice(format string, a ...interface{}) {
-	fmt.Printf("<5>["+l.requestId+"] "+format+"\n", a...)
+	l.log(LevelNotice, format, a...)
 }
 
 // Normal operations
 func (l *Logger) Information(format string, a ...interface{}) {
-	fmt.Printf("<6>["+l.requestId+"] "+format+"\n", a...)
+	l.log(slog.LevelInfo, format, a...)
+}
+
+// Details only useful while debugging
+func (l *Logger) Debug(format string, a ...interface{}) {
+	l.log(slog.LevelDebug, format, a...)
 }
@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+Escribe líneas como las de siempre, <prioridad>[rid] mensaje, seguidas del resto de campos como clave=valor. No pone
+la hora porque ya la añade journald.
+*/
+type manejadorTexto struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	minimo slog.Leveler
+	rid    string
+	campos []byte
+	grupo  string
+}
+
+func nuevoManejadorTexto(w io.Writer, minimo slog.Leveler) *manejadorTexto {
+	return &manejadorTexto{w: w, mu: &sync.Mutex{}, minimo: minimo}
+}
+
+// Prioridad de syslog que corresponde a cada nivel
+func prioridad(nivel slog.Level) int {
+	switch {
+	case nivel >= LevelCritical:
+		return 2
+	case nivel >= slog.LevelError:
+		return 3
+	case nivel >= slog.LevelWarn:
+		return 4
+	case nivel >= LevelNotice:
+		return 5
+	case nivel >= slog.LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+func (h *manejadorTexto) Enabled(_ context.Context, nivel slog.Level) bool {
+	return nivel >= h.minimo.Level()
+}
+
+func (h *manejadorTexto) Handle(_ context.Context, r slog.Record) error {
+	var rid = h.rid
+	var campos = append([]byte{}, h.campos...)
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "rid" && h.grupo == "" {
+			rid = a.Value.String()
+		} else {
+			campos = h.añadir(campos, h.grupo, a)
+		}
+		return true
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("<" + strconv.Itoa(prioridad(r.Level)) + ">")
+	if rid != "" {
+		buf.WriteString("[" + rid + "] ")
+	}
+	buf.WriteString(r.Message)
+	buf.Write(campos)
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *manejadorTexto) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var nuevo = *h
+	nuevo.campos = append([]byte{}, h.campos...)
+	for _, a := range attrs {
+		if a.Key == "rid" && h.grupo == "" {
+			nuevo.rid = a.Value.String()
+			continue
+		}
+		nuevo.campos = h.añadir(nuevo.campos, h.grupo, a)
+	}
+	return &nuevo
+}
+
+func (h *manejadorTexto) WithGroup(nombre string) slog.Handler {
+	if nombre == "" {
+		return h
+	}
+	var nuevo = *h
+	nuevo.grupo = h.grupo + nombre + "."
+	return &nuevo
+}
+
+// Añade el campo como " clave=valor", con los grupos aplanados en la clave como hace slog.TextHandler
+func (h *manejadorTexto) añadir(buf []byte, grupo string, a slog.Attr) []byte {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		for _, g := range a.Value.Group() {
+			buf = h.añadir(buf, grupo+a.Key+".", g)
+		}
+		return buf
+	}
+
+	buf = append(buf, ' ')
+	buf = append(buf, grupo+a.Key...)
+	buf = append(buf, '=')
+	var valor = a.Value.String()
+	if valor == "" || strings.ContainsAny(valor, " \t\n\"=") {
+		valor = strconv.Quote(valor)
+	}
+	return append(buf, valor...)
+}
@@ -10,6 +10,7 @@ var ErrorFormulario ErrorMessage = "Hubo un error recuperando los datos enviados
 var ErrorValidacion ErrorMessage = "Alguno de los datos del formulario no es válido"
 var ErrorSinPermiso ErrorMessage = "No tienes permiso para ver esta página o realizar esta opción."
 var ErrorSinAutenticar ErrorMessage = "Debes autenticarte antes de acceder a esta página"
+var ErrorCsrf ErrorMessage = "El formulario ha caducado o no se ha enviado desde el panel. Vuelve a cargar la página e inténtalo de nuevo."
 
 var ErrorIdInvalido ErrorMessage = "El id no es válido. Asegúrate de que solo contiene caracteres alfanuméricos, guiones y guiones bajos."
 var ErrorIdDuplicado ErrorMessage = "El id ya está en uso."
@@ -0,0 +1,260 @@
+/*
+Package metricas guarda contadores e histogramas en memoria y los escribe en el formato de texto de Prometheus. No
+usa el cliente oficial para no añadir dependencias por unas pocas métricas.
+*/
+package metricas
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Una métrica que se puede escribir en el formato de Prometheus
+type metrica interface {
+	escribir(w io.Writer) error
+}
+
+var (
+	muRegistro sync.Mutex
+	registro   []metrica
+)
+
+func registrar(m metrica) {
+	muRegistro.Lock()
+	defer muRegistro.Unlock()
+	registro = append(registro, m)
+}
+
+// Escribir escribe todas las métricas registradas, en el orden en que se registraron
+func Escribir(w io.Writer) error {
+	muRegistro.Lock()
+	var metricas = append([]metrica{}, registro...)
+	muRegistro.Unlock()
+
+	for _, m := range metricas {
+		if err := m.escribir(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clave de una serie a partir de los valores de sus etiquetas, en el mismo orden que los nombres
+func clave(valores []string) string {
+	return strings.Join(valores, "\x00")
+}
+
+// Escribe {a="1",b="2"} con los valores escapados, y el extra al final si lo hay, como le="0.5"
+func etiquetas(nombres []string, valores []string, extra string) string {
+	if len(nombres) == 0 && extra == "" {
+		return ""
+	}
+	var partes = make([]string, 0, len(nombres)+1)
+	for i, n := range nombres {
+		var v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(valores[i])
+		partes = append(partes, n+`="`+v+`"`)
+	}
+	if extra != "" {
+		partes = append(partes, extra)
+	}
+	return "{" + strings.Join(partes, ",") + "}"
+}
+
+func numero(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func cabecera(w io.Writer, nombre string, ayuda string, tipo string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", nombre, ayuda, nombre, tipo)
+	return err
+}
+
+// Contador que solo aumenta, con una serie por cada combinación de etiquetas
+type Contador struct {
+	nombre    string
+	ayuda     string
+	etiquetas []string
+
+	mu      sync.Mutex
+	series  map[string]float64
+	valores map[string][]string
+}
+
+func NuevoContador(nombre string, ayuda string, etiquetas ...string) *Contador {
+	var c = &Contador{nombre: nombre, ayuda: ayuda, etiquetas: etiquetas, series: map[string]float64{}, valores: map[string][]string{}}
+	registrar(c)
+	return c
+}
+
+// Suma uno a la serie con esos valores de las etiquetas
+func (c *Contador) Incrementar(valores ...string) {
+	c.Sumar(1, valores...)
+}
+
+func (c *Contador) Sumar(n float64, valores ...string) {
+	if len(valores) != len(c.etiquetas) {
+		return
+	}
+	var k = clave(valores)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.valores[k]; !ok {
+		c.valores[k] = append([]string{}, valores...)
+	}
+	c.series[k] += n
+}
+
+func (c *Contador) escribir(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := cabecera(w, c.nombre, c.ayuda, "counter"); err != nil {
+		return err
+	}
+	var claves = make([]string, 0, len(c.series))
+	for k := range c.series {
+		claves = append(claves, k)
+	}
+	sort.Strings(claves)
+	for _, k := range claves {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.nombre, etiquetas(c.etiquetas, c.valores[k], ""), numero(c.series[k])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histograma de duraciones u otros valores, con los límites de los cubos en orden creciente
+type Histograma struct {
+	nombre    string
+	ayuda     string
+	etiquetas []string
+	limites   []float64
+
+	mu     sync.Mutex
+	series map[string]*serieHistograma
+}
+
+type serieHistograma struct {
+	valores []string
+	cubos   []uint64
+	suma    float64
+	cuenta  uint64
+}
+
+// Límites para duraciones en segundos, los mismos que usa por defecto el cliente oficial
+var LimitesDuracion = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+func NuevoHistograma(nombre string, ayuda string, limites []float64, etiquetas ...string) *Histograma {
+	var h = &Histograma{nombre: nombre, ayuda: ayuda, etiquetas: etiquetas, limites: limites, series: map[string]*serieHistograma{}}
+	registrar(h)
+	return h
+}
+
+// Añade una observación a la serie con esos valores de las etiquetas
+func (h *Histograma) Observar(v float64, valores ...string) {
+	if len(valores) != len(h.etiquetas) {
+		return
+	}
+	var k = clave(valores)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[k]
+	if !ok {
+		s = &serieHistograma{valores: append([]string{}, valores...), cubos: make([]uint64, len(h.limites))}
+		h.series[k] = s
+	}
+	// Cada cubo cuenta solo los valores hasta su límite que no caben en el anterior; se acumulan al escribir
+	if i := sort.SearchFloat64s(h.limites, v); i < len(h.limites) {
+		s.cubos[i]++
+	}
+	s.suma += v
+	s.cuenta++
+}
+
+func (h *Histograma) escribir(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := cabecera(w, h.nombre, h.ayuda, "histogram"); err != nil {
+		return err
+	}
+	var claves = make([]string, 0, len(h.series))
+	for k := range h.series {
+		claves = append(claves, k)
+	}
+	sort.Strings(claves)
+	for _, k := range claves {
+		var s = h.series[k]
+		var acumulado uint64
+		for i, limite := range h.limites {
+			acumulado += s.cubos[i]
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.nombre, etiquetas(h.etiquetas, s.valores, `le="`+numero(limite)+`"`), acumulado); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.nombre, etiquetas(h.etiquetas, s.valores, `le="+Inf"`), s.cuenta,
+			h.nombre, etiquetas(h.etiquetas, s.valores, ""), numero(s.suma),
+			h.nombre, etiquetas(h.etiquetas, s.valores, ""), s.cuenta)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Valor que se calcula al escribir las métricas, como las conexiones abiertas con la base de datos
+type medidor struct {
+	nombre string
+	ayuda  string
+	tipo   string
+	valor  func() float64
+}
+
+// Registra un gauge que se lee al escribir las métricas
+func NuevoMedidor(nombre string, ayuda string, valor func() float64) {
+	registrar(&medidor{nombre: nombre, ayuda: ayuda, tipo: "gauge", valor: valor})
+}
+
+// Como NuevoMedidor, para valores que solo aumentan y se leen de otro sitio, como las esperas de sql.DBStats
+func NuevoContadorMedido(nombre string, ayuda string, valor func() float64) {
+	registrar(&medidor{nombre: nombre, ayuda: ayuda, tipo: "counter", valor: valor})
+}
+
+func (m *medidor) escribir(w io.Writer) error {
+	if err := cabecera(w, m.nombre, m.ayuda, m.tipo); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s %s\n", m.nombre, numero(m.valor()))
+	return err
+}
+
+// Métrica constante con la versión en una etiqueta, como hace build_info en otros programas
+type informacion struct {
+	nombre  string
+	ayuda   string
+	version string
+}
+
+// RegistrarVersion publica la versión del binario como vigo360_build_info{version="..."} 1
+func RegistrarVersion(version string) {
+	registrar(&informacion{nombre: "vigo360_build_info", ayuda: "Versión del binario en ejecución", version: version})
+}
+
+func (i *informacion) escribir(w io.Writer) error {
+	if err := cabecera(w, i.nombre, i.ayuda, "gauge"); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s%s 1\n", i.nombre, etiquetas([]string{"version"}, []string{i.version}, ""))
+	return err
+}
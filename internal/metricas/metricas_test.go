@@ -0,0 +1,86 @@
+package metricas
+
+import (
+	"strings"
+	"testing"
+)
+
+// Escribe solo la métrica indicada, sin las que registra vigo360.go
+func texto(t *testing.T, m metrica) string {
+	t.Helper()
+	var b strings.Builder
+	if err := m.escribir(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func comparar(t *testing.T, obtenido string, esperado string) {
+	t.Helper()
+	if obtenido != esperado {
+		t.Errorf("se esperaba\n%s\nse obtuvo\n%s", esperado, obtenido)
+	}
+}
+
+func TestContadorEscapaEtiquetas(t *testing.T) {
+	var c = NuevoContador("prueba_peticiones_total", "Peticiones de prueba", "ruta", "estado")
+	c.Incrementar(`/a\b`, "200")
+	c.Incrementar(`/con "comillas"`, "404")
+	c.Sumar(2.5, "/dos\nlineas", "500")
+	c.Incrementar(`/a\b`, "200")
+	// Con un número de valores distinto al de etiquetas no se anota nada
+	c.Incrementar("/sin-estado")
+
+	comparar(t, texto(t, c), `# HELP prueba_peticiones_total Peticiones de prueba
+# TYPE prueba_peticiones_total counter
+prueba_peticiones_total{ruta="/a\\b",estado="200"} 2
+prueba_peticiones_total{ruta="/con \"comillas\"",estado="404"} 1
+prueba_peticiones_total{ruta="/dos\nlineas",estado="500"} 2.5
+`)
+}
+
+func TestContadorSinEtiquetas(t *testing.T) {
+	var c = NuevoContador("prueba_total", "Sin etiquetas")
+	comparar(t, texto(t, c), "# HELP prueba_total Sin etiquetas\n# TYPE prueba_total counter\n")
+
+	c.Incrementar()
+	comparar(t, texto(t, c), "# HELP prueba_total Sin etiquetas\n# TYPE prueba_total counter\nprueba_total 1\n")
+}
+
+func TestHistogramaCubosAcumulados(t *testing.T) {
+	var h = NuevoHistograma("prueba_duracion_segundos", "Duraciones de prueba", []float64{0.1, 0.5, 1}, "ruta")
+	// Un valor igual a un límite cuenta en ese cubo, y los mayores que el último solo en +Inf
+	for _, v := range []float64{0.05, 0.1, 0.3, 0.5, 1, 2} {
+		h.Observar(v, "/")
+	}
+	h.Observar(0.25, `/"x"`)
+
+	comparar(t, texto(t, h), `# HELP prueba_duracion_segundos Duraciones de prueba
+# TYPE prueba_duracion_segundos histogram
+prueba_duracion_segundos_bucket{ruta="/",le="0.1"} 2
+prueba_duracion_segundos_bucket{ruta="/",le="0.5"} 4
+prueba_duracion_segundos_bucket{ruta="/",le="1"} 5
+prueba_duracion_segundos_bucket{ruta="/",le="+Inf"} 6
+prueba_duracion_segundos_sum{ruta="/"} 3.95
+prueba_duracion_segundos_count{ruta="/"} 6
+prueba_duracion_segundos_bucket{ruta="/\"x\"",le="0.1"} 0
+prueba_duracion_segundos_bucket{ruta="/\"x\"",le="0.5"} 1
+prueba_duracion_segundos_bucket{ruta="/\"x\"",le="1"} 1
+prueba_duracion_segundos_bucket{ruta="/\"x\"",le="+Inf"} 1
+prueba_duracion_segundos_sum{ruta="/\"x\""} 0.25
+prueba_duracion_segundos_count{ruta="/\"x\""} 1
+`)
+}
+
+func TestMedidoresEInformacion(t *testing.T) {
+	var abiertas = 3.0
+	var m = &medidor{nombre: "prueba_abiertas", ayuda: "Conexiones", tipo: "gauge", valor: func() float64 { return abiertas }}
+	comparar(t, texto(t, m), "# HELP prueba_abiertas Conexiones\n# TYPE prueba_abiertas gauge\nprueba_abiertas 3\n")
+
+	// Se lee al escribir, no al registrar
+	abiertas = 4
+	comparar(t, texto(t, m), "# HELP prueba_abiertas Conexiones\n# TYPE prueba_abiertas gauge\nprueba_abiertas 4\n")
+
+	var i = &informacion{nombre: "vigo360_build_info", ayuda: "Versión", version: `v1.2 "beta"`}
+	comparar(t, texto(t, i), "# HELP vigo360_build_info Versión\n# TYPE vigo360_build_info gauge\nvigo360_build_info{version=\"v1.2 \\\"beta\\\"\"} 1\n")
+}
@@ -0,0 +1,15 @@
+package metricas
+
+// Métricas del servidor. Las rutas son las plantillas de gorilla/mux, para no crear una serie por cada URL
+var (
+	PeticionesHttp = NuevoContador("vigo360_http_peticiones_total",
+		"Peticiones atendidas por ruta, método y código de estado", "ruta", "metodo", "estado")
+	DuracionHttp = NuevoHistograma("vigo360_http_duracion_segundos",
+		"Tiempo en atender las peticiones por ruta y método", LimitesDuracion, "ruta", "metodo")
+	DuracionPlantillas = NuevoHistograma("vigo360_plantilla_duracion_segundos",
+		"Tiempo en ejecutar cada plantilla", []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25}, "plantilla")
+	DuracionImagenes = NuevoHistograma("vigo360_imagen_codificacion_segundos",
+		"Tiempo en codificar cada versión de una imagen por formato", []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}, "formato")
+	Comentarios = NuevoContador("vigo360_comentarios_total",
+		"Comentarios enviados por resultado: aprobado, pendiente, captcha, invalido o error", "resultado")
+)
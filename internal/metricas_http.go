@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/metricas"
+)
+
+// RegistrarMetricas publica la versión del binario y las estadísticas del pool de conexiones con la base de datos
+func RegistrarMetricas(db *sqlx.DB, version string) {
+	metricas.RegistrarVersion(version)
+
+	metricas.NuevoMedidor("vigo360_bd_conexiones_abiertas", "Conexiones abiertas con la base de datos, en uso o no",
+		func() float64 { return float64(db.Stats().OpenConnections) })
+	metricas.NuevoMedidor("vigo360_bd_conexiones_en_uso", "Conexiones con la base de datos ejecutando una consulta",
+		func() float64 { return float64(db.Stats().InUse) })
+	metricas.NuevoMedidor("vigo360_bd_conexiones_inactivas", "Conexiones con la base de datos a la espera de una consulta",
+		func() float64 { return float64(db.Stats().Idle) })
+	metricas.NuevoMedidor("vigo360_bd_conexiones_maximas", "Máximo de conexiones abiertas con la base de datos, 0 si no hay límite",
+		func() float64 { return float64(db.Stats().MaxOpenConnections) })
+	metricas.NuevoContadorMedido("vigo360_bd_esperas_total", "Veces que una consulta tuvo que esperar a una conexión libre",
+		func() float64 { return float64(db.Stats().WaitCount) })
+	metricas.NuevoContadorMedido("vigo360_bd_espera_segundos_total", "Tiempo total esperando a una conexión libre",
+		func() float64 { return db.Stats().WaitDuration.Seconds() })
+}
+
+/*
+Escribe las métricas en el formato de Prometheus. Con token, exige la cabecera Authorization: Bearer con ese token;
+sin él, solo debe servirse en una dirección a la que no se pueda llegar desde fuera.
+*/
+func (s *Server) handleMetricas(token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := metricas.Escribir(&buf); err != nil {
+			log := logger.DesdeContexto(r.Context())
+			log.Error("error escribiendo métricas: %s", err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buf.Bytes())
+	}
+}
+
+/*
+MetricsHandler sirve /metrics para escucharlo en METRICAS_DIRECCION, separado del resto del sitio. Si además hay
+METRICAS_TOKEN, también lo exige.
+*/
+func (s *Server) MetricsHandler() http.Handler {
+	var mux = http.NewServeMux()
+	mux.Handle("/metrics", s.handleMetricas(os.Getenv("METRICAS_TOKEN")))
+	return mux
+}
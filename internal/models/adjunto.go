@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Archivo descargable asociado a un trabajo, normalmente un PDF
+type Adjunto struct {
+	Id             int    `json:"id"`
+	Trabajo_id     string `json:"trabajo_id"`
+	Nombre_archivo string `json:"nombre_archivo"`
+	Titulo         string `json:"titulo"`
+	Bytes          int64  `json:"bytes"`
+	Mime           string `json:"mime"`
+	Sha256         string `json:"sha256"`
+	Fecha_subida   string `json:"fecha_subida"`
+}
+
+// Ruta de descarga del adjunto, que lo sirve con el nombre y tipo correctos
+func (a Adjunto) Url() string {
+	return fmt.Sprintf("/trabajos/%s/adjuntos/%s", url.PathEscape(a.Trabajo_id), url.PathEscape(a.Nombre_archivo))
+}
+
+// Tamaño legible del adjunto, como "1,2 MB"
+func (a Adjunto) Tamano() string {
+	const unidad = 1024
+	if a.Bytes < unidad {
+		return fmt.Sprintf("%d B", a.Bytes)
+	}
+	var div, exp = int64(unidad), 0
+	for n := a.Bytes / unidad; n >= unidad; n /= unidad {
+		div *= unidad
+		exp++
+	}
+	var texto = fmt.Sprintf("%.1f %cB", float64(a.Bytes)/float64(div), "KMGT"[exp])
+	return strings.Replace(texto, ".", ",", 1)
+}
@@ -12,6 +12,22 @@ type Autor struct {
 	Rol       string
 	Biografia string
 	Web       Web
+	// Los autores desactivados no pueden iniciar sesión
+	Activo bool
 
 	Publicaciones Publicaciones
 }
+
+type TipoTokenAutor string
+
+const (
+	TokenInvitacion  TipoTokenAutor = "invitacion"
+	TokenRestablecer TipoTokenAutor = "restablecer"
+)
+
+// Enlace de un solo uso para que un autor elija su contraseña
+type TokenAutor struct {
+	Autor_id string
+	Tipo     TipoTokenAutor
+	Caduca   string
+}
@@ -9,6 +9,18 @@ const (
 	EstadoRechazado EstadoComentario = 3
 )
 
+func (e EstadoComentario) String() string {
+	switch e {
+	case EstadoPendiente:
+		return "pendiente"
+	case EstadoAprobado:
+		return "aprobado"
+	case EstadoRechazado:
+		return "rechazado"
+	}
+	return ""
+}
+
 type Comentario struct {
 	Id                 string
 	Publicacion_id     string
@@ -24,4 +36,5 @@ type Comentario struct {
 	Fecha_moderacion string
 	Estado           EstadoComentario
 	Moderador        string
+	Spam             bool
 }
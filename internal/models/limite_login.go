@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Intentos de inicio de sesión recientes de una cuenta o de una IP
+type IntentosLogin struct {
+	Intentos int
+	Ultimo   time.Time
+	// No se admiten más intentos hasta esta hora
+	Bloqueado_hasta time.Time
+}
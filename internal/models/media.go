@@ -0,0 +1,28 @@
+package models
+
+// Imagen de la mediateca, que se puede insertar en el Markdown de cualquier publicación o trabajo
+type Media struct {
+	Id             int    `json:"id"`
+	Nombre_archivo string `json:"nombre_archivo"`
+	Alt            string `json:"alt"`
+	Leyenda        string `json:"leyenda"`
+	Ancho          int    `json:"ancho"`
+	Alto           int    `json:"alto"`
+	Bytes          int    `json:"bytes"`
+
+	Publicacion_id string `json:"publicacion_id"`
+	Autor_id       string `json:"autor_id"`
+	Fecha_subida   string `json:"fecha_subida"`
+}
+
+// Ruta pública de la imagen, tal y como se enlaza desde el Markdown
+func (m Media) Url() string {
+	return "/static/extra/" + m.Nombre_archivo
+}
+
+// Publicación o trabajo cuyo contenido enlaza a una imagen de la mediateca
+type UsoMedia struct {
+	Tipo   string `json:"tipo"` // "publicacion" o "trabajo"
+	Id     string `json:"id"`
+	Titulo string `json:"titulo"`
+}
@@ -0,0 +1,24 @@
+package models
+
+type AccionModeracion string
+
+const (
+	AccionAprobar  AccionModeracion = "aprobar"
+	AccionRechazar AccionModeracion = "rechazar"
+	AccionSpam     AccionModeracion = "spam"
+	AccionRevertir AccionModeracion = "revertir"
+	AccionEditar   AccionModeracion = "editar"
+)
+
+// Decisión o edición de un moderador sobre un comentario
+type Moderacion struct {
+	Id            int
+	Comentario_id string
+	Accion        AccionModeracion
+	// Contenido del comentario antes de editarlo, vacío en el resto de acciones
+	Contenido_anterior string
+
+	Moderador        string
+	Moderador_nombre string
+	Fecha            string
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Publicacion struct {
 	Id                  string
 	Fecha_publicacion   string
@@ -10,7 +12,23 @@ type Publicacion struct {
 	Resumen             string
 	Contenido           string
 	Comentarios         []Comentario
+	Serie_id            string
+	Serie_posicion      int
 
 	Autor Autor
 	Tags  []Tag
 }
+
+// Indica si la publicación tiene fecha de publicación y esta ya ha llegado
+func (p Publicacion) EstaPublicada() bool {
+	if p.Fecha_publicacion == "" {
+		return false
+	}
+
+	var fechaPub, err = time.Parse("2006-01-02 15:04:05" /* Y-M-D H:M:S*/, p.Fecha_publicacion)
+	if err != nil {
+		return false
+	}
+
+	return fechaPub.Unix() <= time.Now().Unix()
+}
@@ -11,15 +11,7 @@ func (ps Publicaciones) FiltrarPublicas() Publicaciones {
 	var nps Publicaciones
 
 	for _, p := range ps {
-		if p.Fecha_publicacion == "" {
-			continue
-		}
-
-		var fechaPub, err = time.Parse("2006-01-02 15:04:05" /* Y-M-D H:M:S*/, p.Fecha_publicacion)
-		if err != nil {
-			continue
-		}
-		if fechaPub.Unix() <= time.Now().Unix() {
+		if p.EstaPublicada() {
 			nps = append(nps, p)
 		}
 	}
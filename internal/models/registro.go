@@ -0,0 +1,68 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Una petición atendida, tal como se guarda en la tabla log
+type EntradaRegistro struct {
+	Rid         string
+	Sid         string
+	Fecha       time.Time
+	Ip          string
+	Url         string
+	Ruta        string
+	Metodo      string
+	Duracion_ms int64
+	User_agent  string
+	Referer     string
+	Evento      string
+	Bot         bool
+}
+
+// Debe coincidir con la expresión de la migración 26-estadisticas.sql
+var botRegexp = regexp.MustCompile(`bot|crawl|spider|slurp|curl|wget|python|go-http-client|java/|libwww|httpclient|headless|lighthouse|facebookexternalhit|feed|preview|monitor`)
+
+// Indica si un user agent parece de un programa y no de una persona. Sin user agent se considera un programa
+func EsBot(userAgent string) bool {
+	return userAgent == "" || botRegexp.MatchString(strings.ToLower(userAgent))
+}
+
+// Peticiones de un día, separando personas de programas
+type EstadisticaDia struct {
+	Dia      string
+	Humanas  int
+	Bots     int
+	Sesiones int
+}
+
+// Visitas de personas a una publicación en un periodo
+type VisitasPublicacion struct {
+	Id       string
+	Titulo   string
+	Visitas  int
+	Sesiones int
+}
+
+// Visitas de una publicación en un día
+type VisitasDia struct {
+	Dia     string
+	Visitas int
+}
+
+// Sitio desde el que llegan visitas, sin contar los enlaces dentro del propio sitio
+type Referente struct {
+	Origen  string
+	Visitas int
+}
+
+// Tiempos de respuesta de una ruta, en milisegundos
+type TiempoRuta struct {
+	Ruta       string
+	Metodo     string
+	Peticiones int
+	P50        int
+	P95        int
+}
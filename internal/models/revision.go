@@ -0,0 +1,23 @@
+package models
+
+type TipoRevision string
+
+const (
+	RevisionPublicacion TipoRevision = "publicacion"
+	RevisionTrabajo     TipoRevision = "trabajo"
+)
+
+// Copia del texto de una publicación o trabajo tal y como quedó al guardarse
+type Revision struct {
+	Id          int
+	Tipo        TipoRevision
+	Entidad_id  string
+	Titulo      string
+	Resumen     string
+	Contenido   string
+	Alt_portada string
+
+	Autor_id     string
+	Autor_nombre string
+	Fecha        string
+}
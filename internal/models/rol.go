@@ -0,0 +1,21 @@
+package models
+
+import "errors"
+
+// Se ha intentado un cambio que dejaría a nadie con permiso para gestionar roles
+var ErrSinGestorPermisos = errors.New("al menos un autor debe conservar el permiso permisos_gestionar")
+
+type Permiso struct {
+	Id         string
+	Comentario string
+}
+
+// Conjunto de permisos con nombre que se asigna a autores
+type Rol struct {
+	Id          string
+	Nombre      string
+	Descripcion string
+	// Los autores con este rol no pueden iniciar sesión sin la verificación en dos pasos
+	Exige_2fa bool
+	Permisos  map[string]bool
+}
@@ -0,0 +1,23 @@
+package models
+
+// Estado de la verificación en dos pasos de un autor
+type SegundoFactor struct {
+	Autor_id string
+	// Secreto TOTP confirmado. Vacío si no tiene la verificación activada
+	Secreto string
+	// Secreto que se está dando de alta y aún no se ha confirmado con un código
+	Pendiente   string
+	Ultimo_paso int64
+	// Códigos de recuperación sin usar
+	Codigos_restantes int
+}
+
+func (sf SegundoFactor) Activo() bool {
+	return sf.Secreto != ""
+}
+
+// Inicio de sesión con la contraseña ya comprobada que espera el código
+type LoginPendiente struct {
+	Autor_id string
+	Intentos int
+}
@@ -5,3 +5,22 @@ type Serie struct {
 	Titulo        string
 	Publicaciones Publicaciones
 }
+
+// Devuelve las publicaciones anterior y siguiente a la indicada dentro de la serie, o nil si no existen
+func (s Serie) ObtenerAdyacentes(publicacion_id string) (anterior *Publicacion, siguiente *Publicacion) {
+	for i, p := range s.Publicaciones {
+		if p.Id != publicacion_id {
+			continue
+		}
+
+		if i > 0 {
+			anterior = &s.Publicaciones[i-1]
+		}
+		if i < len(s.Publicaciones)-1 {
+			siguiente = &s.Publicaciones[i+1]
+		}
+		break
+	}
+
+	return anterior, siguiente
+}
@@ -0,0 +1,39 @@
+package models
+
+import "strings"
+
+// Sesión iniciada por un autor, tal y como se muestra en el panel. No incluye el token de la cookie
+type Sesion struct {
+	Id           int
+	Autor_id     string
+	Autor_nombre string
+	Iniciada     string
+	Ultimo_uso   string
+	User_agent   string
+	Ip           string
+}
+
+// Resumen legible del user agent, como "Firefox en Linux"
+func (s Sesion) Navegador() string {
+	var ua = s.User_agent
+	var navegador, sistema = "Navegador desconocido", ""
+
+	// El orden importa: Edge y Opera también dicen ser Chrome, y Chrome dice ser Safari
+	for _, n := range [][2]string{{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"}} {
+		if strings.Contains(ua, n[0]) {
+			navegador = n[1]
+			break
+		}
+	}
+	for _, so := range [][2]string{{"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Windows", "Windows"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"}} {
+		if strings.Contains(ua, so[0]) {
+			sistema = so[1]
+			break
+		}
+	}
+
+	if sistema == "" {
+		return navegador
+	}
+	return navegador + " en " + sistema
+}
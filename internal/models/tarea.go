@@ -0,0 +1,27 @@
+package models
+
+type EstadoTarea string
+
+const (
+	TareaPendiente  EstadoTarea = "pendiente"
+	TareaEnCurso    EstadoTarea = "en_curso"
+	TareaCompletada EstadoTarea = "completada"
+	// La tarea ha agotado sus intentos y no se volverá a ejecutar salvo que se reintente a mano
+	TareaFallida EstadoTarea = "fallida"
+)
+
+// Trabajo en segundo plano guardado en la cola de tareas
+type Tarea struct {
+	Id    int
+	Tipo  string
+	Carga string // JSON con los datos que necesita el manejador del tipo
+
+	Estado            EstadoTarea
+	Intentos          int
+	Max_intentos      int
+	Siguiente_intento string
+	Ultimo_error      string
+
+	Fecha_creacion      string
+	Fecha_actualizacion string
+}
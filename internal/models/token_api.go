@@ -0,0 +1,68 @@
+package models
+
+import "strings"
+
+// Lo que puede hacer un token de la API, siempre que los roles del autor también lo permitan
+type AlcanceApi string
+
+const (
+	AlcanceEscribir    AlcanceApi = "publicaciones:escribir"
+	AlcancePublicar    AlcanceApi = "publicaciones:publicar"
+	AlcanceDespublicar AlcanceApi = "publicaciones:despublicar"
+	AlcancePortadas    AlcanceApi = "portadas:subir"
+)
+
+// Alcances en el orden en que se muestran en el panel
+var AlcancesApi = []AlcanceApi{AlcanceEscribir, AlcancePublicar, AlcanceDespublicar, AlcancePortadas}
+
+// Permiso que necesita el autor para usar cada alcance. La retirada por motivos legales no se puede hacer desde la API
+func (a AlcanceApi) Permiso() string {
+	return "publicaciones_editar"
+}
+
+func (a AlcanceApi) Descripcion() string {
+	switch a {
+	case AlcanceEscribir:
+		return "Crear publicaciones y editar su contenido"
+	case AlcancePublicar:
+		return "Publicar o programar publicaciones"
+	case AlcanceDespublicar:
+		return "Devolver publicaciones a borrador"
+	case AlcancePortadas:
+		return "Subir portadas"
+	}
+	return ""
+}
+
+// Token de la API tal y como se muestra en el panel. El token en sí no se guarda, solo su hash
+type TokenApi struct {
+	Id           int
+	Autor_id     string
+	Autor_nombre string
+	Nombre       string
+	Prefijo      string
+	// Separados por comas, como se guardan en la base de datos
+	Alcances   string
+	Creado     string
+	Ultimo_uso string
+	Caduca     string
+}
+
+func (t TokenApi) ListaAlcances() []AlcanceApi {
+	var lista []AlcanceApi
+	for _, a := range strings.Split(t.Alcances, ",") {
+		if a != "" {
+			lista = append(lista, AlcanceApi(a))
+		}
+	}
+	return lista
+}
+
+func (t TokenApi) Tiene(alcance AlcanceApi) bool {
+	for _, a := range t.ListaAlcances() {
+		if a == alcance {
+			return true
+		}
+	}
+	return false
+}
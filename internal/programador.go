@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/seo"
+)
+
+/*
+RunPublishScheduler revisa cada intervalo las publicaciones cuya fecha de publicación ya ha llegado y encola su
+notificación a IndexNow. Bloquea hasta que se cancela el contexto, así que debe ejecutarse en su propia goroutine.
+*/
+func (s *Server) RunPublishScheduler(ctx context.Context, interval time.Duration) {
+	log := logger.NewLogger("programador")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Information("programador de publicaciones iniciado cada %s", interval)
+	for {
+		s.notificarPublicadas(&log)
+
+		select {
+		case <-ctx.Done():
+			log.Information("programador de publicaciones detenido")
+			return
+		case <-ticker.C:
+		case <-s.programador:
+		}
+	}
+}
+
+// Despierta al programador para que notifique cuanto antes, sin esperar al siguiente intervalo
+func (s *Server) despertarProgramador() {
+	select {
+	case s.programador <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Server) notificarPublicadas(log *logger.Logger) {
+	pendientes, err := s.store.publicacion.ListarPendientesNotificar()
+	if err != nil {
+		log.Error("error recuperando publicaciones pendientes de notificar: %s", err.Error())
+		return
+	}
+
+	for _, p := range pendientes {
+		var tags []string
+		for _, t := range p.Tags {
+			tags = append(tags, t.Id)
+		}
+
+		// La llamada a IndexNow se reintenta desde la cola de tareas si falla
+		if err := s.encolarTarea(tareaIndexnow, cargaIndexnow{Urls: seo.PublicacionUrls(p.Id, tags)}); err != nil {
+			log.Error("error encolando notificación de %s: %s", p.Id, err.Error())
+			continue
+		}
+
+		// Al marcarla se modifica la publicación, y eso encola su envío al índice de búsqueda
+		if err := s.store.publicacion.MarcarNotificada(p.Id); err != nil {
+			log.Error("error marcando %s como notificada: %s", p.Id, err.Error())
+			continue
+		}
+
+		log.Information("encolada la notificación de %s, publicada el %s", p.Id, p.Fecha_publicacion)
+	}
+}
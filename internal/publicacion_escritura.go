@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"vigo360.es/new/internal/database"
+	"vigo360.es/new/internal/imagenes"
+	"vigo360.es/new/internal/models"
+)
+
+var errPublicacionDuplicada = errors.New("ya existe una publicación con ese id")
+var errPortadaNoEncolada = errors.New("publicación creada sin encolar la portada")
+
+var postIdRegexp = regexp.MustCompile(`^[A-Za-z0-9\-_]{3,40}$`)
+
+// Contenido editable de una publicación, igual desde el panel que desde la API
+type contenidoPublicacion struct {
+	Titulo     string `validate:"required,min=3,max=80"`
+	Resumen    string `validate:"required,min=3,max=300"`
+	Contenido  string `validate:"required"`
+	AltPortada string `validate:"required,min=3,max=300"`
+	Tags       []string
+}
+
+/*
+Crea un borrador vacío con la portada por defecto. Las versiones de la portada se generan en segundo plano como las
+de cualquier otra, así que un fallo al encolarlas no impide crear la publicación.
+*/
+func (s *Server) crearPublicacion(id string, titulo string, autor_id string) error {
+	if existe, err := s.store.publicacion.Existe(id); err != nil {
+		return err
+	} else if existe {
+		return errPublicacionDuplicada
+	}
+
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("error iniciando transacción: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := "INSERT INTO publicaciones(id, titulo, alt_portada, resumen, contenido, autor_id) VALUES (?, ?, 'CAMBIAME','', '', ?);"
+	if _, err := tx.Exec(q, id, titulo, autor_id); err != nil {
+		return fmt.Errorf("error creando artículo: %w", err)
+	}
+
+	photopath := os.Getenv("UPLOAD_PATH")
+	if err := os.WriteFile(photopath+"/images/"+id+".webp", defaultImageWebp, 0o644); err != nil {
+		return fmt.Errorf("error escribiendo imagen webp: %w", err)
+	}
+	if err := os.WriteFile(photopath+"/thumb/"+id+".jpg", defaultImageJPG, 0o644); err != nil {
+		return fmt.Errorf("error escribiendo imagen jpg: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error ejecutando transacción: %w", err)
+	}
+
+	if err := s.encolarPortada(bytes.NewReader(defaultImageJPG), id, imagenes.FocoCentro); err != nil {
+		return fmt.Errorf("%w: error encolando portada por defecto: %s", errPortadaNoEncolada, err.Error())
+	}
+	return nil
+}
+
+// Comprueba que existen todas las etiquetas, ya que si no fallaría la clave foránea
+func (s *Server) comprobarTags(tags []string) (bool, error) {
+	existentes, err := s.store.tag.Listar()
+	if err != nil {
+		return false, err
+	}
+	for _, t := range tags {
+		var encontrada = false
+		for _, e := range existentes {
+			if e.Id == t {
+				encontrada = true
+				break
+			}
+		}
+		if !encontrada {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Sustituye el contenido y las etiquetas de una publicación y guarda la revisión, dentro de la transacción recibida
+func (s *Server) guardarContenidoPublicacion(tx *sql.Tx, id string, c contenidoPublicacion, autor_id string) error {
+	if _, err := tx.Exec("DELETE FROM publicaciones_tags WHERE publicacion_id = ?", id); err != nil {
+		return fmt.Errorf("error eliminando tags existentes: %w", err)
+	}
+
+	for _, t := range c.Tags {
+		if _, err := tx.Exec("INSERT INTO publicaciones_tags (publicacion_id, tag_id) VALUES (?, ?)", id, t); err != nil {
+			return fmt.Errorf("error insertando nuevas tags: %w", err)
+		}
+	}
+
+	query := `UPDATE publicaciones SET titulo=?, resumen=?, contenido=?, alt_portada=? WHERE id=?`
+	if _, err := tx.Exec(query,
+		strings.TrimSpace(c.Titulo),
+		strings.TrimSpace(c.Resumen),
+		strings.TrimSpace(c.Contenido),
+		strings.TrimSpace(c.AltPortada),
+		id,
+	); err != nil {
+		return fmt.Errorf("error actualizando publicación: %w", err)
+	}
+
+	err := s.store.revision.Guardar(tx, models.Revision{
+		Tipo:        models.RevisionPublicacion,
+		Entidad_id:  id,
+		Titulo:      strings.TrimSpace(c.Titulo),
+		Resumen:     strings.TrimSpace(c.Resumen),
+		Contenido:   strings.TrimSpace(c.Contenido),
+		Alt_portada: strings.TrimSpace(c.AltPortada),
+		Autor_id:    autor_id,
+	})
+	if err != nil {
+		return fmt.Errorf("error guardando revisión: %w", err)
+	}
+	return nil
+}
+
+// Como guardarContenidoPublicacion, en una transacción propia
+func (s *Server) guardarContenido(id string, c contenidoPublicacion, autor_id string) error {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.guardarContenidoPublicacion(tx, id, c, autor_id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.despertarTrabajadores()
+	return nil
+}
@@ -0,0 +1,278 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"vigo360.es/new/internal/frontmatter"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/models"
+)
+
+const (
+	importacionCreada      = "creada"
+	importacionActualizada = "actualizada"
+	importacionSinCambios  = "sin cambios"
+	importacionError       = "error"
+)
+
+// Resultado de importar un fichero, para mostrarlo en el panel o en la consola
+type resultadoImportacion struct {
+	Fichero string
+	Id      string
+	Estado  string
+	Error   string
+}
+
+func documentoDesdePublicacion(p models.Publicacion) frontmatter.Documento {
+	var tags = make([]string, 0, len(p.Tags))
+	for _, t := range p.Tags {
+		if t.Id != "" {
+			tags = append(tags, t.Id)
+		}
+	}
+
+	return frontmatter.Documento{
+		Cabecera: frontmatter.Cabecera{
+			Id:                  p.Id,
+			Titulo:              p.Titulo,
+			Resumen:             p.Resumen,
+			Alt_portada:         p.Alt_portada,
+			Tags:                tags,
+			Autor:               p.Autor.Id,
+			Fecha_publicacion:   fechaApi(p.Fecha_publicacion),
+			Fecha_actualizacion: fechaApi(p.Fecha_actualizacion),
+		},
+		Contenido: p.Contenido,
+	}
+}
+
+/*
+Exporta todas las publicaciones, borradores incluidos, ordenadas por id para que dos exportaciones seguidas den los
+mismos ficheros. Cada una se pasa a escribir con su nombre de fichero.
+*/
+func (s *Server) exportarMarkdown(escribir func(nombre string, datos []byte) error) (int, error) {
+	publicaciones, err := s.store.publicacion.Listar()
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("error listando publicaciones: %w", err)
+	}
+	sort.Slice(publicaciones, func(i, j int) bool { return publicaciones[i].Id < publicaciones[j].Id })
+
+	for i, resumen := range publicaciones {
+		// El listado no incluye el contenido
+		p, err := s.store.publicacion.ObtenerPorId(resumen.Id, false)
+		if err != nil {
+			return i, fmt.Errorf("error recuperando %s: %w", resumen.Id, err)
+		}
+
+		datos, err := frontmatter.Codificar(documentoDesdePublicacion(p))
+		if err != nil {
+			return i, fmt.Errorf("error exportando %s: %w", p.Id, err)
+		}
+		if err := escribir(frontmatter.NombreFichero(p.Id), datos); err != nil {
+			return i, fmt.Errorf("error escribiendo %s: %w", p.Id, err)
+		}
+	}
+	return len(publicaciones), nil
+}
+
+/*
+Importa un fichero exportado con exportarMarkdown, o escrito a mano con el mismo formato. Pasa por las mismas
+comprobaciones que handleAdminEditPostAction y, como allí, la fecha de publicación solo se aplica si la publicación
+aún no ha salido. Si falta el id se usa el nombre del fichero.
+
+Las publicaciones nuevas se crean a nombre del autor de la cabecera, o de quien importa si no hay. Crearlas a nombre de
+otro autor exige puedeAsignarAutor. Las revisiones quedan a nombre de quien importa o, desde la consola, también del
+autor de la cabecera. La fecha de actualización se ignora, porque la pone la base de
+datos. Si no ha cambiado nada no se guarda una revisión nueva.
+*/
+func (s *Server) importarMarkdown(fichero string, datos []byte, importador string, puedeAsignarAutor bool) resultadoImportacion {
+	var resultado = resultadoImportacion{Fichero: fichero, Estado: importacionError}
+	var fallo = func(formato string, args ...interface{}) resultadoImportacion {
+		resultado.Error = fmt.Sprintf(formato, args...)
+		return resultado
+	}
+
+	doc, err := frontmatter.Decodificar(datos)
+	if err != nil {
+		return fallo("%s", err.Error())
+	}
+	var cab = doc.Cabecera
+	if cab.Id == "" {
+		cab.Id = strings.TrimSuffix(filepath.Base(fichero), filepath.Ext(fichero))
+	}
+	resultado.Id = cab.Id
+
+	if !postIdRegexp.MatchString(cab.Id) {
+		return fallo("el id %q no es válido", cab.Id)
+	}
+
+	var contenido = contenidoPublicacion{
+		Titulo:     cab.Titulo,
+		Resumen:    cab.Resumen,
+		Contenido:  doc.Contenido,
+		AltPortada: cab.Alt_portada,
+		Tags:       cab.Tags,
+	}
+	if contenido.Tags == nil {
+		contenido.Tags = []string{}
+	}
+	if err := validator.New().Struct(contenido); err != nil {
+		return fallo("los datos no son válidos: %s", err.Error())
+	}
+	if ok, err := s.comprobarTags(contenido.Tags); err != nil {
+		return fallo("error comprobando etiquetas: %s", err.Error())
+	} else if !ok {
+		return fallo("alguna de las etiquetas %v no existe", contenido.Tags)
+	}
+
+	var fecha time.Time
+	if cab.Fecha_publicacion != "" {
+		if fecha, err = time.Parse(time.RFC3339, cab.Fecha_publicacion); err != nil {
+			return fallo("la fecha de publicación %q no está en formato RFC3339", cab.Fecha_publicacion)
+		}
+	}
+
+	actual, err := s.store.publicacion.ObtenerPorId(cab.Id, false)
+	var nueva = errors.Is(err, sql.ErrNoRows)
+	if err != nil && !nueva {
+		return fallo("error recuperando la publicación: %s", err.Error())
+	}
+
+	var autor = importador
+	if autor == "" {
+		autor = cab.Autor
+	}
+
+	if nueva {
+		if cab.Autor == "" {
+			cab.Autor = importador
+		}
+		if cab.Autor == "" {
+			return fallo("falta el autor de la publicación")
+		}
+		if cab.Autor != importador && !puedeAsignarAutor {
+			return fallo("no tienes permiso para crear publicaciones a nombre de %q", cab.Autor)
+		}
+		if _, err := s.store.autor.Obtener(cab.Autor); err != nil {
+			return fallo("no existe el autor %q", cab.Autor)
+		}
+		if err := s.crearPublicacion(cab.Id, cab.Titulo, cab.Autor); err != nil && !errors.Is(err, errPortadaNoEncolada) {
+			return fallo("error creando la publicación: %s", err.Error())
+		}
+		resultado.Estado = importacionCreada
+	} else if !mismoContenido(actual, contenido) {
+		resultado.Estado = importacionActualizada
+	} else {
+		resultado.Estado = importacionSinCambios
+	}
+
+	if resultado.Estado != importacionSinCambios {
+		if err := s.guardarContenido(cab.Id, contenido, autor); err != nil {
+			resultado.Estado = importacionError
+			return fallo("error guardando el contenido: %s", err.Error())
+		}
+	}
+
+	var cambiarFecha = !fecha.IsZero() && !actual.EstaPublicada() && fechaApi(actual.Fecha_publicacion) != fecha.UTC().Format(time.RFC3339)
+	if cambiarFecha {
+		// Una fecha pasada es de una publicación restaurada, que no se vuelve a notificar como si fuese nueva
+		if !fecha.After(time.Now()) {
+			if err := s.store.publicacion.MarcarNotificada(cab.Id); err != nil {
+				resultado.Estado = importacionError
+				return fallo("error marcando la publicación como notificada: %s", err.Error())
+			}
+		}
+		if err := s.store.publicacion.Publicar(cab.Id, fecha); err != nil {
+			resultado.Estado = importacionError
+			return fallo("error cambiando la fecha de publicación: %s", err.Error())
+		}
+		s.despertarProgramador()
+		if resultado.Estado == importacionSinCambios {
+			resultado.Estado = importacionActualizada
+		}
+	}
+
+	return resultado
+}
+
+// Indica si guardar el contenido no cambiaría nada, comparando como se guarda en guardarContenidoPublicacion
+func mismoContenido(p models.Publicacion, c contenidoPublicacion) bool {
+	if p.Titulo != strings.TrimSpace(c.Titulo) || p.Resumen != strings.TrimSpace(c.Resumen) ||
+		p.Contenido != strings.TrimSpace(c.Contenido) || p.Alt_portada != strings.TrimSpace(c.AltPortada) {
+		return false
+	}
+
+	var actuales, nuevas []string
+	for _, t := range p.Tags {
+		if t.Id != "" {
+			actuales = append(actuales, t.Id)
+		}
+	}
+	nuevas = append(nuevas, c.Tags...)
+	sort.Strings(actuales)
+	sort.Strings(nuevas)
+	return strings.Join(actuales, ",") == strings.Join(nuevas, ",")
+}
+
+// ExportarMarkdown escribe todas las publicaciones en el directorio como {id}.md, sustituyendo las que ya hubiera
+func ExportarMarkdown(c *Container, directorio string) error {
+	log := logger.NewLogger("exportar-markdown")
+	if err := os.MkdirAll(directorio, 0o755); err != nil {
+		return fmt.Errorf("error creando %s: %w", directorio, err)
+	}
+
+	var s = &Server{store: c}
+	n, err := s.exportarMarkdown(func(nombre string, datos []byte) error {
+		return os.WriteFile(filepath.Join(directorio, nombre), datos, 0o644)
+	})
+	if err != nil {
+		return err
+	}
+	log.Information("exportadas %d publicaciones a %s", n, directorio)
+	return nil
+}
+
+/*
+ImportarMarkdown importa todos los .md del directorio. Sigue con el resto si alguno falla y termina con error si ha
+fallado alguno, para que se note en un script.
+*/
+func ImportarMarkdown(c *Container, directorio string) error {
+	log := logger.NewLogger("importar-markdown")
+
+	ficheros, err := filepath.Glob(filepath.Join(directorio, "*.md"))
+	if err != nil {
+		return fmt.Errorf("error leyendo %s: %w", directorio, err)
+	}
+
+	var s = &Server{store: c}
+	var fallidos = 0
+	for i, fichero := range ficheros {
+		datos, err := os.ReadFile(fichero)
+		var resultado resultadoImportacion
+		if err != nil {
+			resultado = resultadoImportacion{Fichero: fichero, Estado: importacionError, Error: err.Error()}
+		} else {
+			resultado = s.importarMarkdown(fichero, datos, "", true)
+		}
+
+		if resultado.Estado == importacionError {
+			log.Error("[%d/%d] %s: %s", i+1, len(ficheros), fichero, resultado.Error)
+			fallidos++
+			continue
+		}
+		log.Information("[%d/%d] %s: %s", i+1, len(ficheros), resultado.Id, resultado.Estado)
+	}
+
+	if fallidos > 0 {
+		return fmt.Errorf("no se pudieron importar %d de %d ficheros", fallidos, len(ficheros))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+
+	"vigo360.es/new/internal/imagenes"
+	"vigo360.es/new/internal/logger"
+)
+
+/*
+RegenerarPortadas vuelve a generar todas las versiones de las portadas de publicaciones y trabajos con la
+configuración actual. Se usa desde la línea de comandos tras cambiar los tamaños o formatos configurados.
+Continúa aunque falle alguna portada, y devuelve un error al final si alguna no se pudo generar.
+*/
+func RegenerarPortadas(c *Container) error {
+	log := logger.NewLogger("regenerar-portadas")
+	var uppath = os.Getenv("UPLOAD_PATH")
+	var originales = os.Getenv("ORIGINALES_PATH")
+
+	var ids []string
+	publicaciones, err := c.publicacion.Listar()
+	if err != nil {
+		return fmt.Errorf("error listando publicaciones: %w", err)
+	}
+	for _, p := range publicaciones {
+		ids = append(ids, p.Id)
+	}
+
+	trabajos, err := c.trabajo.Listar()
+	if err != nil {
+		return fmt.Errorf("error listando trabajos: %w", err)
+	}
+	for _, t := range trabajos {
+		ids = append(ids, t.Id)
+	}
+
+	var fallidas = 0
+	for i, id := range ids {
+		original, foco, err := imagenes.LeerOriginal(originales, uppath, id)
+		if err == nil {
+			err = imagenes.Generar(uppath, id, original, foco)
+		}
+		if err != nil {
+			log.Error("[%d/%d] error regenerando portada de %s: %s", i+1, len(ids), id, err.Error())
+			fallidas++
+			continue
+		}
+		log.Information("[%d/%d] regenerada portada de %s", i+1, len(ids), id)
+	}
+
+	if fallidas > 0 {
+		return fmt.Errorf("no se pudieron regenerar %d de %d portadas", fallidas, len(ids))
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+const (
+	// Peticiones que caben en memoria a la espera de guardarse
+	capacidadRegistro = 4096
+	// Peticiones por cada INSERT
+	loteRegistro = 200
+	// Tiempo máximo que espera una petición antes de guardarse
+	intervaloRegistro = 2 * time.Second
+)
+
+/*
+Guarda el registro de peticiones en la tabla log en segundo plano y por lotes, para que una base de datos lenta no
+retrase las respuestas. Si la cola se llena o falla un lote, las peticiones se añaden a un fichero de desborde, si se ha
+configurado, y se guardan cuando la base de datos vuelve a responder. Si no, se descartan.
+*/
+type registroPeticiones struct {
+	store    repository.RegistroStore
+	entradas chan models.EntradaRegistro
+
+	// Fracción de peticiones que se guardan, de 0 a 1. Las que tienen un evento se guardan siempre
+	muestreo float64
+	// Prefijos de rutas que no se guardan
+	excluidas []string
+	// Fichero de desborde, con una petición en JSON por línea
+	desborde    string
+	muDesborde  sync.Mutex
+	descartadas atomic.Int64
+}
+
+/*
+Lee la configuración del entorno: REGISTRO_MUESTREO, la fracción de peticiones a guardar, 1 por defecto;
+REGISTRO_EXCLUIR, prefijos de rutas separados por comas, /static/ por defecto, y REGISTRO_DESBORDE, el fichero de
+desborde. Un valor incorrecto se avisa en el log y se usa el valor por defecto.
+*/
+func nuevoRegistroPeticiones(store repository.RegistroStore) *registroPeticiones {
+	log := logger.NewLogger("registro")
+	var r = &registroPeticiones{
+		store:     store,
+		entradas:  make(chan models.EntradaRegistro, capacidadRegistro),
+		muestreo:  1,
+		excluidas: []string{"/static/"},
+		desborde:  os.Getenv("REGISTRO_DESBORDE"),
+	}
+
+	if val := os.Getenv("REGISTRO_MUESTREO"); val != "" {
+		if m, err := strconv.ParseFloat(val, 64); err != nil || m < 0 || m > 1 {
+			log.Warning("REGISTRO_MUESTREO tiene que ser un número entre 0 y 1, se guardarán todas las peticiones")
+		} else {
+			r.muestreo = m
+		}
+	}
+
+	if val, ok := os.LookupEnv("REGISTRO_EXCLUIR"); ok {
+		r.excluidas = nil
+		for _, prefijo := range strings.Split(val, ",") {
+			if prefijo = strings.TrimSpace(prefijo); prefijo != "" {
+				r.excluidas = append(r.excluidas, prefijo)
+			}
+		}
+	}
+	return r
+}
+
+// Indica si hay que guardar una petición, según las rutas excluidas y el muestreo
+func (r *registroPeticiones) guardar(ruta string, evento string) bool {
+	if evento != "" {
+		return true
+	}
+	for _, prefijo := range r.excluidas {
+		if strings.HasPrefix(ruta, prefijo) {
+			return false
+		}
+	}
+	return r.muestreo >= 1 || rand.Float64() < r.muestreo
+}
+
+// Pone una petición en la cola sin bloquear. Si está llena, va directamente al desborde
+func (r *registroPeticiones) registrar(e models.EntradaRegistro) {
+	select {
+	case r.entradas <- e:
+	default:
+		r.desbordar([]models.EntradaRegistro{e})
+	}
+}
+
+// Añade las peticiones al fichero de desborde o, si no hay o falla, las descarta
+func (r *registroPeticiones) desbordar(entradas []models.EntradaRegistro) {
+	if r.desborde == "" {
+		r.descartadas.Add(int64(len(entradas)))
+		return
+	}
+
+	r.muDesborde.Lock()
+	defer r.muDesborde.Unlock()
+
+	f, err := os.OpenFile(r.desborde, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		r.descartadas.Add(int64(len(entradas)))
+		return
+	}
+	defer f.Close()
+
+	var w = bufio.NewWriter(f)
+	var enc = json.NewEncoder(w)
+	for i, e := range entradas {
+		if err := enc.Encode(e); err != nil {
+			r.descartadas.Add(int64(len(entradas) - i))
+			return
+		}
+	}
+	if err := w.Flush(); err != nil {
+		r.descartadas.Add(int64(len(entradas)))
+	}
+}
+
+/*
+Guarda el contenido del fichero de desborde. Primero lo renombra, para que las peticiones que se desborden mientras
+tanto vayan a un fichero nuevo, y solo lo borra cuando se ha guardado entero. Tras cada lote guardado apunta hasta qué
+byte ha llegado, así que si falla, la siguiente llamada continúa desde ahí sin volver a enviar lo ya guardado.
+*/
+func (r *registroPeticiones) recuperarDesborde() (int, error) {
+	if r.desborde == "" {
+		return 0, nil
+	}
+	var pendiente = r.desborde + ".pendiente"
+	var posicion = pendiente + ".posicion"
+
+	r.muDesborde.Lock()
+	if _, err := os.Stat(pendiente); errors.Is(err, fs.ErrNotExist) {
+		// Una posición sin fichero pendiente es de uno que ya se terminó, y no vale para el nuevo
+		if err := os.Remove(posicion); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			r.muDesborde.Unlock()
+			return 0, err
+		}
+		if err := os.Rename(r.desborde, pendiente); errors.Is(err, fs.ErrNotExist) {
+			r.muDesborde.Unlock()
+			return 0, nil
+		} else if err != nil {
+			r.muDesborde.Unlock()
+			return 0, err
+		}
+	}
+	r.muDesborde.Unlock()
+
+	f, err := os.Open(pendiente)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var guardado int64
+	if b, err := os.ReadFile(posicion); err == nil {
+		if guardado, err = strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := f.Seek(guardado, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var total = 0
+	var leido = guardado
+	var lote = make([]models.EntradaRegistro, 0, loteRegistro)
+	var guardar = func() error {
+		if err := r.store.Guardar(lote); err != nil {
+			return err
+		}
+		total += len(lote)
+		lote = lote[:0]
+		return os.WriteFile(posicion, []byte(strconv.FormatInt(leido, 10)), 0o600)
+	}
+
+	var lector = bufio.NewReader(f)
+	for {
+		linea, err := lector.ReadBytes('\n')
+		leido += int64(len(linea))
+		var e models.EntradaRegistro
+		// Una línea cortada por un apagado a medias no debe bloquear el resto
+		if len(linea) > 0 && json.Unmarshal(linea, &e) == nil {
+			lote = append(lote, e)
+		}
+		if len(lote) == loteRegistro {
+			if err := guardar(); err != nil {
+				return total, err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	if err := guardar(); err != nil {
+		return total, err
+	}
+
+	// Primero el fichero, porque sin él la posición se descarta, pero sin la posición se volvería a guardar entero
+	if err := os.Remove(pendiente); err != nil {
+		return total, err
+	}
+	return total, os.Remove(posicion)
+}
+
+/*
+RunRequestLogWriter guarda las peticiones registradas en lotes, cuando se llena un lote o cada pocos segundos. Bloquea
+hasta que se cancela el contexto y, antes de volver, guarda todas las que queden en la cola, así que al apagar hay que
+esperar a que termine después de dejar de atender peticiones.
+*/
+func (s *Server) RunRequestLogWriter(ctx context.Context) {
+	var r = s.registro
+	log := logger.NewLogger("registro")
+	ticker := time.NewTicker(intervaloRegistro)
+	defer ticker.Stop()
+
+	var lote = make([]models.EntradaRegistro, 0, loteRegistro)
+	var fallando = false
+	var vaciar = func() {
+		if len(lote) == 0 {
+			return
+		}
+		if err := r.store.Guardar(lote); err != nil {
+			if !fallando {
+				log.Error("error guardando %d peticiones, se guardarán más tarde si hay desborde: %s", len(lote), err.Error())
+			}
+			fallando = true
+			r.desbordar(lote)
+		} else {
+			fallando = false
+		}
+		lote = lote[:0]
+	}
+	var recuperar = func() {
+		if n, err := r.recuperarDesborde(); err != nil {
+			log.Warning("error guardando peticiones del desborde, se reintentará: %s", err.Error())
+			fallando = true
+		} else if n > 0 {
+			log.Information("guardadas %d peticiones del desborde", n)
+		}
+		if n := r.descartadas.Swap(0); n > 0 {
+			log.Warning("se han descartado %d peticiones por no poder guardarlas", n)
+		}
+	}
+
+	recuperar()
+	for {
+		select {
+		case e := <-r.entradas:
+			lote = append(lote, e)
+			if len(lote) == loteRegistro {
+				vaciar()
+			}
+		case <-ticker.C:
+			vaciar()
+			if !fallando {
+				recuperar()
+			}
+		case <-ctx.Done():
+			// Solo lee esta goroutine, así que si hay algo en la cola no se bloquea
+			for len(r.entradas) > 0 {
+				lote = append(lote, <-r.entradas)
+				if len(lote) == loteRegistro {
+					vaciar()
+				}
+			}
+			vaciar()
+			if n := r.descartadas.Swap(0); n > 0 {
+				log.Warning("se han descartado %d peticiones por no poder guardarlas", n)
+			}
+			log.Information("registro de peticiones detenido")
+			return
+		}
+	}
+}
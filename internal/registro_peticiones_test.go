@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+// Guarda las peticiones en memoria, contando cuántas veces llega cada una, y falla en el lote indicado
+type memRegistroStore struct {
+	repository.RegistroStore
+	guardadas map[string]int
+	lotes     int
+	fallarEn  int
+}
+
+func (s *memRegistroStore) Guardar(entradas []models.EntradaRegistro) error {
+	if len(entradas) == 0 {
+		return nil
+	}
+	s.lotes++
+	if s.lotes == s.fallarEn {
+		return errors.New("base de datos caída")
+	}
+	for _, e := range entradas {
+		s.guardadas[e.Rid]++
+	}
+	return nil
+}
+
+func TestRecuperarDesbordeContinuaTrasUnFallo(t *testing.T) {
+	var store = &memRegistroStore{guardadas: map[string]int{}, fallarEn: 2}
+	var r = &registroPeticiones{store: store, desborde: filepath.Join(t.TempDir(), "desborde.jsonl")}
+
+	var entradas = make([]models.EntradaRegistro, 0)
+	for i := 0; i < 2*loteRegistro+50; i++ {
+		entradas = append(entradas, models.EntradaRegistro{Rid: fmt.Sprintf("rid-%d", i)})
+	}
+	r.desbordar(entradas)
+
+	// El primer lote se guarda y el segundo falla
+	if n, err := r.recuperarDesborde(); err == nil || n != loteRegistro {
+		t.Fatalf("se esperaba un error tras guardar un lote, se obtuvo %d %v", n, err)
+	}
+
+	// Las que se desborden entretanto esperan a la siguiente vez
+	r.desbordar([]models.EntradaRegistro{{Rid: "nueva"}})
+
+	n, err := r.recuperarDesborde()
+	if err != nil || n != loteRegistro+50 {
+		t.Fatalf("se esperaba guardar el resto, %d, se obtuvo %d %v", loteRegistro+50, n, err)
+	}
+	for _, e := range entradas {
+		if store.guardadas[e.Rid] != 1 {
+			t.Fatalf("%s se guardó %d veces", e.Rid, store.guardadas[e.Rid])
+		}
+	}
+	if _, err := os.Stat(r.desborde + ".pendiente.posicion"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("la posición debe borrarse al terminar")
+	}
+
+	// La nueva se guarda entera, sin usar la posición del fichero anterior
+	if n, err := r.recuperarDesborde(); err != nil || n != 1 || store.guardadas["nueva"] != 1 {
+		t.Fatalf("se esperaba guardar la nueva, se obtuvo %d %v", n, err)
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlAdjuntoStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlAdjuntoStore(db *sqlx.DB) *MysqlAdjuntoStore {
+	return &MysqlAdjuntoStore{
+		db: db,
+	}
+}
+
+const columnasAdjunto = `id, trabajo_id, nombre_archivo, titulo, bytes, mime, sha256, fecha_subida`
+
+func (s *MysqlAdjuntoStore) Listar(trabajo_id string) ([]models.Adjunto, error) {
+	var adjuntos = make([]models.Adjunto, 0)
+	err := s.db.Select(&adjuntos, `SELECT `+columnasAdjunto+` FROM adjuntos WHERE trabajo_id = ? ORDER BY fecha_subida, id`, trabajo_id)
+	return adjuntos, err
+}
+
+func (s *MysqlAdjuntoStore) Obtener(id int) (models.Adjunto, error) {
+	var a models.Adjunto
+	err := s.db.Get(&a, `SELECT `+columnasAdjunto+` FROM adjuntos WHERE id = ?`, id)
+	return a, err
+}
+
+func (s *MysqlAdjuntoStore) ObtenerPorNombre(trabajo_id string, nombre_archivo string) (models.Adjunto, error) {
+	var a models.Adjunto
+	err := s.db.Get(&a, `SELECT `+columnasAdjunto+` FROM adjuntos WHERE trabajo_id = ? AND nombre_archivo = ?`, trabajo_id, nombre_archivo)
+	return a, err
+}
+
+func (s *MysqlAdjuntoStore) Crear(a models.Adjunto) (int, error) {
+	res, err := s.db.Exec(`INSERT INTO adjuntos (trabajo_id, nombre_archivo, titulo, bytes, mime, sha256) VALUES (?, ?, ?, ?, ?, ?)`,
+		a.Trabajo_id, a.Nombre_archivo, a.Titulo, a.Bytes, a.Mime, a.Sha256)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (s *MysqlAdjuntoStore) Eliminar(id int) error {
+	_, err := s.db.Exec(`DELETE FROM adjuntos WHERE id = ?`, id)
+	return err
+}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/jmoiron/sqlx"
 	"vigo360.es/new/internal/models"
 )
@@ -17,14 +20,14 @@ func NewMysqlAutorStore(db *sqlx.DB) *MysqlAutorStore {
 
 func (s *MysqlAutorStore) Listar() ([]models.Autor, error) {
 	var autores = make([]models.Autor, 0)
-	var rows, err = s.db.Query(`SELECT id, nombre, email, rol, biografia FROM autores`)
+	var rows, err = s.db.Query(`SELECT id, nombre, email, rol, biografia, activo FROM autores`)
 	if err != nil {
 		return []models.Autor{}, err
 	}
 
 	for rows.Next() {
 		var na models.Autor
-		err = rows.Scan(&na.Id, &na.Nombre, &na.Email, &na.Rol, &na.Biografia)
+		err = rows.Scan(&na.Id, &na.Nombre, &na.Email, &na.Rol, &na.Biografia, &na.Activo)
 		if err != nil {
 			return []models.Autor{}, err
 		}
@@ -36,8 +39,8 @@ func (s *MysqlAutorStore) Listar() ([]models.Autor, error) {
 
 func (s *MysqlAutorStore) Obtener(autor_id string) (models.Autor, error) {
 	var autor models.Autor
-	var row = s.db.QueryRow(`SELECT id, nombre, email, rol, biografia, web_url, web_titulo FROM autores WHERE id=?`, autor_id)
-	var err = row.Scan(&autor.Id, &autor.Nombre, &autor.Email, &autor.Rol, &autor.Biografia, &autor.Web.Url, &autor.Web.Titulo)
+	var row = s.db.QueryRow(`SELECT id, nombre, email, rol, biografia, web_url, web_titulo, activo FROM autores WHERE id=?`, autor_id)
+	var err = row.Scan(&autor.Id, &autor.Nombre, &autor.Email, &autor.Rol, &autor.Biografia, &autor.Web.Url, &autor.Web.Titulo, &autor.Activo)
 
 	if err != nil {
 		return models.Autor{}, err
@@ -66,3 +69,87 @@ func (s *MysqlAutorStore) Buscar(termino string) ([]models.Autor, error) {
 
 	return autores, nil
 }
+
+func (s *MysqlAutorStore) ObtenerPorEmail(email string) (models.Autor, error) {
+	var autor models.Autor
+	var row = s.db.QueryRow(`SELECT id, nombre, email, rol, biografia, web_url, web_titulo, activo FROM autores WHERE email=?`, email)
+	var err = row.Scan(&autor.Id, &autor.Nombre, &autor.Email, &autor.Rol, &autor.Biografia, &autor.Web.Url, &autor.Web.Titulo, &autor.Activo)
+	if err != nil {
+		return models.Autor{}, err
+	}
+	return autor, nil
+}
+
+func (s *MysqlAutorStore) Crear(autor models.Autor) error {
+	// Una contraseña vacía nunca coincide con ningún hash bcrypt
+	_, err := s.db.Exec(`INSERT INTO autores (id, nombre, email, contraseña, rol, biografia, web_url, web_titulo) VALUES (?, ?, ?, "", ?, "", "", "")`,
+		autor.Id, autor.Nombre, autor.Email, autor.Rol)
+	return err
+}
+
+func (s *MysqlAutorStore) ObtenerContraseña(autor_id string) (string, error) {
+	var hash string
+	err := s.db.QueryRow(`SELECT contraseña FROM autores WHERE id=?`, autor_id).Scan(&hash)
+	return hash, err
+}
+
+func (s *MysqlAutorStore) CambiarContraseña(autor_id string, hash string) error {
+	_, err := s.db.Exec(`UPDATE autores SET contraseña=? WHERE id=?`, hash, autor_id)
+	return err
+}
+
+func (s *MysqlAutorStore) CambiarActivo(autor_id string, activo bool) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE autores SET activo=? WHERE id=?`, activo, autor_id); err != nil {
+		return err
+	}
+	if !activo {
+		if _, err := tx.Exec(`UPDATE sesiones SET revocada=true WHERE autor_id=?`, autor_id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *MysqlAutorStore) CrearToken(autor_id string, tipo models.TipoTokenAutor, hash string, validez time.Duration) error {
+	_, err := s.db.Exec(`INSERT INTO autores_tokens (token_hash, autor_id, tipo, caduca) VALUES (?, ?, ?, NOW() + INTERVAL ? SECOND)`,
+		hash, autor_id, tipo, int(validez.Seconds()))
+	return err
+}
+
+func (s *MysqlAutorStore) ObtenerToken(hash string) (models.TokenAutor, error) {
+	var token models.TokenAutor
+	err := s.db.Get(&token, `SELECT autor_id, tipo, caduca FROM autores_tokens WHERE token_hash=? AND usado IS NULL AND caduca > NOW()`, hash)
+	return token, err
+}
+
+func (s *MysqlAutorStore) ConsumirToken(hash string) (models.TokenAutor, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return models.TokenAutor{}, err
+	}
+	defer tx.Rollback()
+
+	var token models.TokenAutor
+	err = tx.Get(&token, `SELECT autor_id, tipo, caduca FROM autores_tokens WHERE token_hash=? AND usado IS NULL AND caduca > NOW() FOR UPDATE`, hash)
+	if err != nil {
+		return models.TokenAutor{}, err
+	}
+
+	res, err := tx.Exec(`UPDATE autores_tokens SET usado=NOW() WHERE token_hash=? AND usado IS NULL`, hash)
+	if err != nil {
+		return models.TokenAutor{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return models.TokenAutor{}, err
+	} else if n == 0 {
+		return models.TokenAutor{}, sql.ErrNoRows
+	}
+
+	return token, tx.Commit()
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -27,10 +28,20 @@ func (s *MysqlComentarioStore) ListarPublicos(publicacion_id string) ([]models.C
 	return comentarios, nil
 }
 
+// Obtiene un comentario cualquiera sea su estado
+func (s *MysqlComentarioStore) Obtener(comentario_id string) (models.Comentario, error) {
+	var comentario models.Comentario
+	var err = s.db.Get(&comentario, `SELECT id, publicacion_id, publicacion_titulo, padre_id, nombre, es_autor, autor_original, contenido, fecha_creacion, fecha_moderacion, estado, moderador, spam FROM comment_moderation WHERE id=?`, comentario_id)
+	if err != nil {
+		return models.Comentario{}, err
+	}
+	return comentario, nil
+}
+
 // Lista los comentarios con un estado específico
 func (s *MysqlComentarioStore) ListarPorEstado(estado models.EstadoComentario) ([]models.Comentario, error) {
 	var comentarios []models.Comentario
-	var err = s.db.Select(&comentarios, `SELECT id, publicacion_id, publicacion_titulo, COALESCE(padre_id, '') as padre_id, nombre, es_autor, autor_original, contenido, fecha_creacion, COALESCE(fecha_moderacion, "") as fecha_moderacion, estado+0 as estado, COALESCE(moderador, "") as moderador FROM comment_moderation WHERE estado=?`, estado)
+	var err = s.db.Select(&comentarios, `SELECT id, publicacion_id, publicacion_titulo, COALESCE(padre_id, '') as padre_id, nombre, es_autor, autor_original, contenido, fecha_creacion, COALESCE(fecha_moderacion, "") as fecha_moderacion, estado+0 as estado, COALESCE(moderador, "") as moderador, spam FROM comment_moderation WHERE estado=? ORDER BY fecha_creacion DESC`, estado)
 	if err != nil {
 		return []models.Comentario{}, err
 	}
@@ -46,14 +57,100 @@ func (s *MysqlComentarioStore) GuardarComentario(c models.Comentario) error {
 	return err
 }
 
-// Cambia el estado de PENDIENTE a APROBADO
-func (s *MysqlComentarioStore) Aprobar(comentario_id string, moderador string) error {
-	_, err := s.db.Exec(`UPDATE comentarios SET estado=2, moderador=?, fecha_moderacion=NOW() WHERE id=? AND estado=1`, moderador, comentario_id)
-	return err
+var consultasModeracion = map[models.AccionModeracion]string{
+	models.AccionAprobar:  `UPDATE comentarios SET estado=2, spam=false, moderador=?, fecha_moderacion=NOW() WHERE id=?`,
+	models.AccionRechazar: `UPDATE comentarios SET estado=3, spam=false, moderador=?, fecha_moderacion=NOW() WHERE id=?`,
+	models.AccionSpam:     `UPDATE comentarios SET estado=3, spam=true, moderador=?, fecha_moderacion=NOW() WHERE id=?`,
+	// Devuelve el comentario a la cola de moderación, deshaciendo la decisión anterior
+	models.AccionRevertir: `UPDATE comentarios SET estado=1, spam=false, moderador=NULLIF(?, ""), fecha_moderacion=NULL WHERE id=?`,
 }
 
-// Cambia el estado de PENDIENTE a RECHAZADO
-func (s *MysqlComentarioStore) Rechazar(comentario_id string, moderador string) error {
-	_, err := s.db.Exec(`UPDATE comentarios SET estado=3, moderador=?,fecha_moderacion=NOW() WHERE id=? AND estado=1`, moderador, comentario_id)
-	return err
+var ErrAccionModeracionInvalida = errors.New("acción de moderación no válida")
+
+// Aplica una decisión de moderación a varios comentarios a la vez, registrándola en el historial
+func (s *MysqlComentarioStore) Moderar(comentario_ids []string, accion models.AccionModeracion, moderador string) error {
+	query, ok := consultasModeracion[accion]
+	if !ok {
+		return ErrAccionModeracionInvalida
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range comentario_ids {
+		// Al revertir el comentario queda sin moderador
+		var moderadorComentario = moderador
+		if accion == models.AccionRevertir {
+			moderadorComentario = ""
+		}
+
+		res, err := tx.Exec(query, moderadorComentario, id)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+
+		_, err = tx.Exec(`INSERT INTO comentarios_moderacion(comentario_id, moderador, accion) VALUES (?, ?, ?)`, id, moderador, accion)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Cambia el contenido de un comentario, guardando el anterior en el historial
+func (s *MysqlComentarioStore) Editar(comentario_id string, contenido string, moderador string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var anterior string
+	if err := tx.QueryRow(`SELECT contenido FROM comentarios WHERE id=? FOR UPDATE`, comentario_id).Scan(&anterior); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE comentarios SET contenido=? WHERE id=?`, contenido, comentario_id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO comentarios_moderacion(comentario_id, moderador, accion, contenido_anterior) VALUES (?, ?, ?, ?)`, comentario_id, moderador, models.AccionEditar, anterior)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Lista las decisiones y ediciones de moderación de un comentario, de más reciente a más antigua
+func (s *MysqlComentarioStore) ListarModeraciones(comentario_id string) ([]models.Moderacion, error) {
+	var moderaciones = make([]models.Moderacion, 0)
+	var query = `SELECT m.id, m.comentario_id, m.accion, COALESCE(m.contenido_anterior, ""), m.moderador, COALESCE(a.nombre, m.moderador), m.fecha FROM comentarios_moderacion m LEFT JOIN autores a ON m.moderador = a.id WHERE m.comentario_id=? ORDER BY m.fecha DESC, m.id DESC`
+
+	rows, err := s.db.Query(query, comentario_id)
+	if err != nil {
+		return []models.Moderacion{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var nm models.Moderacion
+		err = rows.Scan(&nm.Id, &nm.Comentario_id, &nm.Accion, &nm.Contenido_anterior, &nm.Moderador, &nm.Moderador_nombre, &nm.Fecha)
+		if err != nil {
+			return []models.Moderacion{}, err
+		}
+		moderaciones = append(moderaciones, nm)
+	}
+
+	return moderaciones, nil
 }
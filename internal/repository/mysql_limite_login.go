@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlLimiteLoginStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlLimiteLoginStore(db *sqlx.DB) *MysqlLimiteLoginStore {
+	return &MysqlLimiteLoginStore{
+		db: db,
+	}
+}
+
+func (s *MysqlLimiteLoginStore) Obtener(clave string) (models.IntentosLogin, error) {
+	var fila struct {
+		Intentos        int
+		Ultimo          int64
+		Bloqueado_hasta int64
+	}
+	err := s.db.Get(&fila, `SELECT intentos, ultimo, bloqueado_hasta FROM login_intentos WHERE clave = ?`, clave)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.IntentosLogin{}, nil
+	} else if err != nil {
+		return models.IntentosLogin{}, err
+	}
+	return models.IntentosLogin{
+		Intentos:        fila.Intentos,
+		Ultimo:          time.Unix(fila.Ultimo, 0),
+		Bloqueado_hasta: time.Unix(fila.Bloqueado_hasta, 0),
+	}, nil
+}
+
+func (s *MysqlLimiteLoginStore) Actualizar(clave string, anterior models.IntentosLogin, nuevo models.IntentosLogin) (bool, error) {
+	var res sql.Result
+	var err error
+	if anterior.Intentos == 0 && anterior.Ultimo.IsZero() {
+		res, err = s.db.Exec(`INSERT IGNORE INTO login_intentos (clave, intentos, ultimo, bloqueado_hasta) VALUES (?, ?, ?, ?)`,
+			clave, nuevo.Intentos, nuevo.Ultimo.Unix(), unixOCero(nuevo.Bloqueado_hasta))
+	} else {
+		res, err = s.db.Exec(`UPDATE login_intentos SET intentos = ?, ultimo = ?, bloqueado_hasta = ? WHERE clave = ? AND intentos = ? AND ultimo = ?`,
+			nuevo.Intentos, nuevo.Ultimo.Unix(), unixOCero(nuevo.Bloqueado_hasta), clave, anterior.Intentos, anterior.Ultimo.Unix())
+	}
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *MysqlLimiteLoginStore) Limpiar(clave string, antiguos time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM login_intentos WHERE clave = ? OR ultimo < ?`, clave, antiguos.Unix())
+	return err
+}
+
+func unixOCero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
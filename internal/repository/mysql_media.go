@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlMediaStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlMediaStore(db *sqlx.DB) *MysqlMediaStore {
+	return &MysqlMediaStore{
+		db: db,
+	}
+}
+
+const columnasMedia = `id, nombre_archivo, alt, leyenda, ancho, alto, bytes, COALESCE(publicacion_id, "") as publicacion_id, COALESCE(autor_id, "") as autor_id, fecha_subida`
+
+func (s *MysqlMediaStore) Listar(publicacion_id string) ([]models.Media, error) {
+	var media = make([]models.Media, 0)
+	var err error
+	if publicacion_id == "" {
+		err = s.db.Select(&media, `SELECT `+columnasMedia+` FROM media ORDER BY fecha_subida DESC, id DESC`)
+	} else {
+		err = s.db.Select(&media, `SELECT `+columnasMedia+` FROM media WHERE publicacion_id = ? ORDER BY fecha_subida DESC, id DESC`, publicacion_id)
+	}
+	return media, err
+}
+
+func (s *MysqlMediaStore) Obtener(id int) (models.Media, error) {
+	var m models.Media
+	err := s.db.Get(&m, `SELECT `+columnasMedia+` FROM media WHERE id = ?`, id)
+	return m, err
+}
+
+func (s *MysqlMediaStore) Crear(m models.Media) (int, error) {
+	res, err := s.db.Exec(`INSERT INTO media (nombre_archivo, alt, leyenda, ancho, alto, bytes, publicacion_id, autor_id) VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ""), NULLIF(?, ""))`,
+		m.Nombre_archivo, m.Alt, m.Leyenda, m.Ancho, m.Alto, m.Bytes, m.Publicacion_id, m.Autor_id)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (s *MysqlMediaStore) Actualizar(id int, alt string, leyenda string) error {
+	_, err := s.db.Exec(`UPDATE media SET alt = ?, leyenda = ? WHERE id = ?`, alt, leyenda, id)
+	return err
+}
+
+var ErrMediaEnUso = errors.New("la imagen se está usando")
+
+func (s *MysqlMediaStore) Eliminar(m models.Media) error {
+	// La comprobación va en el mismo DELETE, para no borrar una imagen que se enlace justo después de comprobar sus usos
+	var patron = patronUso(m)
+	res, err := s.db.Exec(`DELETE FROM media WHERE id = ?
+	AND NOT EXISTS (SELECT 1 FROM publicaciones WHERE contenido LIKE ?)
+	AND NOT EXISTS (SELECT 1 FROM trabajos WHERE contenido LIKE ?)`, m.Id, patron, patron)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrMediaEnUso
+	}
+	return nil
+}
+
+func (s *MysqlMediaStore) Usos(m models.Media) ([]models.UsoMedia, error) {
+	var usos = make([]models.UsoMedia, 0)
+
+	var patron = patronUso(m)
+	var query = `SELECT "publicacion" as tipo, id, titulo FROM publicaciones WHERE contenido LIKE ?
+	UNION ALL SELECT "trabajo" as tipo, id, titulo FROM trabajos WHERE contenido LIKE ?
+	ORDER BY tipo, id`
+
+	err := s.db.Select(&usos, query, patron, patron)
+	return usos, err
+}
+
+// Patrón LIKE que encuentra la URL de la imagen en un contenido. El nombre puede contener _, que en LIKE es un comodín
+func patronUso(m models.Media) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(m.Url()) + "%"
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"vigo360.es/new/internal/models"
@@ -20,7 +21,7 @@ func NewMysqlPublicacionStore(db *sqlx.DB) *MysqlPublicacionStore {
 
 func (s *MysqlPublicacionStore) Listar() (models.Publicaciones, error) {
 	publicaciones := make(models.Publicaciones, 0)
-	query := `SELECT p.id, COALESCE(fecha_publicacion, ""), fecha_actualizacion, COALESCE(legally_retired_at, ""), titulo, resumen, alt_portada, autor_id, autores.nombre as autor_nombre, autores.email as autor_email, COALESCE(GROUP_CONCAT(tags.id), "") as tags_ids, COALESCE(GROUP_CONCAT(tags.nombre), "") as tags_nombres FROM publicaciones p LEFT JOIN publicaciones_tags ON p.id = publicaciones_tags.publicacion_id LEFT JOIN tags ON publicaciones_tags.tag_id = tags.id LEFT JOIN autores ON p.autor_id = autores.id GROUP BY id ORDER BY fecha_publicacion DESC;`
+	query := `SELECT p.id, COALESCE(fecha_publicacion, ""), fecha_actualizacion, COALESCE(legally_retired_at, ""), titulo, resumen, alt_portada, COALESCE(serie_id, ""), autor_id, autores.nombre as autor_nombre, autores.email as autor_email, COALESCE(GROUP_CONCAT(tags.id), "") as tags_ids, COALESCE(GROUP_CONCAT(tags.nombre), "") as tags_nombres FROM publicaciones p LEFT JOIN publicaciones_tags ON p.id = publicaciones_tags.publicacion_id LEFT JOIN tags ON publicaciones_tags.tag_id = tags.id LEFT JOIN autores ON p.autor_id = autores.id GROUP BY id ORDER BY fecha_publicacion DESC;`
 
 	rows, err := s.db.Query(query)
 
@@ -35,7 +36,7 @@ func (s *MysqlPublicacionStore) Listar() (models.Publicaciones, error) {
 			rawTagNombres string
 		)
 
-		err = rows.Scan(&np.Id, &np.Fecha_publicacion, &np.Fecha_actualizacion, &np.Legally_retired_at, &np.Titulo, &np.Resumen, &np.Alt_portada, &np.Autor.Id, &np.Autor.Nombre, &np.Autor.Email, &rawTagIds, &rawTagNombres)
+		err = rows.Scan(&np.Id, &np.Fecha_publicacion, &np.Fecha_actualizacion, &np.Legally_retired_at, &np.Titulo, &np.Resumen, &np.Alt_portada, &np.Serie_id, &np.Autor.Id, &np.Autor.Nombre, &np.Autor.Email, &rawTagIds, &rawTagNombres)
 		if err != nil {
 			return models.Publicaciones{}, err
 		}
@@ -106,7 +107,7 @@ func (s *MysqlPublicacionStore) Existe(id string) (bool, error) {
 
 func (s *MysqlPublicacionStore) ObtenerPorId(id string, requirePublic bool) (models.Publicacion, error) {
 	var post models.Publicacion
-	var query = `SELECT publicaciones.id, alt_portada, titulo, resumen, contenido, COALESCE(fecha_publicacion, ""), fecha_actualizacion,COALESCE(legally_retired_at, ""), autores.id as autor_id, autores.nombre as autor_nombre, autores.biografia as autor_biografia, autores.rol as autor_rol, COALESCE(GROUP_CONCAT(tags.id), "") as tags_ids, COALESCE(GROUP_CONCAT(tags.nombre), "") as tags_names
+	var query = `SELECT publicaciones.id, alt_portada, titulo, resumen, contenido, COALESCE(fecha_publicacion, ""), fecha_actualizacion,COALESCE(legally_retired_at, ""), COALESCE(serie_id, ""), COALESCE(serie_posicion, 0), autores.id as autor_id, autores.nombre as autor_nombre, autores.biografia as autor_biografia, autores.rol as autor_rol, COALESCE(GROUP_CONCAT(tags.id), "") as tags_ids, COALESCE(GROUP_CONCAT(tags.nombre), "") as tags_names
 	FROM publicaciones
 	LEFT JOIN autores on publicaciones.autor_id = autores.id
 	LEFT JOIN publicaciones_tags ON publicaciones.id = publicaciones_tags.publicacion_id
@@ -120,7 +121,7 @@ func (s *MysqlPublicacionStore) ObtenerPorId(id string, requirePublic bool) (mod
 		rawTagNombres string
 	)
 
-	var err = s.db.QueryRow(query, id).Scan(&post.Id, &post.Alt_portada, &post.Titulo, &post.Resumen, &post.Contenido, &post.Fecha_publicacion, &post.Fecha_actualizacion, &post.Legally_retired_at, &post.Autor.Id, &post.Autor.Nombre, &post.Autor.Biografia, &post.Autor.Rol, &rawTagIds, &rawTagNombres)
+	var err = s.db.QueryRow(query, id).Scan(&post.Id, &post.Alt_portada, &post.Titulo, &post.Resumen, &post.Contenido, &post.Fecha_publicacion, &post.Fecha_actualizacion, &post.Legally_retired_at, &post.Serie_id, &post.Serie_posicion, &post.Autor.Id, &post.Autor.Nombre, &post.Autor.Biografia, &post.Autor.Rol, &rawTagIds, &rawTagNombres)
 
 	if err != nil {
 		return models.Publicacion{}, err
@@ -140,9 +141,9 @@ func (s *MysqlPublicacionStore) ObtenerPorId(id string, requirePublic bool) (mod
 }
 
 func (s *MysqlPublicacionStore) Buscar(termino string) (models.Publicaciones, error) {
-	var query = `SELECT p.id, COALESCE(fecha_publicacion, ""), fecha_actualizacion, titulo, resumen, alt_portada, autor_id, autores.nombre as autor_nombre, autores.email as autor_email, COALESCE(GROUP_CONCAT(tags.id), "") as tags_ids, COALESCE(GROUP_CONCAT(tags.nombre), "") as tags_nombres FROM publicaciones p LEFT JOIN publicaciones_tags ON p.id = publicaciones_tags.publicacion_id LEFT JOIN tags ON publicaciones_tags.tag_id = tags.id LEFT JOIN autores ON p.autor_id = autores.id WHERE MATCH(p.id, titulo, resumen, contenido, alt_portada) AGAINST (? IN NATURAL LANGUAGE MODE) GROUP BY id`
+	var query = `SELECT p.id, COALESCE(fecha_publicacion, ""), fecha_actualizacion, COALESCE(legally_retired_at, ""), titulo, resumen, alt_portada, autor_id, autores.nombre as autor_nombre, autores.email as autor_email, COALESCE(GROUP_CONCAT(tags.id), "") as tags_ids, COALESCE(GROUP_CONCAT(tags.nombre), "") as tags_nombres FROM publicaciones p LEFT JOIN publicaciones_tags ON p.id = publicaciones_tags.publicacion_id LEFT JOIN tags ON publicaciones_tags.tag_id = tags.id LEFT JOIN autores ON p.autor_id = autores.id WHERE MATCH(p.id, titulo, resumen, contenido, alt_portada) AGAINST (? IN NATURAL LANGUAGE MODE) GROUP BY id ORDER BY MATCH(p.id, titulo, resumen, contenido, alt_portada) AGAINST (? IN NATURAL LANGUAGE MODE) DESC`
 
-	rows, err := s.db.Query(query, "*"+termino+"*")
+	rows, err := s.db.Query(query, termino, termino)
 	if err != nil {
 		return models.Publicaciones{}, err
 	}
@@ -155,7 +156,7 @@ func (s *MysqlPublicacionStore) Buscar(termino string) (models.Publicaciones, er
 			rawTagNombres string
 		)
 
-		err = rows.Scan(&np.Id, &np.Fecha_publicacion, &np.Fecha_actualizacion, &np.Titulo, &np.Resumen, &np.Alt_portada, &np.Autor.Id, &np.Autor.Nombre, &np.Autor.Email, &rawTagIds, &rawTagNombres)
+		err = rows.Scan(&np.Id, &np.Fecha_publicacion, &np.Fecha_actualizacion, &np.Legally_retired_at, &np.Titulo, &np.Resumen, &np.Alt_portada, &np.Autor.Id, &np.Autor.Nombre, &np.Autor.Email, &rawTagIds, &rawTagNombres)
 		if err != nil {
 			return models.Publicaciones{}, err
 		}
@@ -178,3 +179,68 @@ func (s *MysqlPublicacionStore) Buscar(termino string) (models.Publicaciones, er
 
 	return publicaciones, nil
 }
+
+func (s *MysqlPublicacionStore) ListarPendientesNotificar() (models.Publicaciones, error) {
+	var query = `SELECT p.id, fecha_publicacion, COALESCE(GROUP_CONCAT(pt.tag_id), "") as tags_ids FROM publicaciones p LEFT JOIN publicaciones_tags pt ON p.id = pt.publicacion_id WHERE fecha_publicacion IS NOT NULL AND fecha_publicacion <= NOW() AND fecha_notificacion IS NULL AND legally_retired_at IS NULL GROUP BY p.id ORDER BY fecha_publicacion`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return models.Publicaciones{}, err
+	}
+
+	var publicaciones = make(models.Publicaciones, 0)
+	for rows.Next() {
+		var (
+			np        models.Publicacion
+			rawTagIds string
+		)
+
+		err = rows.Scan(&np.Id, &np.Fecha_publicacion, &rawTagIds)
+		if err != nil {
+			return models.Publicaciones{}, err
+		}
+
+		np.Tags = make([]models.Tag, 0)
+		if rawTagIds != "" {
+			for _, id := range strings.Split(rawTagIds, ",") {
+				np.Tags = append(np.Tags, models.Tag{Id: id})
+			}
+		}
+
+		publicaciones = append(publicaciones, np)
+	}
+
+	return publicaciones, nil
+}
+
+func (s *MysqlPublicacionStore) MarcarNotificada(id string) error {
+	_, err := s.db.Exec(`UPDATE publicaciones SET fecha_notificacion=NOW() WHERE id=?`, id)
+	return err
+}
+
+func (s *MysqlPublicacionStore) Publicar(id string, fecha time.Time) error {
+	res, err := s.db.Exec(`UPDATE publicaciones SET fecha_publicacion=? WHERE id=? AND (fecha_publicacion IS NULL OR fecha_publicacion > NOW())`,
+		fecha.UTC().Format("2006-01-02 15:04:05"), id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *MysqlPublicacionStore) Despublicar(id string) error {
+	res, err := s.db.Exec(`UPDATE publicaciones SET fecha_publicacion=NULL WHERE id=? AND fecha_publicacion IS NOT NULL AND legally_retired_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlRegistroStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlRegistroStore(db *sqlx.DB) *MysqlRegistroStore {
+	return &MysqlRegistroStore{
+		db: db,
+	}
+}
+
+// Recorta un texto al tamaño de su columna, que con el modo estricto de MySQL haría fallar todo el lote
+func recortar(texto string, maximo int) string {
+	if len([]rune(texto)) <= maximo {
+		return texto
+	}
+	return string([]rune(texto)[:maximo])
+}
+
+func (s *MysqlRegistroStore) Guardar(entradas []models.EntradaRegistro) error {
+	if len(entradas) == 0 {
+		return nil
+	}
+
+	var filas = make([]string, 0, len(entradas))
+	var args = make([]interface{}, 0, 9*len(entradas))
+	for _, e := range entradas {
+		filas = append(filas, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, e.Rid, e.Sid, e.Fecha.UTC().Format("2006-01-02 15:04:05"), recortar(e.Ip, 40), recortar(e.Url, 255),
+			recortar(e.Ruta, 100), e.Metodo, e.Duracion_ms, recortar(e.User_agent, 255), recortar(e.Referer, 255),
+			recortar(e.Evento, 40), e.Bot)
+	}
+
+	// El rid es la clave primaria, así que reintentar un lote que sí llegó a guardarse no duplica nada
+	_, err := s.db.Exec(`INSERT IGNORE INTO log (rid, sid, time, ip, url, ruta, method, time_taken_ms, user_agent, referer, evento, bot) VALUES `+
+		strings.Join(filas, ", "), args...)
+	return err
+}
+
+func (s *MysqlRegistroStore) ResumenDiario(desde time.Time) ([]models.EstadisticaDia, error) {
+	var dias = []models.EstadisticaDia{}
+	err := s.db.Select(&dias, `SELECT DATE(time) AS dia, SUM(bot = false) AS humanas, SUM(bot = true) AS bots,
+	COUNT(DISTINCT CASE WHEN bot = false THEN sid END) AS sesiones
+	FROM log WHERE time >= ? GROUP BY dia ORDER BY dia`, desde.UTC().Format("2006-01-02 15:04:05"))
+	return dias, err
+}
+
+func (s *MysqlRegistroStore) PublicacionesMasVistas(desde time.Time, limite int) ([]models.VisitasPublicacion, error) {
+	var visitas = []models.VisitasPublicacion{}
+	err := s.db.Select(&visitas, `SELECT p.id, p.titulo, COUNT(*) AS visitas, COUNT(DISTINCT l.sid) AS sesiones
+	FROM log l JOIN publicaciones p ON p.id = SUBSTRING(l.url, 7)
+	WHERE l.ruta = '/post/{postid}' AND l.method = 'GET' AND l.bot = false AND l.time >= ?
+	GROUP BY p.id, p.titulo ORDER BY visitas DESC LIMIT ?`, desde.UTC().Format("2006-01-02 15:04:05"), limite)
+	return visitas, err
+}
+
+func (s *MysqlRegistroStore) VisitasPublicacion(id string, desde time.Time) ([]models.VisitasDia, error) {
+	var dias = []models.VisitasDia{}
+	err := s.db.Select(&dias, `SELECT DATE(time) AS dia, COUNT(*) AS visitas FROM log
+	WHERE ruta = '/post/{postid}' AND url = ? AND method = 'GET' AND bot = false AND time >= ?
+	GROUP BY dia ORDER BY dia`, "/post/"+id, desde.UTC().Format("2006-01-02 15:04:05"))
+	return dias, err
+}
+
+func (s *MysqlRegistroStore) Referentes(desde time.Time, dominio string, limite int) ([]models.Referente, error) {
+	var referentes = []models.Referente{}
+	// De https://ejemplo.com/ruta?a=b se queda con ejemplo.com
+	err := s.db.Select(&referentes, `SELECT SUBSTRING_INDEX(SUBSTRING_INDEX(referer, '/', 3), '/', -1) AS origen, COUNT(*) AS visitas
+	FROM log WHERE referer != '' AND bot = false AND time >= ?
+	GROUP BY origen HAVING origen != ? ORDER BY visitas DESC LIMIT ?`, desde.UTC().Format("2006-01-02 15:04:05"), dominio, limite)
+	return referentes, err
+}
+
+func (s *MysqlRegistroStore) RutasMasLentas(desde time.Time, limite int) ([]models.TiempoRuta, error) {
+	var rutas = []models.TiempoRuta{}
+	// El percentil es el primer tiempo cuya distribución acumulada llega a 0,5 o 0,95
+	err := s.db.Select(&rutas, `SELECT ruta, metodo, COUNT(*) AS peticiones,
+	MIN(CASE WHEN acumulado >= 0.5 THEN time_taken_ms END) AS p50,
+	MIN(CASE WHEN acumulado >= 0.95 THEN time_taken_ms END) AS p95
+	FROM (
+		SELECT ruta, method AS metodo, time_taken_ms,
+		CUME_DIST() OVER (PARTITION BY ruta, method ORDER BY time_taken_ms) AS acumulado
+		FROM log WHERE ruta != '' AND time >= ?
+	) t
+	GROUP BY ruta, metodo ORDER BY p95 DESC LIMIT ?`, desde.UTC().Format("2006-01-02 15:04:05"), limite)
+	return rutas, err
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRecortarNoPartesCaracteres(t *testing.T) {
+	// Cada ñ ocupa dos bytes, así que cortar por bytes la dejaría a medias
+	var texto = strings.Repeat("ñ", 300)
+
+	var recortado = recortar(texto, 255)
+	if !utf8.ValidString(recortado) || utf8.RuneCountInString(recortado) != 255 {
+		t.Fatalf("se esperaban 255 caracteres válidos, se obtuvieron %d", utf8.RuneCountInString(recortado))
+	}
+	if recortar("Mozilla", 255) != "Mozilla" {
+		t.Fatal("un texto corto no se debe modificar")
+	}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlRevisionStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlRevisionStore(db *sqlx.DB) *MysqlRevisionStore {
+	return &MysqlRevisionStore{
+		db: db,
+	}
+}
+
+func (s *MysqlRevisionStore) Listar(tipo models.TipoRevision, entidad_id string) ([]models.Revision, error) {
+	var revisiones = make([]models.Revision, 0)
+	var query = `SELECT r.id, r.tipo, r.entidad_id, r.titulo, r.resumen, r.contenido, r.alt_portada, r.autor_id, COALESCE(a.nombre, r.autor_id), r.fecha FROM revisiones r LEFT JOIN autores a ON r.autor_id = a.id WHERE r.tipo=? AND r.entidad_id=? ORDER BY r.fecha DESC, r.id DESC`
+
+	rows, err := s.db.Query(query, tipo, entidad_id)
+	if err != nil {
+		return []models.Revision{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var nr models.Revision
+		err = rows.Scan(&nr.Id, &nr.Tipo, &nr.Entidad_id, &nr.Titulo, &nr.Resumen, &nr.Contenido, &nr.Alt_portada, &nr.Autor_id, &nr.Autor_nombre, &nr.Fecha)
+		if err != nil {
+			return []models.Revision{}, err
+		}
+		revisiones = append(revisiones, nr)
+	}
+
+	return revisiones, rows.Err()
+}
+
+func (s *MysqlRevisionStore) Obtener(tipo models.TipoRevision, entidad_id string, revision_id int) (models.Revision, error) {
+	var nr models.Revision
+	var query = `SELECT r.id, r.tipo, r.entidad_id, r.titulo, r.resumen, r.contenido, r.alt_portada, r.autor_id, COALESCE(a.nombre, r.autor_id), r.fecha FROM revisiones r LEFT JOIN autores a ON r.autor_id = a.id WHERE r.tipo=? AND r.entidad_id=? AND r.id=?`
+
+	err := s.db.QueryRow(query, tipo, entidad_id, revision_id).Scan(&nr.Id, &nr.Tipo, &nr.Entidad_id, &nr.Titulo, &nr.Resumen, &nr.Contenido, &nr.Alt_portada, &nr.Autor_id, &nr.Autor_nombre, &nr.Fecha)
+	if err != nil {
+		return models.Revision{}, err
+	}
+
+	return nr, nil
+}
+
+func (s *MysqlRevisionStore) Guardar(tx sqlx.Execer, r models.Revision) error {
+	_, err := tx.Exec(`INSERT INTO revisiones (tipo, entidad_id, titulo, resumen, contenido, alt_portada, autor_id) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		r.Tipo, r.Entidad_id, r.Titulo, r.Resumen, r.Contenido, r.Alt_portada, r.Autor_id)
+	return err
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlRolStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlRolStore(db *sqlx.DB) *MysqlRolStore {
+	return &MysqlRolStore{
+		db: db,
+	}
+}
+
+// Permiso que nunca puede quedarse sin nadie que lo tenga, o nadie podría volver a asignar roles
+const permisoGestionarPermisos = "permisos_gestionar"
+
+const consultaPermisosEfectivos = `SELECT rp.permiso_id, ra.autor_id FROM roles_autores ra JOIN roles_permisos rp ON ra.rol_id = rp.rol_id
+	UNION SELECT permiso_id, autor_id FROM permisos_usuarios`
+
+func (s *MysqlRolStore) ListarPermisos() ([]models.Permiso, error) {
+	var permisos = make([]models.Permiso, 0)
+	err := s.db.Select(&permisos, `SELECT id, comentario FROM permisos ORDER BY id`)
+	return permisos, err
+}
+
+func (s *MysqlRolStore) Listar() ([]models.Rol, error) {
+	var roles = make([]models.Rol, 0)
+	err := s.db.Select(&roles, `SELECT id, nombre, descripcion, exige_2fa FROM roles ORDER BY nombre`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT rol_id, permiso_id FROM roles_permisos`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permisos = make(map[string]map[string]bool)
+	for rows.Next() {
+		var rol, permiso string
+		if err := rows.Scan(&rol, &permiso); err != nil {
+			return nil, err
+		}
+		if permisos[rol] == nil {
+			permisos[rol] = make(map[string]bool)
+		}
+		permisos[rol][permiso] = true
+	}
+
+	for i := range roles {
+		roles[i].Permisos = permisos[roles[i].Id]
+		if roles[i].Permisos == nil {
+			roles[i].Permisos = make(map[string]bool)
+		}
+	}
+	return roles, rows.Err()
+}
+
+func (s *MysqlRolStore) Guardar(rol models.Rol) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO roles (id, nombre, descripcion, exige_2fa) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE nombre = VALUES(nombre), descripcion = VALUES(descripcion), exige_2fa = VALUES(exige_2fa)`,
+		rol.Id, rol.Nombre, rol.Descripcion, rol.Exige_2fa)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM roles_permisos WHERE rol_id = ?`, rol.Id); err != nil {
+		return err
+	}
+	for permiso, tiene := range rol.Permisos {
+		if !tiene {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO roles_permisos (rol_id, permiso_id) VALUES (?, ?)`, rol.Id, permiso); err != nil {
+			return err
+		}
+	}
+
+	if err := comprobarGestorPermisos(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *MysqlRolStore) Eliminar(id string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM roles WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	if err := comprobarGestorPermisos(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *MysqlRolStore) RolesPorAutor() (map[string][]string, error) {
+	rows, err := s.db.Query(`SELECT autor_id, rol_id FROM roles_autores ORDER BY rol_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles = make(map[string][]string)
+	for rows.Next() {
+		var autor, rol string
+		if err := rows.Scan(&autor, &rol); err != nil {
+			return nil, err
+		}
+		roles[autor] = append(roles[autor], rol)
+	}
+	return roles, rows.Err()
+}
+
+func (s *MysqlRolStore) AsignarRoles(autor_id string, roles []string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM roles_autores WHERE autor_id = ?`, autor_id); err != nil {
+		return err
+	}
+	for _, rol := range roles {
+		if _, err := tx.Exec(`INSERT INTO roles_autores (rol_id, autor_id) VALUES (?, ?)`, rol, autor_id); err != nil {
+			return err
+		}
+	}
+
+	if err := comprobarGestorPermisos(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *MysqlRolStore) PermisosDeAutor(autor_id string) (map[string]bool, error) {
+	var lista []string
+	err := s.db.Select(&lista, `SELECT DISTINCT permiso_id FROM (`+consultaPermisosEfectivos+`) p WHERE autor_id = ?`, autor_id)
+	if err != nil {
+		return nil, err
+	}
+
+	var permisos = make(map[string]bool, len(lista))
+	for _, p := range lista {
+		permisos[p] = true
+	}
+	return permisos, nil
+}
+
+// Comprueba, dentro de la transacción que hace el cambio, que alguien sigue pudiendo gestionar permisos
+func comprobarGestorPermisos(tx *sqlx.Tx) error {
+	var cantidad int
+	err := tx.Get(&cantidad, `SELECT COUNT(*) FROM (`+consultaPermisosEfectivos+`) p WHERE permiso_id = ?`, permisoGestionarPermisos)
+	if err != nil {
+		return err
+	}
+	if cantidad == 0 {
+		return models.ErrSinGestorPermisos
+	}
+	return nil
+}
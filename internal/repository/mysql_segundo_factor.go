@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlSegundoFactorStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlSegundoFactorStore(db *sqlx.DB) *MysqlSegundoFactorStore {
+	return &MysqlSegundoFactorStore{
+		db: db,
+	}
+}
+
+func (s *MysqlSegundoFactorStore) Obtener(autor_id string) (models.SegundoFactor, error) {
+	var sf models.SegundoFactor
+	err := s.db.Get(&sf, `SELECT id as autor_id, COALESCE(totp_secreto, '') as secreto, COALESCE(totp_pendiente, '') as pendiente, totp_ultimo_paso as ultimo_paso,
+		(SELECT COUNT(*) FROM autores_recuperacion r WHERE r.autor_id = autores.id AND r.usado IS NULL) as codigos_restantes
+		FROM autores WHERE id = ?`, autor_id)
+	return sf, err
+}
+
+func (s *MysqlSegundoFactorStore) Exigido(autor_id string) (bool, error) {
+	var exigido bool
+	err := s.db.Get(&exigido, `SELECT EXISTS (SELECT 1 FROM roles_autores ra JOIN roles r ON ra.rol_id = r.id WHERE ra.autor_id = ? AND r.exige_2fa = true)`, autor_id)
+	return exigido, err
+}
+
+func (s *MysqlSegundoFactorStore) GuardarPendiente(autor_id string, secreto string) error {
+	_, err := s.db.Exec(`UPDATE autores SET totp_pendiente = ? WHERE id = ?`, secreto, autor_id)
+	return err
+}
+
+func (s *MysqlSegundoFactorStore) Activar(autor_id string, paso int64, codigos_hash []string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE autores SET totp_secreto = totp_pendiente, totp_pendiente = NULL, totp_ultimo_paso = ? WHERE id = ? AND totp_pendiente IS NOT NULL`, paso, autor_id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := reemplazarCodigos(tx, autor_id, codigos_hash); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *MysqlSegundoFactorStore) Desactivar(autor_id string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE autores SET totp_secreto = NULL, totp_pendiente = NULL WHERE id = ?`, autor_id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM autores_recuperacion WHERE autor_id = ?`, autor_id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *MysqlSegundoFactorStore) RegistrarPaso(autor_id string, paso int64) (bool, error) {
+	res, err := s.db.Exec(`UPDATE autores SET totp_ultimo_paso = ? WHERE id = ? AND totp_ultimo_paso < ?`, paso, autor_id, paso)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *MysqlSegundoFactorStore) ReemplazarCodigos(autor_id string, codigos_hash []string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := reemplazarCodigos(tx, autor_id, codigos_hash); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func reemplazarCodigos(tx *sqlx.Tx, autor_id string, codigos_hash []string) error {
+	if _, err := tx.Exec(`DELETE FROM autores_recuperacion WHERE autor_id = ?`, autor_id); err != nil {
+		return err
+	}
+	for _, hash := range codigos_hash {
+		if _, err := tx.Exec(`INSERT INTO autores_recuperacion (codigo_hash, autor_id) VALUES (?, ?)`, hash, autor_id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MysqlSegundoFactorStore) ConsumirCodigo(autor_id string, codigo_hash string) error {
+	res, err := s.db.Exec(`UPDATE autores_recuperacion SET usado = NOW() WHERE autor_id = ? AND codigo_hash = ? AND usado IS NULL`, autor_id, codigo_hash)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *MysqlSegundoFactorStore) CrearLogin(hash string, autor_id string, validez time.Duration) error {
+	_, err := s.db.Exec(`INSERT INTO logins_pendientes (token_hash, autor_id, caduca) VALUES (?, ?, NOW() + INTERVAL ? SECOND)`,
+		hash, autor_id, int(validez.Seconds()))
+	return err
+}
+
+func (s *MysqlSegundoFactorStore) ObtenerLogin(hash string) (models.LoginPendiente, error) {
+	var login models.LoginPendiente
+	err := s.db.Get(&login, `SELECT autor_id, intentos FROM logins_pendientes WHERE token_hash = ? AND caduca > NOW()`, hash)
+	return login, err
+}
+
+func (s *MysqlSegundoFactorStore) SumarIntento(hash string) error {
+	_, err := s.db.Exec(`UPDATE logins_pendientes SET intentos = intentos + 1 WHERE token_hash = ?`, hash)
+	return err
+}
+
+func (s *MysqlSegundoFactorStore) EliminarLogin(hash string) error {
+	_, err := s.db.Exec(`DELETE FROM logins_pendientes WHERE token_hash = ? OR caduca < NOW()`, hash)
+	return err
+}
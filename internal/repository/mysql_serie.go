@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlSerieStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlSerieStore(db *sqlx.DB) *MysqlSerieStore {
+	return &MysqlSerieStore{
+		db: db,
+	}
+}
+
+func (s *MysqlSerieStore) Listar() ([]models.Serie, error) {
+	var series = make([]models.Serie, 0)
+	var rows, err = s.db.Query(`SELECT id, titulo FROM series ORDER BY titulo`)
+	if err != nil {
+		return []models.Serie{}, err
+	}
+
+	for rows.Next() {
+		var ns models.Serie
+		err = rows.Scan(&ns.Id, &ns.Titulo)
+		if err != nil {
+			return []models.Serie{}, err
+		}
+		series = append(series, ns)
+	}
+
+	for i, serie := range series {
+		serie.Publicaciones, err = s.listarPublicaciones(serie.Id)
+		if err != nil {
+			return []models.Serie{}, err
+		}
+		series[i] = serie
+	}
+
+	return series, nil
+}
+
+func (s *MysqlSerieStore) Obtener(serie_id string) (models.Serie, error) {
+	var serie models.Serie
+	var err = s.db.QueryRow(`SELECT id, titulo FROM series WHERE id=?`, serie_id).Scan(&serie.Id, &serie.Titulo)
+	if err != nil {
+		return models.Serie{}, err
+	}
+
+	serie.Publicaciones, err = s.listarPublicaciones(serie.Id)
+	if err != nil {
+		return models.Serie{}, err
+	}
+
+	return serie, nil
+}
+
+func (s *MysqlSerieStore) Crear(serie models.Serie) error {
+	_, err := s.db.Exec(`INSERT INTO series(id, titulo) VALUES (?, ?)`, serie.Id, serie.Titulo)
+	return err
+}
+
+var ErrPublicacionEnOtraSerie = errors.New("la publicación no existe o ya pertenece a otra serie")
+
+func (s *MysqlSerieStore) Actualizar(serie models.Serie) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE series SET titulo=? WHERE id=?`, serie.Titulo, serie.Id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE publicaciones SET serie_id=NULL, serie_posicion=NULL WHERE serie_id=?`, serie.Id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Tras vaciar esta serie, una publicación que conserve serie_id es de otra serie y no se le quita
+	for i, p := range serie.Publicaciones {
+		res, err := tx.Exec(`UPDATE publicaciones SET serie_id=?, serie_posicion=? WHERE id=? AND serie_id IS NULL`, serie.Id, i+1, p.Id)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			_ = tx.Rollback()
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: %s", ErrPublicacionEnOtraSerie, p.Id)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Lista todas las publicaciones de una serie, públicas o no, ordenadas por su posición
+func (s *MysqlSerieStore) listarPublicaciones(serie_id string) (models.Publicaciones, error) {
+	var publicaciones = make(models.Publicaciones, 0)
+	var query = `SELECT p.id, COALESCE(fecha_publicacion, ""), fecha_actualizacion, COALESCE(legally_retired_at, ""), titulo, resumen, alt_portada, COALESCE(serie_id, ""), COALESCE(serie_posicion, 0), autor_id, autores.nombre as autor_nombre FROM publicaciones p LEFT JOIN autores ON p.autor_id = autores.id WHERE serie_id=? ORDER BY serie_posicion, fecha_publicacion`
+
+	rows, err := s.db.Query(query, serie_id)
+	if err != nil {
+		return models.Publicaciones{}, err
+	}
+
+	for rows.Next() {
+		var np models.Publicacion
+		err = rows.Scan(&np.Id, &np.Fecha_publicacion, &np.Fecha_actualizacion, &np.Legally_retired_at, &np.Titulo, &np.Resumen, &np.Alt_portada, &np.Serie_id, &np.Serie_posicion, &np.Autor.Id, &np.Autor.Nombre)
+		if err != nil {
+			return models.Publicaciones{}, err
+		}
+		publicaciones = append(publicaciones, np)
+	}
+
+	return publicaciones, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlSesionStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlSesionStore(db *sqlx.DB) *MysqlSesionStore {
+	return &MysqlSesionStore{
+		db: db,
+	}
+}
+
+// Las sesiones caducan a los 7 días de iniciarse, como comprueba getSession
+const columnasSesion = `s.id, s.autor_id, COALESCE(a.nombre, s.autor_id) as autor_nombre, s.iniciada, COALESCE(s.ultimo_uso, s.iniciada) as ultimo_uso, s.user_agent, s.ip
+	FROM sesiones s LEFT JOIN autores a ON s.autor_id = a.id
+	WHERE s.revocada = false AND s.iniciada > NOW() - INTERVAL 7 DAY`
+
+func (s *MysqlSesionStore) Crear(sessid string, autor_id string, user_agent string, ip string) error {
+	// La columna admite 255 caracteres, no bytes, y cortar por bytes puede dejar un carácter a medias
+	if utf8.RuneCountInString(user_agent) > 255 {
+		user_agent = string([]rune(user_agent)[:255])
+	}
+	_, err := s.db.Exec(`INSERT INTO sesiones (sessid, iniciada, revocada, autor_id, user_agent, ip, ultimo_uso) VALUES (?, NOW(), false, ?, ?, ?, NOW())`,
+		sessid, autor_id, user_agent, ip)
+	return err
+}
+
+func (s *MysqlSesionStore) Listar(autor_id string) ([]models.Sesion, error) {
+	var sesiones = make([]models.Sesion, 0)
+	err := s.db.Select(&sesiones, `SELECT `+columnasSesion+` AND s.autor_id = ? ORDER BY ultimo_uso DESC, s.id DESC`, autor_id)
+	return sesiones, err
+}
+
+func (s *MysqlSesionStore) Obtener(id int) (models.Sesion, error) {
+	var sesion models.Sesion
+	err := s.db.Get(&sesion, `SELECT `+columnasSesion+` AND s.id = ?`, id)
+	return sesion, err
+}
+
+func (s *MysqlSesionStore) ObtenerId(sessid string) (int, error) {
+	var id int
+	err := s.db.Get(&id, `SELECT id FROM sesiones WHERE sessid = ?`, sessid)
+	return id, err
+}
+
+func (s *MysqlSesionStore) Revocar(id int) error {
+	_, err := s.db.Exec(`UPDATE sesiones SET revocada=true WHERE id=?`, id)
+	return err
+}
+
+func (s *MysqlSesionStore) RevocarTodas(autor_id string, excepto string) error {
+	_, err := s.db.Exec(`UPDATE sesiones SET revocada=true WHERE autor_id=? AND sessid != ? AND revocada=false`, autor_id, excepto)
+	return err
+}
+
+func (s *MysqlSesionStore) Tocar(sessid string, ip string) error {
+	_, err := s.db.Exec(`UPDATE sesiones SET ultimo_uso=NOW(), ip=? WHERE sessid=? AND (ultimo_uso IS NULL OR ultimo_uso < NOW() - INTERVAL 1 MINUTE)`, ip, sessid)
+	return err
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlTareaStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlTareaStore(db *sqlx.DB) *MysqlTareaStore {
+	return &MysqlTareaStore{
+		db: db,
+	}
+}
+
+// Tiempo tras el que una tarea en curso se considera abandonada, por ejemplo si el servidor se detuvo a mitad
+const tareaAbandonadaMinutos = 15
+
+const columnasTarea = `id, tipo, carga, estado, intentos, max_intentos, siguiente_intento, COALESCE(ultimo_error, "") as ultimo_error, fecha_creacion, fecha_actualizacion`
+
+func (s *MysqlTareaStore) Encolar(tipo string, carga string) error {
+	_, err := s.db.Exec(`INSERT INTO tareas (tipo, carga) VALUES (?, ?)`, tipo, carga)
+	return err
+}
+
+func (s *MysqlTareaStore) Reclamar() (models.Tarea, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return models.Tarea{}, err
+	}
+
+	var tarea models.Tarea
+	err = tx.Get(&tarea, `SELECT `+columnasTarea+` FROM tareas
+	WHERE (estado = "pendiente" AND siguiente_intento <= NOW())
+	   OR (estado = "en_curso" AND reclamada_en < NOW() - INTERVAL ? MINUTE)
+	ORDER BY siguiente_intento, id LIMIT 1 FOR UPDATE SKIP LOCKED`, tareaAbandonadaMinutos)
+	if err != nil {
+		tx.Rollback()
+		return models.Tarea{}, err
+	}
+
+	_, err = tx.Exec(`UPDATE tareas SET estado = "en_curso", intentos = intentos + 1, reclamada_en = NOW() WHERE id = ?`, tarea.Id)
+	if err != nil {
+		tx.Rollback()
+		return models.Tarea{}, err
+	}
+
+	tarea.Estado = models.TareaEnCurso
+	tarea.Intentos++
+	return tarea, tx.Commit()
+}
+
+func (s *MysqlTareaStore) Completar(id int) error {
+	_, err := s.db.Exec(`UPDATE tareas SET estado = "completada", reclamada_en = NULL WHERE id = ?`, id)
+	return err
+}
+
+// Espera exponencial desde dos minutos hasta un máximo de seis horas entre intentos
+func (s *MysqlTareaStore) Fallar(t models.Tarea, motivo string, definitivo bool) error {
+	_, err := s.db.Exec(`UPDATE tareas SET
+		ultimo_error = ?,
+		reclamada_en = NULL,
+		estado = IF(? OR intentos >= max_intentos, "fallida", "pendiente"),
+		siguiente_intento = NOW() + INTERVAL LEAST(POW(2, intentos), 360) MINUTE
+	WHERE id = ?`, motivo, definitivo, t.Id)
+	return err
+}
+
+func (s *MysqlTareaStore) Reintentar(id int) error {
+	_, err := s.db.Exec(`UPDATE tareas SET estado = "pendiente", intentos = 0, siguiente_intento = NOW() WHERE id = ? AND estado = "fallida"`, id)
+	return err
+}
+
+func (s *MysqlTareaStore) Listar(estado models.EstadoTarea, limite int) ([]models.Tarea, error) {
+	var tareas = make([]models.Tarea, 0)
+	err := s.db.Select(&tareas, `SELECT `+columnasTarea+` FROM tareas WHERE estado = ? ORDER BY fecha_actualizacion DESC, id DESC LIMIT ?`, estado, limite)
+	if err != nil {
+		return []models.Tarea{}, err
+	}
+	return tareas, nil
+}
+
+func (s *MysqlTareaStore) Contar() (map[models.EstadoTarea]int, error) {
+	var filas []struct {
+		Estado   models.EstadoTarea
+		Cantidad int
+	}
+	err := s.db.Select(&filas, `SELECT estado, COUNT(*) as cantidad FROM tareas GROUP BY estado`)
+	if err != nil {
+		return map[models.EstadoTarea]int{}, err
+	}
+
+	var cuenta = make(map[models.EstadoTarea]int)
+	for _, f := range filas {
+		cuenta[f.Estado] = f.Cantidad
+	}
+	return cuenta, nil
+}
+
+func (s *MysqlTareaStore) PurgarCompletadas(antiguedad time.Duration) error {
+	_, err := s.db.Exec(`DELETE FROM tareas WHERE estado = "completada" AND fecha_actualizacion < NOW() - INTERVAL ? SECOND`, int(antiguedad.Seconds()))
+	return err
+}
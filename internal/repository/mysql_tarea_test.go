@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+Aplica las migraciones de búsqueda y de tareas, en orden, sobre una tabla de publicaciones mínima en la base de datos de PRUEBAS_MYSQL_DSN, que
+debe estar vacía porque se crean y borran tablas. Sin la variable, las pruebas se omiten.
+*/
+func baseDatosTareas(t *testing.T) *sqlx.DB {
+	t.Helper()
+	var dsn = os.Getenv("PRUEBAS_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("PRUEBAS_MYSQL_DSN no está definida")
+	}
+	db, err := sqlx.Connect("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DROP TABLE IF EXISTS tareas, busqueda_pendiente, publicaciones`)
+		db.Close()
+	})
+
+	if _, err := db.Exec(`DROP TABLE IF EXISTS tareas, busqueda_pendiente, publicaciones`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE publicaciones(id VARCHAR(40) NOT NULL, titulo VARCHAR(80) NOT NULL, PRIMARY KEY (id))`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fichero := range []string{"16-busqueda-pendiente.sql", "17-tareas.sql"} {
+		migracion, err := os.ReadFile("../../deploy/mysql/" + fichero)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Los triggers tienen una sola sentencia, así que se puede separar por ;
+		for _, sentencia := range strings.Split(string(migracion), ";\n") {
+			if sentencia = strings.TrimSpace(sentencia); sentencia == "" || strings.HasPrefix(sentencia, "USE ") {
+				continue
+			}
+			if _, err := db.Exec(sentencia); err != nil {
+				t.Fatalf("error aplicando %q de %s: %s", sentencia, fichero, err.Error())
+			}
+		}
+	}
+	return db
+}
+
+func contarPendientes(t *testing.T, db *sqlx.DB, id string) int {
+	t.Helper()
+	var n int
+	err := db.Get(&n, `SELECT COUNT(*) FROM tareas WHERE tipo = "busqueda" AND estado = "pendiente" AND JSON_UNQUOTE(JSON_EXTRACT(carga, "$.publicacion_id")) = ?`, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestTriggerBusquedaNoDuplicaPendientes(t *testing.T) {
+	var db = baseDatosTareas(t)
+
+	db.MustExec(`INSERT INTO publicaciones (id, titulo) VALUES ("faro", "El faro"), ("puerto", "El puerto")`)
+	for _, titulo := range []string{"Uno", "Dos", "Tres"} {
+		db.MustExec(`UPDATE publicaciones SET titulo = ? WHERE id = "faro"`, titulo)
+	}
+	if n := contarPendientes(t, db, "faro"); n != 1 {
+		t.Fatalf("tras varios cambios se esperaba una tarea pendiente, hay %d", n)
+	}
+
+	// Una publicación distinta tiene su propia tarea
+	db.MustExec(`UPDATE publicaciones SET titulo = "Otro" WHERE id = "puerto"`)
+	if n := contarPendientes(t, db, "puerto"); n != 1 {
+		t.Fatalf("se esperaba una tarea pendiente para otra publicación, hay %d", n)
+	}
+
+	// Cuando la tarea ya no está pendiente, un cambio nuevo vuelve a encolarla
+	db.MustExec(`UPDATE tareas SET estado = "completada"`)
+	db.MustExec(`UPDATE publicaciones SET titulo = "Cuatro" WHERE id = "faro"`)
+	db.MustExec(`DELETE FROM publicaciones WHERE id = "faro"`)
+	if n := contarPendientes(t, db, "faro"); n != 1 {
+		t.Fatalf("tras completar la anterior se esperaba una tarea pendiente nueva, hay %d", n)
+	}
+}
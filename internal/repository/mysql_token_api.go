@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type MysqlTokenApiStore struct {
+	db *sqlx.DB
+}
+
+func NewMysqlTokenApiStore(db *sqlx.DB) *MysqlTokenApiStore {
+	return &MysqlTokenApiStore{
+		db: db,
+	}
+}
+
+const columnasTokenApi = `t.id, t.autor_id, a.nombre as autor_nombre, t.nombre, t.prefijo, t.alcances, t.creado,
+	COALESCE(t.ultimo_uso, '') as ultimo_uso, COALESCE(t.caduca, '') as caduca
+	FROM tokens_api t JOIN autores a ON t.autor_id = a.id
+	WHERE t.revocado = false AND (t.caduca IS NULL OR t.caduca > NOW())`
+
+func (s *MysqlTokenApiStore) Crear(token models.TokenApi, hash string, validez time.Duration) error {
+	var segundos = int64(validez.Seconds())
+	_, err := s.db.Exec(`INSERT INTO tokens_api (autor_id, nombre, token_hash, prefijo, alcances, caduca)
+		VALUES (?, ?, ?, ?, ?, IF(? > 0, NOW() + INTERVAL ? SECOND, NULL))`,
+		token.Autor_id, token.Nombre, hash, token.Prefijo, token.Alcances, segundos, segundos)
+	return err
+}
+
+func (s *MysqlTokenApiStore) Listar(autor_id string) ([]models.TokenApi, error) {
+	var tokens = make([]models.TokenApi, 0)
+	err := s.db.Select(&tokens, `SELECT `+columnasTokenApi+` AND t.autor_id = ? ORDER BY t.creado DESC, t.id DESC`, autor_id)
+	return tokens, err
+}
+
+func (s *MysqlTokenApiStore) ObtenerPorHash(hash string) (models.TokenApi, error) {
+	var token models.TokenApi
+	err := s.db.Get(&token, `SELECT `+columnasTokenApi+` AND a.activo = true AND t.token_hash = ?`, hash)
+	return token, err
+}
+
+func (s *MysqlTokenApiStore) Revocar(id int, autor_id string) error {
+	res, err := s.db.Exec(`UPDATE tokens_api SET revocado = true WHERE id = ? AND autor_id = ? AND revocado = false`, id, autor_id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *MysqlTokenApiStore) Tocar(id int) error {
+	_, err := s.db.Exec(`UPDATE tokens_api SET ultimo_uso = NOW() WHERE id = ? AND (ultimo_uso IS NULL OR ultimo_uso < NOW() - INTERVAL 1 MINUTE)`, id)
+	return err
+}
@@ -0,0 +1,16 @@
+package repository
+
+import "vigo360.es/new/internal/models"
+
+type AdjuntoStore interface {
+	// Lista los adjuntos de un trabajo, del más antiguo al más reciente
+	Listar(trabajo_id string) ([]models.Adjunto, error)
+	// Obtiene un adjunto por su id
+	Obtener(id int) (models.Adjunto, error)
+	// Obtiene un adjunto de un trabajo por su nombre de archivo
+	ObtenerPorNombre(trabajo_id string, nombre_archivo string) (models.Adjunto, error)
+	// Guarda un adjunto nuevo y devuelve su id
+	Crear(a models.Adjunto) (int, error)
+	// Elimina la fila de un adjunto. El archivo en disco lo borra quien llama
+	Eliminar(id int) error
+}
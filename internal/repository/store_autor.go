@@ -1,9 +1,30 @@
 package repository
 
-import "vigo360.es/new/internal/models"
+import (
+	"time"
+
+	"vigo360.es/new/internal/models"
+)
 
 type AutorStore interface {
 	Listar() ([]models.Autor, error)
 	Obtener(string) (models.Autor, error)
 	Buscar(string) ([]models.Autor, error)
+	// Obtiene un autor por su correo electrónico
+	ObtenerPorEmail(email string) (models.Autor, error)
+	// Crea un autor sin contraseña, que no podrá iniciar sesión hasta elegir una con un token
+	Crear(autor models.Autor) error
+	// Devuelve el hash bcrypt de la contraseña de un autor
+	ObtenerContraseña(autor_id string) (string, error)
+	// Cambia el hash bcrypt de la contraseña de un autor
+	CambiarContraseña(autor_id string, hash string) error
+	// Activa o desactiva un autor. Al desactivarlo se revocan todas sus sesiones
+	CambiarActivo(autor_id string, activo bool) error
+
+	// Guarda un token de un solo uso, identificado por el hash SHA-256 del token, que caduca pasada la validez
+	CrearToken(autor_id string, tipo models.TipoTokenAutor, hash string, validez time.Duration) error
+	// Obtiene un token sin usar ni caducado. Devuelve sql.ErrNoRows si no hay ninguno
+	ObtenerToken(hash string) (models.TokenAutor, error)
+	// Marca como usado un token sin usar ni caducado, y lo devuelve. Devuelve sql.ErrNoRows si no hay ninguno
+	ConsumirToken(hash string) (models.TokenAutor, error)
 }
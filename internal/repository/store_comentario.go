@@ -5,12 +5,16 @@ import "vigo360.es/new/internal/models"
 type ComentarioStore interface {
 	// Lista los comentarios públicos para un artículo en forma de lista
 	ListarPublicos(publicacion_id string) ([]models.Comentario, error)
+	// Obtiene un comentario cualquiera sea su estado
+	Obtener(comentario_id string) (models.Comentario, error)
 	// Lista los comentarios con un estado específico
 	ListarPorEstado(models.EstadoComentario) ([]models.Comentario, error)
 	// Guarda un nuevo comentario a la base de datos
 	GuardarComentario(models.Comentario) error
-	// Cambia el estado de PENDIENTE a APROBADO
-	Aprobar(comentario_id string, moderador string) error
-	// Cambia el estado de PENDIENTE a RECHAZADO
-	Rechazar(comentario_id string, moderador string) error
+	// Aplica una decisión de moderación a varios comentarios a la vez, registrándola en el historial
+	Moderar(comentario_ids []string, accion models.AccionModeracion, moderador string) error
+	// Cambia el contenido de un comentario, guardando el anterior en el historial
+	Editar(comentario_id string, contenido string, moderador string) error
+	// Lista las decisiones y ediciones de moderación de un comentario, de más reciente a más antigua
+	ListarModeraciones(comentario_id string) ([]models.Moderacion, error)
 }
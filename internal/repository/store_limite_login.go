@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"time"
+
+	"vigo360.es/new/internal/models"
+)
+
+type LimiteLoginStore interface {
+	// Obtiene los intentos de una clave. Si no hay ninguno devuelve el valor vacío
+	Obtener(clave string) (models.IntentosLogin, error)
+	// Sustituye los intentos de una clave solo si no han cambiado desde que se leyeron. Devuelve false si otra petición se adelantó
+	Actualizar(clave string, anterior models.IntentosLogin, nuevo models.IntentosLogin) (bool, error)
+	// Elimina los intentos de una clave, junto con los de cualquier clave sin intentos desde antes de la fecha indicada
+	Limpiar(clave string, antiguos time.Time) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import "vigo360.es/new/internal/models"
+
+type MediaStore interface {
+	// Lista las imágenes de la mediateca, de la más reciente a la más antigua. Si publicacion_id no está vacío,
+	// solo las que se subieron desde esa publicación
+	Listar(publicacion_id string) ([]models.Media, error)
+	// Obtiene una imagen por su id
+	Obtener(id int) (models.Media, error)
+	// Guarda una imagen nueva y devuelve su id
+	Crear(m models.Media) (int, error)
+	// Cambia el texto alternativo y la leyenda de una imagen
+	Actualizar(id int, alt string, leyenda string) error
+	// Elimina la fila de una imagen si ninguna publicación ni trabajo la enlaza, o devuelve ErrMediaEnUso
+	Eliminar(m models.Media) error
+	// Lista las publicaciones y trabajos cuyo contenido enlaza a la imagen
+	Usos(m models.Media) ([]models.UsoMedia, error)
+}
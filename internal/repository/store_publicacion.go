@@ -1,6 +1,10 @@
 package repository
 
-import "vigo360.es/new/internal/models"
+import (
+	"time"
+
+	"vigo360.es/new/internal/models"
+)
 
 type PublicacionStore interface {
 	Listar() (models.Publicaciones, error)
@@ -9,4 +13,13 @@ type PublicacionStore interface {
 	Existe(id string) (bool, error)
 	ObtenerPorId(id string, requirePublic bool) (models.Publicacion, error)
 	Buscar(query string) (models.Publicaciones, error)
+	// Lista las publicaciones cuya fecha de publicación ya ha pasado pero aún no se han notificado a los buscadores
+	ListarPendientesNotificar() (models.Publicaciones, error)
+	// Marca una publicación como notificada a los buscadores
+	MarcarNotificada(id string) error
+	// Publica o programa una publicación que aún no ha salido. Devuelve sql.ErrNoRows si no existe o ya está publicada
+	Publicar(id string, fecha time.Time) error
+	// Devuelve a borrador una publicación publicada o programada. Devuelve sql.ErrNoRows si no existe, ya es un borrador
+	// o está retirada por motivos legales
+	Despublicar(id string) error
 }
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"time"
+
+	"vigo360.es/new/internal/models"
+)
+
+type RegistroStore interface {
+	// Guarda varias peticiones en una sola consulta. Las que ya estuvieran guardadas se ignoran
+	Guardar(entradas []models.EntradaRegistro) error
+	// Peticiones por día desde la fecha indicada, con las sesiones distintas de personas
+	ResumenDiario(desde time.Time) ([]models.EstadisticaDia, error)
+	// Publicaciones con más visitas de personas desde la fecha indicada
+	PublicacionesMasVistas(desde time.Time, limite int) ([]models.VisitasPublicacion, error)
+	// Visitas de personas a una publicación por día desde la fecha indicada
+	VisitasPublicacion(id string, desde time.Time) ([]models.VisitasDia, error)
+	// Sitios desde los que llegan más visitas de personas, sin contar el dominio indicado
+	Referentes(desde time.Time, dominio string, limite int) ([]models.Referente, error)
+	// Rutas con el percentil 95 del tiempo de respuesta más alto
+	RutasMasLentas(desde time.Time, limite int) ([]models.TiempoRuta, error)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"github.com/jmoiron/sqlx"
+	"vigo360.es/new/internal/models"
+)
+
+type RevisionStore interface {
+	// Lista las revisiones de una publicación o trabajo, de la más reciente a la más antigua
+	Listar(tipo models.TipoRevision, entidad_id string) ([]models.Revision, error)
+	// Obtiene una revisión concreta de una publicación o trabajo
+	Obtener(tipo models.TipoRevision, entidad_id string, revision_id int) (models.Revision, error)
+	// Guarda una nueva revisión. Recibe la transacción en la que se está guardando el contenido
+	Guardar(tx sqlx.Execer, revision models.Revision) error
+}
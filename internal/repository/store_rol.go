@@ -0,0 +1,20 @@
+package repository
+
+import "vigo360.es/new/internal/models"
+
+type RolStore interface {
+	// Lista todos los permisos que existen
+	ListarPermisos() ([]models.Permiso, error)
+	// Lista todos los roles con sus permisos
+	Listar() ([]models.Rol, error)
+	// Guarda un rol, creándolo si no existe, y reemplaza sus permisos
+	Guardar(rol models.Rol) error
+	// Elimina un rol y sus asignaciones
+	Eliminar(id string) error
+	// Devuelve los ids de los roles de cada autor
+	RolesPorAutor() (map[string][]string, error)
+	// Reemplaza los roles de un autor
+	AsignarRoles(autor_id string, roles []string) error
+	// Permisos efectivos de un autor: los de sus roles y los asignados directamente
+	PermisosDeAutor(autor_id string) (map[string]bool, error)
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"time"
+
+	"vigo360.es/new/internal/models"
+)
+
+type SegundoFactorStore interface {
+	// Obtiene el estado de la verificación en dos pasos de un autor
+	Obtener(autor_id string) (models.SegundoFactor, error)
+	// Indica si alguno de los roles del autor exige la verificación en dos pasos
+	Exigido(autor_id string) (bool, error)
+	// Guarda el secreto que se está dando de alta, sustituyendo al anterior sin confirmar
+	GuardarPendiente(autor_id string, secreto string) error
+	// Confirma el secreto pendiente, apunta el paso usado y sustituye los códigos de recuperación
+	Activar(autor_id string, paso int64, codigos_hash []string) error
+	// Quita el secreto y los códigos de recuperación
+	Desactivar(autor_id string) error
+	// Apunta el paso de un código válido. Devuelve false si ya se había usado ese paso o uno posterior
+	RegistrarPaso(autor_id string, paso int64) (bool, error)
+	// Sustituye los códigos de recuperación, identificados por su hash SHA-256
+	ReemplazarCodigos(autor_id string, codigos_hash []string) error
+	// Marca como usado un código de recuperación. Devuelve sql.ErrNoRows si no hay ninguno sin usar con ese hash
+	ConsumirCodigo(autor_id string, codigo_hash string) error
+
+	// Guarda un inicio de sesión pendiente del código, identificado por el hash SHA-256 del token
+	CrearLogin(hash string, autor_id string, validez time.Duration) error
+	// Obtiene un inicio de sesión pendiente sin caducar. Devuelve sql.ErrNoRows si no hay ninguno
+	ObtenerLogin(hash string) (models.LoginPendiente, error)
+	// Suma un intento fallido a un inicio de sesión pendiente
+	SumarIntento(hash string) error
+	// Elimina un inicio de sesión pendiente, junto con los caducados
+	EliminarLogin(hash string) error
+}
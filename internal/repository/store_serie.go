@@ -0,0 +1,15 @@
+package repository
+
+import "vigo360.es/new/internal/models"
+
+type SerieStore interface {
+	// Lista todas las series, con sus publicaciones ordenadas por posición
+	Listar() ([]models.Serie, error)
+	// Obtiene una serie, con sus publicaciones ordenadas por posición
+	Obtener(serie_id string) (models.Serie, error)
+	// Crea una nueva serie vacía
+	Crear(models.Serie) error
+	// Actualiza el título de la serie y sustituye sus publicaciones por las indicadas, en ese orden. Devuelve
+	// ErrPublicacionEnOtraSerie si alguna no existe o está en otra serie, sin cambiar nada
+	Actualizar(models.Serie) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import "vigo360.es/new/internal/models"
+
+type SesionStore interface {
+	// Guarda una sesión nueva
+	Crear(sessid string, autor_id string, user_agent string, ip string) error
+	// Lista las sesiones sin revocar ni caducar de un autor, de la usada más recientemente a la que menos
+	Listar(autor_id string) ([]models.Sesion, error)
+	// Obtiene una sesión sin revocar por su id numérico
+	Obtener(id int) (models.Sesion, error)
+	// Obtiene el id numérico de una sesión a partir de su token
+	ObtenerId(sessid string) (int, error)
+	// Revoca una sesión por su id numérico
+	Revocar(id int) error
+	// Revoca todas las sesiones de un autor salvo la indicada, que puede estar vacía
+	RevocarTodas(autor_id string, excepto string) error
+	// Apunta que la sesión se acaba de usar desde una IP. Solo escribe si ha pasado un rato desde la última vez
+	Tocar(sessid string, ip string) error
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"time"
+
+	"vigo360.es/new/internal/models"
+)
+
+type TareaStore interface {
+	// Añade una tarea a la cola para ejecutarla lo antes posible
+	Encolar(tipo string, carga string) error
+	// Reserva la siguiente tarea lista para ejecutarse. Devuelve sql.ErrNoRows si no hay ninguna
+	Reclamar() (models.Tarea, error)
+	// Marca una tarea como completada
+	Completar(id int) error
+	// Registra un error. La tarea se reintenta más tarde, o pasa a fallida si ha agotado sus intentos o es definitivo
+	Fallar(t models.Tarea, motivo string, definitivo bool) error
+	// Devuelve una tarea fallida a la cola con los intentos a cero
+	Reintentar(id int) error
+	// Lista las tareas con un estado, de más reciente a más antigua
+	Listar(estado models.EstadoTarea, limite int) ([]models.Tarea, error)
+	// Cuenta las tareas que hay en cada estado
+	Contar() (map[models.EstadoTarea]int, error)
+	// Elimina las tareas completadas hace más del tiempo indicado
+	PurgarCompletadas(antiguedad time.Duration) error
+}
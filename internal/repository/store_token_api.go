@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"time"
+
+	"vigo360.es/new/internal/models"
+)
+
+type TokenApiStore interface {
+	// Guarda un token nuevo identificado por el hash SHA-256. Sin validez, el token no caduca
+	Crear(token models.TokenApi, hash string, validez time.Duration) error
+	// Lista los tokens sin revocar ni caducar de un autor, del más reciente al más antiguo
+	Listar(autor_id string) ([]models.TokenApi, error)
+	// Obtiene un token sin revocar ni caducar de un autor activo. Devuelve sql.ErrNoRows si no hay ninguno
+	ObtenerPorHash(hash string) (models.TokenApi, error)
+	// Revoca un token, solo si es del autor indicado. Devuelve sql.ErrNoRows si no había ninguno
+	Revocar(id int, autor_id string) error
+	// Apunta que el token se acaba de usar. Solo escribe si ha pasado un rato desde la última vez
+	Tocar(id int) error
+}
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"net/http"
+
+	"github.com/algolia/algoliasearch-client-go/v3/algolia/opt"
+	"github.com/algolia/algoliasearch-client-go/v3/algolia/search"
+)
+
+type AlgoliaConfig struct {
+	AppId  string
+	ApiKey string
+	Index  string
+	// Servidores a los que enviar las peticiones en lugar de los de Algolia, por ejemplo uno local de pruebas.
+	// El cliente siempre usa HTTPS.
+	Hosts []string
+	// Cliente HTTP con el que hacer las peticiones. Si es nil, se usa el del cliente de Algolia
+	Cliente *http.Client
+}
+
+type AlgoliaIndex struct {
+	index *search.Index
+}
+
+func NewAlgoliaIndex(c AlgoliaConfig) *AlgoliaIndex {
+	var config = search.Configuration{
+		AppID:  c.AppId,
+		APIKey: c.ApiKey,
+		Hosts:  c.Hosts,
+	}
+	if c.Cliente != nil {
+		config.Requester = requesterHttp{c.Cliente}
+	}
+
+	client := search.NewClientWithConfig(config)
+	return &AlgoliaIndex{
+		index: client.InitIndex(c.Index),
+	}
+}
+
+type requesterHttp struct {
+	cliente *http.Client
+}
+
+func (r requesterHttp) Request(req *http.Request) (*http.Response, error) {
+	return r.cliente.Do(req)
+}
+
+// Documento con el identificador que usa Algolia para reemplazar objetos existentes
+type objetoAlgolia struct {
+	ObjectID string `json:"objectID"`
+	Documento
+}
+
+func (a *AlgoliaIndex) Buscar(termino string) ([]Documento, error) {
+	result, err := a.index.Search(termino, opt.Filters("fecha_publicacion != null"))
+	if err != nil {
+		return []Documento{}, err
+	}
+
+	var documentos = make([]Documento, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		documentos = append(documentos, Documento{
+			Id:                  campoHit(hit, "id"),
+			Alt_portada:         campoHit(hit, "alt_portada"),
+			Titulo:              campoHit(hit, "titulo"),
+			Resumen:             campoHit(hit, "resumen"),
+			Fecha_publicacion:   campoHit(hit, "fecha_publicacion"),
+			Fecha_actualizacion: campoHit(hit, "fecha_actualizacion"),
+			Autor:               campoHit(hit, "autor"),
+		})
+	}
+
+	return documentos, nil
+}
+
+func (a *AlgoliaIndex) Guardar(documentos ...Documento) error {
+	var objetos = make([]objetoAlgolia, 0, len(documentos))
+	for _, d := range documentos {
+		objetos = append(objetos, objetoAlgolia{ObjectID: d.Id, Documento: d})
+	}
+
+	// No se espera a que Algolia termine de indexar, basta con que haya aceptado los cambios
+	_, err := a.index.SaveObjects(objetos)
+	return err
+}
+
+func (a *AlgoliaIndex) Eliminar(ids ...string) error {
+	_, err := a.index.DeleteObjects(ids)
+	return err
+}
+
+// Los hits de Algolia son mapas sin tipo, y los campos pueden faltar si el objeto se indexó con otra versión
+func campoHit(hit map[string]interface{}, campo string) string {
+	valor, _ := hit[campo].(string)
+	return valor
+}
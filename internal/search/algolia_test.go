@@ -0,0 +1,136 @@
+package search
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Petición recibida por el servidor que hace de Algolia
+type peticionAlgolia struct {
+	Metodo string
+	Ruta   string
+	Cuerpo map[string]any
+}
+
+// Servidor HTTPS que responde como Algolia y guarda las peticiones recibidas. Con otra clave que "clave" responde 403
+func nuevoAlgoliaPrueba(t *testing.T, clave string) (*AlgoliaIndex, func() []peticionAlgolia) {
+	t.Helper()
+	var (
+		mu         sync.Mutex
+		peticiones []peticionAlgolia
+	)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p = peticionAlgolia{Metodo: r.Method, Ruta: r.URL.Path}
+		raw, _ := io.ReadAll(r.Body)
+		json.Unmarshal(raw, &p.Cuerpo)
+		mu.Lock()
+		peticiones = append(peticiones, p)
+		mu.Unlock()
+
+		if r.Header.Get("X-Algolia-Application-Id") != "app" || r.Header.Get("X-Algolia-Api-Key") != "clave" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"credenciales incorrectas","status":403}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/query") {
+			w.Write([]byte(`{"hits":[{"objectID":"faro","id":"faro","titulo":"El faro","fecha_publicacion":"2024-01-01 10:00:00","autor":"Ana"}],"nbHits":1}`))
+			return
+		}
+		w.Write([]byte(`{"taskID":1,"objectIDs":["faro"]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var index = NewAlgoliaIndex(AlgoliaConfig{
+		AppId:   "app",
+		ApiKey:  clave,
+		Index:   "publicaciones",
+		Hosts:   []string{srv.Listener.Addr().String()},
+		Cliente: srv.Client(),
+	})
+	return index, func() []peticionAlgolia {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]peticionAlgolia{}, peticiones...)
+	}
+}
+
+// Devuelve la única operación de un lote, comprobando que va al índice configurado
+func operacionLote(t *testing.T, p peticionAlgolia) map[string]any {
+	t.Helper()
+	if p.Metodo != http.MethodPost || p.Ruta != "/1/indexes/publicaciones/batch" {
+		t.Fatalf("petición inesperada: %s %s", p.Metodo, p.Ruta)
+	}
+	requests, _ := p.Cuerpo["requests"].([]any)
+	if len(requests) != 1 {
+		t.Fatalf("se esperaba una operación, se recibió %v", p.Cuerpo)
+	}
+	operacion, _ := requests[0].(map[string]any)
+	return operacion
+}
+
+func TestAlgoliaGuardarEnviaDocumento(t *testing.T) {
+	index, peticiones := nuevoAlgoliaPrueba(t, "clave")
+
+	err := index.Guardar(Documento{Id: "faro", Titulo: "El faro", Contenido: "Texto", Fecha_publicacion: "2024-01-01 10:00:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ps = peticiones()
+	if len(ps) != 1 {
+		t.Fatalf("se esperaba una petición, se recibieron %d", len(ps))
+	}
+	var operacion = operacionLote(t, ps[0])
+	cuerpo, _ := operacion["body"].(map[string]any)
+	if operacion["action"] != "addObject" || cuerpo["objectID"] != "faro" || cuerpo["titulo"] != "El faro" || cuerpo["contenido"] != "Texto" {
+		t.Fatalf("operación inesperada: %v", operacion)
+	}
+}
+
+func TestAlgoliaEliminarEnviaId(t *testing.T) {
+	index, peticiones := nuevoAlgoliaPrueba(t, "clave")
+
+	if err := index.Eliminar("faro"); err != nil {
+		t.Fatal(err)
+	}
+
+	var ps = peticiones()
+	if len(ps) != 1 {
+		t.Fatalf("se esperaba una petición, se recibieron %d", len(ps))
+	}
+	var operacion = operacionLote(t, ps[0])
+	cuerpo, _ := operacion["body"].(map[string]any)
+	if operacion["action"] != "deleteObject" || cuerpo["objectID"] != "faro" {
+		t.Fatalf("operación inesperada: %v", operacion)
+	}
+}
+
+func TestAlgoliaBuscarLeeResultados(t *testing.T) {
+	index, peticiones := nuevoAlgoliaPrueba(t, "clave")
+
+	documentos, err := index.Buscar("faro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documentos) != 1 || documentos[0].Id != "faro" || documentos[0].Autor != "Ana" {
+		t.Fatalf("resultados inesperados: %+v", documentos)
+	}
+	if ps := peticiones(); len(ps) != 1 || ps[0].Ruta != "/1/indexes/publicaciones/query" {
+		t.Fatalf("petición inesperada: %+v", ps)
+	}
+}
+
+func TestAlgoliaDevuelveErrores(t *testing.T) {
+	index, _ := nuevoAlgoliaPrueba(t, "mala")
+
+	if err := index.Guardar(Documento{Id: "faro"}); err == nil {
+		t.Fatal("un error de Algolia se debe devolver para reintentar la tarea")
+	}
+}
@@ -0,0 +1,144 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"vigo360.es/new/internal/repository"
+)
+
+/*
+Índice en memoria, sin dependencias externas. Los resultados son deterministas: se ordenan por relevancia,
+después por fecha de publicación (más reciente primero) y por último por id.
+*/
+type MemoriaIndex struct {
+	mu         sync.RWMutex
+	documentos map[string]Documento
+}
+
+func NewMemoriaIndex(documentos ...Documento) *MemoriaIndex {
+	var m = &MemoriaIndex{
+		documentos: make(map[string]Documento),
+	}
+	m.Guardar(documentos...)
+	return m
+}
+
+/*
+Crea un índice en memoria con las publicaciones públicas de la base de datos. El índice se pierde al reiniciar y cada
+instancia tiene el suyo, así que solo sirve para desarrollo y pruebas.
+*/
+func NewMemoriaIndexDesde(pstore repository.PublicacionStore) (*MemoriaIndex, error) {
+	var m = NewMemoriaIndex()
+	publicaciones, err := pstore.Listar()
+	if err != nil {
+		return m, err
+	}
+
+	for _, p := range publicaciones.FiltrarPublicas().FiltrarRetiradas() {
+		// Listar no incluye el contenido
+		completa, err := pstore.ObtenerPorId(p.Id, false)
+		if err != nil {
+			return m, err
+		}
+		m.Guardar(NewDocumento(completa))
+	}
+	return m, nil
+}
+
+func (m *MemoriaIndex) Buscar(termino string) ([]Documento, error) {
+	var terminos = tokenizar(termino)
+	if len(terminos) == 0 {
+		return []Documento{}, nil
+	}
+
+	type resultado struct {
+		documento  Documento
+		puntuacion int
+	}
+
+	m.mu.RLock()
+	var resultados = make([]resultado, 0)
+	for _, d := range m.documentos {
+		if d.Fecha_publicacion == "" {
+			continue
+		}
+		if p := puntuar(d, terminos); p > 0 {
+			resultados = append(resultados, resultado{d, p})
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(resultados, func(i, j int) bool {
+		a, b := resultados[i], resultados[j]
+		if a.puntuacion != b.puntuacion {
+			return a.puntuacion > b.puntuacion
+		}
+		if a.documento.Fecha_publicacion != b.documento.Fecha_publicacion {
+			return a.documento.Fecha_publicacion > b.documento.Fecha_publicacion
+		}
+		return a.documento.Id < b.documento.Id
+	})
+
+	var documentos = make([]Documento, 0, len(resultados))
+	for _, r := range resultados {
+		documentos = append(documentos, r.documento)
+	}
+	return documentos, nil
+}
+
+func (m *MemoriaIndex) Guardar(documentos ...Documento) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range documentos {
+		m.documentos[d.Id] = d
+	}
+	return nil
+}
+
+func (m *MemoriaIndex) Eliminar(ids ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		delete(m.documentos, id)
+	}
+	return nil
+}
+
+// Un documento solo coincide si contiene todos los términos. Las coincidencias en el título pesan más
+func puntuar(d Documento, terminos []string) int {
+	var campos = []struct {
+		palabras []string
+		peso     int
+	}{
+		{tokenizar(d.Titulo), 4},
+		{tokenizar(d.Resumen), 2},
+		{tokenizar(d.Alt_portada + " " + d.Autor), 1},
+		{tokenizar(d.Contenido), 1},
+	}
+
+	var total = 0
+	for _, t := range terminos {
+		var puntuacionTermino = 0
+		for _, c := range campos {
+			for _, palabra := range c.palabras {
+				if strings.HasPrefix(palabra, t) {
+					puntuacionTermino += c.peso
+				}
+			}
+		}
+		if puntuacionTermino == 0 {
+			return 0
+		}
+		total += puntuacionTermino
+	}
+	return total
+}
+
+func tokenizar(texto string) []string {
+	return strings.FieldsFunc(strings.ToLower(texto), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"database/sql"
+	"testing"
+
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+func ids(documentos []Documento) []string {
+	var r = make([]string, 0, len(documentos))
+	for _, d := range documentos {
+		r = append(r, d.Id)
+	}
+	return r
+}
+
+func TestMemoriaOrdenaPorRelevanciaYFecha(t *testing.T) {
+	var m = NewMemoriaIndex(
+		Documento{Id: "contenido", Titulo: "Otra cosa", Contenido: "El puerto de Vigo", Fecha_publicacion: "2024-01-03 10:00:00"},
+		Documento{Id: "titulo", Titulo: "Vigo y su puerto", Fecha_publicacion: "2024-01-01 10:00:00"},
+		Documento{Id: "reciente", Titulo: "Otra cosa", Contenido: "Vigo tiene puerto", Fecha_publicacion: "2024-01-04 10:00:00"},
+		Documento{Id: "borrador", Titulo: "Puerto de Vigo"},
+		Documento{Id: "solo-uno", Titulo: "Vigo", Fecha_publicacion: "2024-01-05 10:00:00"},
+	)
+
+	resultados, err := m.Buscar("PUERTO vigo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var esperados = []string{"titulo", "reciente", "contenido"}
+	if got := ids(resultados); len(got) != len(esperados) || got[0] != esperados[0] || got[1] != esperados[1] || got[2] != esperados[2] {
+		t.Fatalf("se esperaba %v, se obtuvo %v", esperados, got)
+	}
+
+	m.Eliminar("titulo")
+	if resultados, _ := m.Buscar("puerto"); len(resultados) != 2 {
+		t.Fatalf("se esperaban 2 resultados tras eliminar uno, se obtuvo %v", ids(resultados))
+	}
+}
+
+type memPublicacionStore struct {
+	repository.PublicacionStore
+	publicaciones map[string]models.Publicacion
+}
+
+func (s *memPublicacionStore) Listar() (models.Publicaciones, error) {
+	var ps models.Publicaciones
+	for _, p := range s.publicaciones {
+		p.Contenido = ""
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+func (s *memPublicacionStore) ObtenerPorId(id string, _ bool) (models.Publicacion, error) {
+	p, ok := s.publicaciones[id]
+	if !ok {
+		return models.Publicacion{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func TestMemoriaDesdeCargaSoloPublicas(t *testing.T) {
+	var pstore = &memPublicacionStore{publicaciones: map[string]models.Publicacion{
+		"publica":    {Id: "publica", Titulo: "Pública", Contenido: "faro", Fecha_publicacion: "2024-01-01 10:00:00"},
+		"programada": {Id: "programada", Titulo: "Programada", Contenido: "faro", Fecha_publicacion: "2999-01-01 10:00:00"},
+		"retirada":   {Id: "retirada", Titulo: "Retirada", Contenido: "faro", Fecha_publicacion: "2024-01-01 10:00:00", Legally_retired_at: "2024-02-01 10:00:00"},
+		"borrador":   {Id: "borrador", Titulo: "Borrador", Contenido: "faro"},
+	}}
+
+	m, err := NewMemoriaIndexDesde(pstore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// El contenido viene de ObtenerPorId, porque Listar no lo incluye
+	resultados, _ := m.Buscar("faro")
+	if got := ids(resultados); len(got) != 1 || got[0] != "publica" {
+		t.Fatalf("se esperaba solo la publicación pública, se obtuvo %v", got)
+	}
+}
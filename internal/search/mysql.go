@@ -0,0 +1,39 @@
+package search
+
+import (
+	"vigo360.es/new/internal/repository"
+)
+
+// Índice que busca directamente sobre el índice FULLTEXT de la tabla de publicaciones
+type MysqlIndex struct {
+	pstore repository.PublicacionStore
+}
+
+func NewMysqlIndex(pstore repository.PublicacionStore) *MysqlIndex {
+	return &MysqlIndex{
+		pstore: pstore,
+	}
+}
+
+func (m *MysqlIndex) Buscar(termino string) ([]Documento, error) {
+	publicaciones, err := m.pstore.Buscar(termino)
+	if err != nil {
+		return []Documento{}, err
+	}
+
+	var documentos = make([]Documento, 0, len(publicaciones))
+	for _, p := range publicaciones.FiltrarPublicas().FiltrarRetiradas() {
+		documentos = append(documentos, NewDocumento(p))
+	}
+	return documentos, nil
+}
+
+// La tabla de publicaciones ya es el índice, no hay nada que guardar
+func (m *MysqlIndex) Guardar(documentos ...Documento) error {
+	return nil
+}
+
+// La tabla de publicaciones ya es el índice, no hay nada que eliminar
+func (m *MysqlIndex) Eliminar(ids ...string) error {
+	return nil
+}
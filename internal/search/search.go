@@ -0,0 +1,86 @@
+package search
+
+import (
+	"os"
+	"strings"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+// Publicación tal y como se guarda en un índice de búsqueda
+type Documento struct {
+	Id                  string `json:"id"`
+	Alt_portada         string `json:"alt_portada"`
+	Titulo              string `json:"titulo"`
+	Resumen             string `json:"resumen"`
+	Contenido           string `json:"contenido"`
+	Fecha_publicacion   string `json:"fecha_publicacion"`
+	Fecha_actualizacion string `json:"fecha_actualizacion"`
+	Autor               string `json:"autor"`
+}
+
+type SearchIndex interface {
+	// Devuelve las publicaciones que coinciden con el término, de más a menos relevante
+	Buscar(termino string) ([]Documento, error)
+	// Añade o actualiza publicaciones en el índice
+	Guardar(documentos ...Documento) error
+	// Elimina publicaciones del índice
+	Eliminar(ids ...string) error
+}
+
+// Longitud máxima del contenido que se guarda en el índice
+const LongitudMaximaContenido = 8500
+
+func NewDocumento(p models.Publicacion) Documento {
+	var contenido = []rune(p.Contenido)
+	if len(contenido) > LongitudMaximaContenido {
+		contenido = contenido[:LongitudMaximaContenido]
+	}
+
+	return Documento{
+		Id:                  p.Id,
+		Alt_portada:         p.Alt_portada,
+		Titulo:              p.Titulo,
+		Resumen:             p.Resumen,
+		Contenido:           string(contenido),
+		Fecha_publicacion:   p.Fecha_publicacion,
+		Fecha_actualizacion: p.Fecha_actualizacion,
+		Autor:               p.Autor.Nombre,
+	}
+}
+
+/*
+Crea el índice configurado en SEARCH_BACKEND: "algolia", "mysql" o "memoria". Si no se especifica, se usa Algolia
+cuando hay credenciales configuradas y el índice FULLTEXT de MySQL en otro caso.
+*/
+func NewFromEnv(pstore repository.PublicacionStore) SearchIndex {
+	var backend = os.Getenv("SEARCH_BACKEND")
+	if backend == "" && os.Getenv("ALGOLIA_APPLICATION") != "" {
+		backend = "algolia"
+	}
+
+	switch backend {
+	case "algolia":
+		var hosts []string
+		if val := os.Getenv("ALGOLIA_HOSTS"); val != "" {
+			hosts = strings.Split(val, ",")
+		}
+		return NewAlgoliaIndex(AlgoliaConfig{
+			AppId:  os.Getenv("ALGOLIA_APPLICATION"),
+			ApiKey: os.Getenv("ALGOLIA_API_KEY"),
+			Index:  os.Getenv("ALGOLIA_INDEX"),
+			Hosts:  hosts,
+		})
+	case "memoria":
+		m, err := NewMemoriaIndexDesde(pstore)
+		if err != nil {
+			log := logger.NewLogger("busqueda")
+			log.Warning("error cargando publicaciones en el índice en memoria, se añadirán al modificarlas: %s", err.Error())
+		}
+		return m
+	default:
+		return NewMysqlIndex(pstore)
+	}
+}
@@ -1,12 +1,14 @@
 package seo
 
 import (
-	"fmt"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
+
+	"vigo360.es/new/internal/logger"
 )
 
 type indexnowRequestBody struct {
@@ -28,10 +30,31 @@ func BingIndexnowRequest(urls []string) error {
 	var requestBody = bytes.NewBuffer(requestBytes)
 
 	response, err := client.Post("https://www.bing.com/indexnow", "application/json", requestBody)
-	if err != nil || response.StatusCode != 200 {
+	if err != nil {
 		return err
-	} else {
-		fmt.Println("enviado a indexnow: " + requestBody.String())
 	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 && response.StatusCode != 202 {
+		return fmt.Errorf("indexnow respondió con estado %d", response.StatusCode)
+	}
+
+	log := logger.NewLogger("indexnow")
+	log.Information("enviado a indexnow: %s", requestBytes)
 	return nil
 }
+
+// Devuelve las URLs a notificar cuando se publica una publicación: la portada, la propia publicación y sus tags
+func PublicacionUrls(publicacion_id string, tags []string) []string {
+	var DOMAIN = os.Getenv("DOMAIN")
+	var urls = []string{
+		DOMAIN + "/",
+		DOMAIN + "/post/" + publicacion_id,
+	}
+
+	for _, t := range tags {
+		urls = append(urls, DOMAIN+"/tags/"+t)
+	}
+
+	return urls
+}
@@ -7,11 +7,18 @@ import (
 type Server struct {
 	Router *mux.Router
 	store  *Container
+
+	programador chan struct{}
+	tareas      chan struct{}
+	registro    *registroPeticiones
 }
 
 func NewServer(c *Container) *Server {
 	s := &Server{
-		store: c,
+		store:       c,
+		programador: make(chan struct{}, 1),
+		tareas:      make(chan struct{}, 1),
+		registro:    nuevoRegistroPeticiones(c.registro),
 	}
 
 	var router = mux.NewRouter().StrictSlash(true)
@@ -23,6 +30,7 @@ func NewServer(c *Container) *Server {
 	router = s.IdentifySessions(router)
 	router = s.LogRequests(router)
 	router = s.SetupSecurityHeaders(router)
+	router = s.VerifyCsrf(router)
 	s.Router = router
 	return s
 }
@@ -2,14 +2,21 @@ package internal
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
-	"vigo360.es/new/internal/database"
+	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/messages"
+	"vigo360.es/new/internal/metricas"
+	"vigo360.es/new/internal/models"
 
 	"github.com/gorilla/mux"
 	"github.com/kataras/hcaptcha"
@@ -21,11 +28,16 @@ func (s *Server) JsonifyRoutes(router *mux.Router, path string) *mux.Router {
 	newrouter.Use(func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			var isJsonRoute = strings.HasPrefix(r.URL.Path, path)
-			if isJsonRoute {
-				w.Header().Add("Content-Type", "application/json; charset=utf-8")
+			if !isJsonRoute {
+				h.ServeHTTP(w, r)
+				return
 			}
-			h.ServeHTTP(w, r)
-			if isJsonRoute {
+
+			w.Header().Add("Content-Type", "application/json; charset=utf-8")
+			var ew = &estadoResponseWriter{ResponseWriter: w, r: r}
+			h.ServeHTTP(ew, r)
+			// Las respuestas 304 y 204 no pueden llevar cuerpo
+			if ew.estado != http.StatusNotModified && ew.estado != http.StatusNoContent {
 				fmt.Fprintf(w, "\n")
 			}
 		})
@@ -41,7 +53,6 @@ func (s *Server) IdentifySessions(router *mux.Router) *mux.Router {
 			var sidCookie, err = r.Cookie("sid")
 			if sidCookie == nil || err != nil {
 				sid = randstr.String(15)
-				println("sid: " + sid)
 				http.SetCookie(w, &http.Cookie{
 					Name:     "sid",
 					Value:    sid,
@@ -51,10 +62,10 @@ func (s *Server) IdentifySessions(router *mux.Router) *mux.Router {
 					SameSite: http.SameSiteStrictMode,
 				})
 			} else {
-				println("Reusing sid: " + sidCookie.Value)
 				sid = sidCookie.Value
 			}
 
+			logger.Anotar(r.Context(), "sid", sid)
 			newContext := context.WithValue(r.Context(), ridContextKey("sid"), sid)
 			r = r.WithContext(newContext)
 			next.ServeHTTP(w, r)
@@ -68,7 +79,9 @@ func (s *Server) IdentifyRequests(router *mux.Router) *mux.Router {
 	newrouter.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			var rid = randstr.String(15)
-			newContext := context.WithValue(r.Context(), ridContextKey("rid"), rid)
+			var ruta, _ = mux.CurrentRoute(r).GetPathTemplate()
+			newContext := logger.ConCampos(r.Context(), "rid", rid, "ruta", ruta)
+			newContext = context.WithValue(newContext, ridContextKey("rid"), rid)
 			r = r.WithContext(newContext)
 			w.Header().Add("vigo360-rid", rid)
 			next.ServeHTTP(w, r)
@@ -77,30 +90,181 @@ func (s *Server) IdentifyRequests(router *mux.Router) *mux.Router {
 	return newrouter
 }
 
+type eventoContextKey string
+
+// Anota un evento destacado, como un bloqueo de inicio de sesión, para guardarlo con el registro de la petición
+func anotarEvento(r *http.Request, evento string) {
+	if e, ok := r.Context().Value(eventoContextKey("evento")).(*string); ok {
+		*e = evento
+	}
+}
+
+// ResponseWriter que recuerda el código de estado, para el registro de la petición y los campos del log
+type estadoResponseWriter struct {
+	http.ResponseWriter
+	r      *http.Request
+	estado int
+}
+
+func (w *estadoResponseWriter) WriteHeader(estado int) {
+	if w.estado == 0 {
+		w.estado = estado
+		logger.Anotar(w.r.Context(), "estado", estado)
+	}
+	w.ResponseWriter.WriteHeader(estado)
+}
+
+func (w *estadoResponseWriter) Write(b []byte) (int, error) {
+	if w.estado == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *estadoResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (s *Server) LogRequests(router *mux.Router) *mux.Router {
 	var newrouter = router
 	newrouter.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			var startTime = time.Now()
+			var evento string
+			r = r.WithContext(context.WithValue(r.Context(), eventoContextKey("evento"), &evento))
+			var ew = &estadoResponseWriter{ResponseWriter: w, r: r}
+
+			next.ServeHTTP(ew, r)
+			if ew.estado == 0 {
+				ew.estado = http.StatusOK
+				logger.Anotar(r.Context(), "estado", http.StatusOK)
+			}
 
-			next.ServeHTTP(w, r)
-
-			var rid = r.Context().Value(ridContextKey("rid")).(string)
-			var sid = r.Context().Value(ridContextKey("sid")).(string)
 			var duration = time.Since(startTime).Milliseconds()
-			var ip = r.Header.Get("X-Forwarded-For")
-			var method = r.Method
-			var path = r.URL.Path
-			var ua = r.Header.Get("User-Agent")
+			log := logger.DesdeContexto(r.Context())
+			log.Debug("%s %s atendida en %dms", r.Method, r.URL.Path, duration)
+
+			var ruta, _ = mux.CurrentRoute(r).GetPathTemplate()
+			metricas.PeticionesHttp.Incrementar(ruta, r.Method, strconv.Itoa(ew.estado))
+			metricas.DuracionHttp.Observar(time.Since(startTime).Seconds(), ruta, r.Method)
+
+			if s.registro == nil || !s.registro.guardar(r.URL.Path, evento) {
+				return
+			}
+			s.registro.registrar(models.EntradaRegistro{
+				Rid:         r.Context().Value(ridContextKey("rid")).(string),
+				Sid:         r.Context().Value(ridContextKey("sid")).(string),
+				Fecha:       startTime,
+				Ip:          ipCliente(r),
+				Url:         r.URL.Path,
+				Ruta:        ruta,
+				Metodo:      r.Method,
+				Duracion_ms: duration,
+				User_agent:  r.Header.Get("User-Agent"),
+				Referer:     r.Header.Get("Referer"),
+				Evento:      evento,
+				Bot:         models.EsBot(r.Header.Get("User-Agent")),
+			})
+		})
+	})
+	return newrouter
+}
+
+// Rutas de /admin que aceptan formularios sin sesión, y por tanto sin token CSRF
+var rutasSinCsrf = []string{"/admin/login", "/admin/restablecer"}
+
+// Tamaño máximo del cuerpo de una ruta y el error que se muestra si se pasa
+type limiteCuerpo struct {
+	bytes int64
+	error messages.ErrorMessage
+}
+
+/*
+Rutas que limitan el tamaño del cuerpo. VerifyCsrf lee el formulario para buscar el token antes que el handler, así que
+el límite se pone ahí para que no se llegue a leer entero un cuerpo demasiado grande.
+*/
+var limitesCuerpo = map[string]limiteCuerpo{
+	"/admin/markdown/importar": {bytes: maxCuerpoImportacion, error: errorImportacionGrande},
+}
 
-			var db = database.GetDB()
+// Token CSRF de una sesión. Se deriva del token de la cookie, que es secreto, así que no hace falta guardarlo
+func tokenCsrf(sessid string) string {
+	var mac = hmac.New(sha256.New, []byte(sessid))
+	mac.Write([]byte("csrf"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ResponseWriter que pasa el token CSRF a templates.Render. Unwrap permite a http.ResponseController llegar al original
+type csrfResponseWriter struct {
+	http.ResponseWriter
+	token string
+}
+
+func (w *csrfResponseWriter) TokenCsrf() string {
+	return w.token
+}
+
+func (w *csrfResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
 
-			var query = `INSERT INTO log (rid, sid, time, ip ,url, method, time_taken_ms, user_agent) VALUES (?,?, ?, ?, ?, ?, ?, ?)`
+/*
+VerifyCsrf exige el token CSRF de la sesión en todas las peticiones a /admin que no sean de lectura, ya sea en el campo
+csrf del formulario o en la cabecera X-CSRF-Token para las llamadas desde JavaScript. Las peticiones sin cookie de
+sesión no se comprueban, porque o no necesitan sesión o el propio handler las rechaza.
+*/
+func (s *Server) VerifyCsrf(router *mux.Router) *mux.Router {
+	var newrouter = router
+	newrouter.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sc, err := r.Cookie("sess")
+			if !strings.HasPrefix(r.URL.Path, "/admin/") || err != nil || sc.Value == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-			_, err := db.Exec(query, rid, sid, startTime, ip, path, method, duration, ua)
-			if err != nil {
-				fmt.Println(err)
+			var esperado = tokenCsrf(sc.Value)
+			w = &csrfResponseWriter{ResponseWriter: w, token: esperado}
+
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, ruta := range rutasSinCsrf {
+				if r.URL.Path == ruta || strings.HasPrefix(r.URL.Path, ruta+"/") {
+					next.ServeHTTP(w, r)
+					return
+				}
 			}
+
+			if limite, ok := limitesCuerpo[r.URL.Path]; ok {
+				r.Body = http.MaxBytesReader(w, r.Body, limite.bytes)
+				var demasiado *http.MaxBytesError
+				if err := r.ParseMultipartForm(memoriaFormulario); errors.As(err, &demasiado) {
+					logger := logger.DesdeContexto(r.Context())
+					logger.Warning("cuerpo de más de %d bytes en %s", limite.bytes, r.URL.Path)
+					s.handleError(r, w, 413, limite.error)
+					return
+				}
+			}
+
+			var enviado = r.Header.Get("X-CSRF-Token")
+			if enviado == "" {
+				enviado = r.PostFormValue("csrf")
+			}
+			if !hmac.Equal([]byte(enviado), []byte(esperado)) {
+				logger := logger.DesdeContexto(r.Context())
+				logger.Warning("token CSRF incorrecto o ausente en %s %s", r.Method, r.URL.Path)
+				anotarEvento(r, "csrf_rechazado")
+				if strings.HasPrefix(r.URL.Path, "/admin/async") {
+					s.handleJsonError(r, w, 403, messages.ErrorCsrf)
+				} else {
+					s.handleError(r, w, 403, messages.ErrorCsrf)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
 		})
 	})
 	return newrouter
@@ -128,50 +292,116 @@ func (s *Server) SetupWebRoutes(router *mux.Router) *mux.Router {
 	newrouter.Handle("/admin/", http.RedirectHandler("/admin/login", http.StatusFound)).Methods(http.MethodGet)
 	newrouter.HandleFunc("/admin/login", s.handle_login_page("")).Methods(http.MethodGet)
 	newrouter.HandleFunc("/admin/login", s.handle_login_action()).Methods(http.MethodPost)
-	newrouter.HandleFunc("/admin/logout", s.withAuth(s.handleAdminLogoutAction())).Methods(http.MethodGet)
-
-	newrouter.HandleFunc("/admin/comentarios", s.withAuth(s.handleAdminListComentarios())).Methods(http.MethodGet)
-	newrouter.HandleFunc("/admin/comentarios/aprobar", s.withAuth(s.handleAdminAprobarComentario())).Methods(http.MethodGet)
-	newrouter.HandleFunc("/admin/comentarios/rechazar", s.withAuth(s.handleAdminRechazarComentario())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/login/2fa", s.handleAdminLogin2fa()).Methods(http.MethodGet, http.MethodPost)
+	newrouter.HandleFunc("/admin/logout", s.withAuth(s.handleAdminLogoutAction())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/restablecer", s.handleAdminRestablecerSolicitudPage()).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/restablecer", s.handleAdminRestablecerSolicitudAction()).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/restablecer/{token}", s.handleAdminRestablecerPage()).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/restablecer/{token}", s.handleAdminRestablecerAction()).Methods(http.MethodPost)
+
+	newrouter.HandleFunc("/admin/comentarios", s.withPermission("comentarios_moderar", s.handleAdminListComentarios())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/comentarios", s.withPermission("comentarios_moderar", s.handleAdminModerarComentarios())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/comentarios/{id}", s.withPermission("comentarios_moderar", s.handleAdminEditComentarioPage())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/comentarios/{id}", s.withPermission("comentarios_moderar", s.handleAdminEditComentarioAction())).Methods(http.MethodPost)
 
 	newrouter.HandleFunc("/admin/dashboard", s.withAuth(s.handleAdminDashboardPage())).Methods(http.MethodGet)
 
-	newrouter.HandleFunc("/admin/post", s.withAuth(s.handleAdminListPost())).Methods(http.MethodGet)
-	newrouter.HandleFunc("/admin/post", s.withAuth(s.handleAdminCreatePost())).Methods(http.MethodPost)
-	newrouter.HandleFunc("/admin/post/{id}", s.withAuth(s.handleAdminEditPostPage())).Methods(http.MethodGet)
-	newrouter.HandleFunc("/admin/post/{id}", s.withAuth(s.handleAdminEditPostAction())).Methods(http.MethodPost)
-	newrouter.HandleFunc("/admin/post/{postid}/delete", s.withAuth(s.handleAdminDeletePost())).Methods(http.MethodGet)
-
-	newrouter.HandleFunc("/admin/works", s.withAuth(s.handleAdminListWorks())).Methods(http.MethodGet)
-	newrouter.HandleFunc("/admin/works", s.withAuth(s.handleAdminCreateWork())).Methods(http.MethodPost)
-	newrouter.HandleFunc("/admin/works/{id}", s.withAuth(s.handleAdminEditWorkPage())).Methods(http.MethodGet)
-	newrouter.HandleFunc("/admin/works/{id}", s.withAuth(s.handleAdminEditWorkAction())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/post", s.withPermission("publicaciones_editar", s.handleAdminListPost())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/post", s.withPermission("publicaciones_editar", s.handleAdminCreatePost())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/markdown/exportar", s.withPermission("publicaciones_editar", s.handleAdminExportarMarkdown())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/markdown/importar", s.withPermission("publicaciones_editar", s.handleAdminImportarMarkdown())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/post/{id}", s.withPermission("publicaciones_editar", s.handleAdminEditPostPage())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/post/{id}", s.withPermission("publicaciones_editar", s.handleAdminEditPostAction())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/post/{postid}/delete", s.withPermission("publicaciones_delete", s.handleAdminDeletePost())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/post/{id}/revisiones", s.withPermission("publicaciones_editar", s.handleAdminListRevisiones(models.RevisionPublicacion))).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/post/{id}/revisiones/{revision}", s.withPermission("publicaciones_editar", s.handleAdminRestaurarRevision(models.RevisionPublicacion))).Methods(http.MethodPost)
+
+	newrouter.HandleFunc("/admin/works", s.withPermission("trabajos_editar", s.handleAdminListWorks())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/works", s.withPermission("trabajos_editar", s.handleAdminCreateWork())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/works/{id}", s.withPermission("trabajos_editar", s.handleAdminEditWorkPage())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/works/{id}", s.withPermission("trabajos_editar", s.handleAdminEditWorkAction())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/works/{trabajoid}/adjuntos/{archivo}", s.withPermission("trabajos_editar", s.handleAdminDescargarAdjunto())).Methods(http.MethodGet, http.MethodHead)
+	newrouter.HandleFunc("/admin/works/{id}/revisiones", s.withPermission("trabajos_editar", s.handleAdminListRevisiones(models.RevisionTrabajo))).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/works/{id}/revisiones/{revision}", s.withPermission("trabajos_editar", s.handleAdminRestaurarRevision(models.RevisionTrabajo))).Methods(http.MethodPost)
+
+	newrouter.HandleFunc("/admin/series", s.withPermission("series_editar", s.handleAdminListSeries())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/series", s.withPermission("series_editar", s.handleAdminCreateSerie())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/series/{id}", s.withPermission("series_editar", s.handleAdminEditSeriePage())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/series/{id}", s.withPermission("series_editar", s.handleAdminEditSerieAction())).Methods(http.MethodPost)
+
+	newrouter.HandleFunc("/admin/estadisticas", s.withPermission("estadisticas_ver", s.handleAdminEstadisticas())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/tareas", s.withPermission("tareas_gestionar", s.handleAdminListTareas())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/tareas/{id:[0-9]+}/reintentar", s.withPermission("tareas_gestionar", s.handleAdminReintentarTarea())).Methods(http.MethodPost)
+
+	newrouter.HandleFunc("/admin/roles", s.withPermission("permisos_gestionar", s.handleAdminListRoles())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/roles", s.withPermission("permisos_gestionar", s.handleAdminGuardarRol())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/roles/{id}/eliminar", s.withPermission("permisos_gestionar", s.handleAdminEliminarRol())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/autores", s.withPermission("autores_gestionar", s.handleAdminListAutores())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/autores", s.withPermission("autores_gestionar", s.handleAdminCrearAutor())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/autores/{id}/desactivar", s.withPermission("autores_gestionar", s.handleAdminCambiarActivoAutor(false))).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/autores/{id}/activar", s.withPermission("autores_gestionar", s.handleAdminCambiarActivoAutor(true))).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/autores/{id}/restablecer", s.withPermission("autores_gestionar", s.handleAdminEnviarRestablecimiento())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/autores/{id}/2fa/restablecer", s.withPermission("autores_gestionar", s.handleAdminRestablecerSegundoFactor())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/autores/{id}/roles", s.withPermission("permisos_gestionar", s.handleAdminAsignarRoles())).Methods(http.MethodPost)
 
 	newrouter.HandleFunc("/admin/perfil", s.withAuth(s.handleAdminPerfilView())).Methods(http.MethodGet)
 	newrouter.HandleFunc("/admin/perfil", s.withAuth(s.handleAdminPerfilEdit())).Methods(http.MethodPost)
-
-	newrouter.HandleFunc("/admin/preview", s.withAuth(s.handleAdminPreviewPage())).Methods(http.MethodPost)
-
-	newrouter.HandleFunc("/admin/async/fotosExtra", s.withAuth(s.handleAdminListarFotoExtra())).Methods(http.MethodGet)
-	newrouter.HandleFunc("/admin/async/fotosExtra", s.withAuth(s.handleAdminCrearFotoExtra())).Methods(http.MethodPost)
-	newrouter.HandleFunc("/admin/async/fotosExtra", s.withAuth(s.handleAdminDeleteFotoExtra())).Methods(http.MethodDelete)
-
-	newrouter.HandleFunc("/admin/async/attachments", s.withAuth(s.adminApiAttachmentList())).Methods(http.MethodGet)
-	newrouter.HandleFunc("/admin/async/attachments", s.withAuth(s.adminApiAttachmentCreate())).Methods(http.MethodPost)
-	newrouter.HandleFunc("/admin/async/attachments", s.withAuth(s.adminApiAttachmentDelete())).Methods(http.MethodDelete)
+	newrouter.HandleFunc("/admin/perfil/contrasena", s.withAuth(s.handleAdminCambiarContraseña())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/perfil/2fa", s.withAuth(s.handleAdminSegundoFactorView())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/perfil/2fa/alta", s.withAuth(s.handleAdminSegundoFactorAlta())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/perfil/2fa/confirmar", s.withAuth(s.handleAdminSegundoFactorConfirmar())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/perfil/2fa/codigos", s.withAuth(s.handleAdminSegundoFactorCodigos())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/perfil/2fa/desactivar", s.withAuth(s.handleAdminSegundoFactorDesactivar())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/perfil/tokens", s.withAuth(s.handleAdminTokensApiView())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/perfil/tokens", s.withAuth(s.handleAdminCrearTokenApi())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/perfil/tokens/{id:[0-9]+}/revocar", s.withAuth(s.handleAdminRevocarTokenApi())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/sesiones", s.withAuth(s.handleAdminListSesiones())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/sesiones/{id:[0-9]+}/revocar", s.withAuth(s.handleAdminRevocarSesion())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/sesiones/revocar-otras", s.withAuth(s.handleAdminRevocarOtrasSesiones())).Methods(http.MethodPost)
+
+	newrouter.HandleFunc("/admin/preview", s.withPermission("publicaciones_editar", s.handleAdminPreviewPage())).Methods(http.MethodPost)
+
+	newrouter.HandleFunc("/admin/async/media", s.withPermission("publicaciones_editar", s.handleAdminListarMedia())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/async/media", s.withPermission("publicaciones_editar", s.handleAdminCrearMedia())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/async/media/{id:[0-9]+}", s.withPermission("publicaciones_editar", s.handleAdminEditarMedia())).Methods(http.MethodPut)
+	newrouter.HandleFunc("/admin/async/media/{id:[0-9]+}", s.withPermission("publicaciones_editar", s.handleAdminDeleteMedia())).Methods(http.MethodDelete)
+
+	newrouter.HandleFunc("/admin/async/attachments", s.withPermission("trabajos_editar", s.adminApiAttachmentList())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/admin/async/attachments", s.withPermission("trabajos_editar", s.adminApiAttachmentCreate())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/admin/async/attachments", s.withPermission("trabajos_editar", s.adminApiAttachmentDelete())).Methods(http.MethodDelete)
+
+	newrouter.HandleFunc("/api/v1/publicaciones", s.handleApiListarPublicaciones()).Methods(http.MethodGet)
+	newrouter.HandleFunc("/api/v1/publicaciones/{id}", s.handleApiObtenerPublicacion()).Methods(http.MethodGet)
+	newrouter.HandleFunc("/api/v1/publicaciones/{id}/comentarios", s.handleApiListarComentarios()).Methods(http.MethodGet)
+	newrouter.HandleFunc("/api/v1/publicaciones", s.withAlcance(models.AlcanceEscribir, s.handleApiCrearPublicacion())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/api/v1/publicaciones/{id}", s.withAlcance(models.AlcanceEscribir, s.handleApiEditarPublicacion())).Methods(http.MethodPut)
+	newrouter.HandleFunc("/api/v1/publicaciones/{id}/edicion", s.withAlcance(models.AlcanceEscribir, s.handleApiObtenerEdicion())).Methods(http.MethodGet)
+	newrouter.HandleFunc("/api/v1/publicaciones/{id}/publicar", s.withAlcance(models.AlcancePublicar, s.handleApiPublicarPublicacion())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/api/v1/publicaciones/{id}/despublicar", s.withAlcance(models.AlcanceDespublicar, s.handleApiDespublicarPublicacion())).Methods(http.MethodPost)
+	newrouter.HandleFunc("/api/v1/publicaciones/{id}/portada", s.withAlcance(models.AlcancePortadas, s.handleApiSubirPortada())).Methods(http.MethodPut)
+	newrouter.HandleFunc("/api/v1/tags", s.handleApiListarTags()).Methods(http.MethodGet)
+	newrouter.HandleFunc("/api/v1/autores", s.handleApiListarAutores()).Methods(http.MethodGet)
+	newrouter.HandleFunc("/api/v1/trabajos", s.handleApiListarTrabajos()).Methods(http.MethodGet)
 
 	newrouter.HandleFunc(`/post/{postid}`, s.handlePublicPostPage()).Methods(http.MethodGet)
 
 	var secretKey = os.Getenv("HCAPTCHA_SECRET")
 
 	var cli = hcaptcha.New(secretKey)
+	cli.FailureHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metricas.Comentarios.Incrementar("captcha")
+		hcaptcha.DefaultFailureHandler.ServeHTTP(w, r)
+	})
 
 	newrouter.HandleFunc(`/post/{postid}`, cli.HandlerFunc(s.handlePublicEnviarComentario())).Methods(http.MethodPost)
 
 	newrouter.HandleFunc(`/tags`, s.handlePublicListTags()).Methods(http.MethodGet)
 	newrouter.HandleFunc(`/tags/{tagid}`, s.handlePublicTagPage()).Methods(http.MethodGet)
+	newrouter.HandleFunc(`/series`, s.handlePublicListSeries()).Methods(http.MethodGet)
+	newrouter.HandleFunc(`/series/{serieid}`, s.handlePublicSeriePage()).Methods(http.MethodGet)
 	newrouter.HandleFunc(`/trabajos`, s.handlePublicListTrabajos()).Methods(http.MethodGet)
 	newrouter.HandleFunc(`/trabajos/{trabajoid}`, s.handlePublicTrabajoPage()).Methods(http.MethodGet)
+	newrouter.HandleFunc(`/trabajos/{trabajoid}/adjuntos/{archivo}`, s.handlePublicDescargarAdjunto()).Methods(http.MethodGet, http.MethodHead)
 	newrouter.HandleFunc(`/autores/{id}`, s.handlePublicAutorPage()).Methods(http.MethodGet)
 	newrouter.HandleFunc(`/autores`, s.handlePublicListAutores()).Methods(http.MethodGet)
 
@@ -186,10 +416,19 @@ func (s *Server) SetupWebRoutes(router *mux.Router) *mux.Router {
 	var indexnowkeyurl = fmt.Sprintf("/%s.txt", os.Getenv("INDEXNOW_KEY"))
 	newrouter.HandleFunc(indexnowkeyurl, s.handlePublicIndexnowKey()).Methods(http.MethodGet)
 
-	newrouter.HandleFunc("/algolia.json", s.handlePublicIndexAlgolia()).Methods(http.MethodGet)
+	// Con METRICAS_DIRECCION las métricas se sirven aparte, desde main
+	if token := os.Getenv("METRICAS_TOKEN"); token != "" && os.Getenv("METRICAS_DIRECCION") == "" {
+		newrouter.HandleFunc("/metrics", s.handleMetricas(token)).Methods(http.MethodGet)
+	}
+
 	newrouter.HandleFunc("/", s.handlePublicIndex()).Methods(http.MethodGet)
 
 	newrouter.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			w.Header().Add("Content-Type", "application/json; charset=utf-8")
+			s.handleJsonError(r, w, http.StatusNotFound, messages.ErrorPaginaNoEncontrada)
+			return
+		}
 		s.handleError(r, w, http.StatusNotFound, messages.ErrorPaginaNoEncontrada)
 	})
 	return newrouter
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"vigo360.es/new/internal/templates"
+)
+
+// El token CSRF no debe ocultar las interfaces opcionales del ResponseWriter original, como http.Flusher
+func TestCsrfResponseWriterPermiteFlush(t *testing.T) {
+	var original = httptest.NewRecorder()
+	var w http.ResponseWriter = &csrfResponseWriter{ResponseWriter: original, token: "abc"}
+
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		t.Fatalf("no se pudo hacer Flush a través del token CSRF: %s", err)
+	}
+	if !original.Flushed {
+		t.Fatal("el Flush no llegó al ResponseWriter original")
+	}
+	if _, ok := w.(templates.ConTokenCsrf); !ok {
+		t.Fatal("templates.Render no encontraría el token")
+	}
+}
+
+func TestJsonifyRoutesSinCuerpoEn304(t *testing.T) {
+	var s = &Server{}
+	var router = mux.NewRouter()
+	router.HandleFunc("/api/v1/datos", func(w http.ResponseWriter, r *http.Request) {
+		escribirApi(w, r, map[string]string{"hola": "mundo"})
+	})
+	router.HandleFunc("/api/v1/vacio", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router = s.JsonifyRoutes(router, "/api/v1")
+
+	var w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/datos", nil))
+	var etag = w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.String() != "{\n\t\"hola\": \"mundo\"\n}\n" {
+		t.Fatalf("respuesta inesperada %d %q", w.Code, w.Body.String())
+	}
+
+	var r = httptest.NewRequest(http.MethodGet, "/api/v1/datos", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("se esperaba 304 sin cuerpo, se obtuvo %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/vacio", nil))
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("se esperaba 204 sin cuerpo, se obtuvo %d %q", w.Code, w.Body.String())
+	}
+}
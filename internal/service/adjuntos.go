@@ -0,0 +1,185 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/thanhpk/randstr"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+var Err_AdjuntoTrabajoInvalido = errors.New("el trabajo del adjunto no existe")
+var Err_AdjuntoTituloInvalido = errors.New("el título del adjunto no es válido")
+var Err_AdjuntoVacio = errors.New("el archivo adjunto está vacío")
+var Err_AdjuntoNoExiste = errors.New("el adjunto no existe")
+
+// Longitud máxima de la parte del nombre de archivo que se saca del nombre original
+const longitudSlugAdjunto = 40
+
+type Adjunto struct {
+	astore     repository.AdjuntoStore
+	tstore     repository.TrabajoStore
+	directorio string
+}
+
+// El directorio es donde se guardan los archivos, ADJUNTOS_PATH. Está fuera de UPLOAD_PATH para que nginx no los sirva
+// sin comprobar si el trabajo está publicado
+func NewAdjuntoService(astore repository.AdjuntoStore, tstore repository.TrabajoStore, directorio string) Adjunto {
+	return Adjunto{astore: astore, tstore: tstore, directorio: directorio}
+}
+
+/*
+Subir guarda un archivo como adjunto de un trabajo. Se escribe primero a un archivo temporal mientras se calculan el
+tamaño y la suma SHA-256, y solo se mueve a su nombre definitivo si todo ha ido bien.
+*/
+func (se *Adjunto) Subir(trabajo_id string, titulo string, nombreOriginal string, contenido io.Reader) (models.Adjunto, error) {
+	titulo = strings.TrimSpace(titulo)
+	if titulo == "" || utf8.RuneCountInString(titulo) > 80 {
+		return models.Adjunto{}, Err_AdjuntoTituloInvalido
+	}
+
+	if _, err := se.tstore.ObtenerPorId(trabajo_id, false); errors.Is(err, sql.ErrNoRows) {
+		return models.Adjunto{}, Err_AdjuntoTrabajoInvalido
+	} else if err != nil {
+		return models.Adjunto{}, err
+	}
+
+	tmp, err := os.CreateTemp(se.directorio, ".subida-*")
+	if err != nil {
+		return models.Adjunto{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// Los primeros bytes bastan para detectar el tipo MIME
+	var cabecera = make([]byte, 3072)
+	n, err := io.ReadFull(contenido, cabecera)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return models.Adjunto{}, err
+	}
+	if n == 0 {
+		return models.Adjunto{}, Err_AdjuntoVacio
+	}
+	cabecera = cabecera[:n]
+
+	var suma = sha256.New()
+	tamano, err := io.Copy(io.MultiWriter(tmp, suma), io.MultiReader(bytes.NewReader(cabecera), contenido))
+	if err != nil {
+		return models.Adjunto{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return models.Adjunto{}, err
+	}
+
+	var mime = mimetype.Detect(cabecera)
+	var adjunto = models.Adjunto{
+		Trabajo_id:     trabajo_id,
+		Nombre_archivo: NombreArchivoAdjunto(nombreOriginal, mime.Extension()),
+		Titulo:         titulo,
+		Bytes:          tamano,
+		Mime:           mime.String(),
+		Sha256:         hex.EncodeToString(suma.Sum(nil)),
+	}
+
+	var ruta = filepath.Join(se.directorio, adjunto.Nombre_archivo)
+	if err := os.Rename(tmp.Name(), ruta); err != nil {
+		return models.Adjunto{}, err
+	}
+	if err := os.Chmod(ruta, 0o600); err != nil {
+		return models.Adjunto{}, err
+	}
+
+	adjunto.Id, err = se.astore.Crear(adjunto)
+	if err != nil {
+		_ = os.Remove(ruta)
+		return models.Adjunto{}, err
+	}
+	return adjunto, nil
+}
+
+// Elimina un adjunto y su archivo
+func (se *Adjunto) Eliminar(id int) error {
+	adjunto, err := se.astore.Obtener(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Err_AdjuntoNoExiste
+	} else if err != nil {
+		return err
+	}
+
+	if err := se.astore.Eliminar(id); err != nil {
+		return err
+	}
+
+	err = os.Remove(filepath.Join(se.directorio, adjunto.Nombre_archivo))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("el adjunto se eliminó pero no su archivo: %w", err)
+	}
+	return nil
+}
+
+// Ruta en disco de un adjunto
+func (se *Adjunto) Ruta(a models.Adjunto) string {
+	return filepath.Join(se.directorio, a.Nombre_archivo)
+}
+
+/*
+NombreArchivoAdjunto genera un nombre de archivo seguro a partir del nombre original: sin tildes, en minúsculas,
+con guiones en lugar de cualquier otro carácter, recortado a 40 caracteres y con un sufijo aleatorio para que no
+choque con otros adjuntos. La extensión es la del nombre original si la tiene, o si no la del tipo detectado.
+*/
+func NombreArchivoAdjunto(original string, extensionDetectada string) string {
+	var extension = strings.ToLower(filepath.Ext(original))
+	var base = strings.TrimSuffix(filepath.Base(original), filepath.Ext(original))
+	if extension == "." || !esSlug(strings.TrimPrefix(extension, ".")) || len(extension) > 10 {
+		extension = extensionDetectada
+	}
+
+	var slug = slugificar(base)
+	if len(slug) > longitudSlugAdjunto {
+		slug = strings.TrimRight(slug[:longitudSlugAdjunto], "-")
+	}
+	if slug == "" {
+		slug = "adjunto"
+	}
+
+	return slug + "-" + strings.ToLower(randstr.String(5)) + extension
+}
+
+func slugificar(texto string) string {
+	sinTildes, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), texto)
+	if err != nil {
+		sinTildes = texto
+	}
+
+	var sb strings.Builder
+	var guion = false
+	for _, r := range strings.ToLower(sinTildes) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+			guion = false
+		} else if !guion && sb.Len() > 0 {
+			sb.WriteRune('-')
+			guion = true
+		}
+	}
+	return strings.TrimRight(sb.String(), "-")
+}
+
+func esSlug(texto string) bool {
+	return texto != "" && slugificar(texto) == texto && !strings.Contains(texto, "-")
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSlugificar(t *testing.T) {
+	var casos = map[string]string{
+		"":                          "",
+		"a":                         "a",
+		"Memoria":                   "memoria",
+		"Memoria final (v2)":        "memoria-final-v2",
+		"  --Guiones__y  espacios ": "guiones-y-espacios",
+		"Canción de Año Nuevo":      "cancion-de-ano-nuevo",
+		"Çà et là, über":            "ca-et-la-uber",
+		"日本語":                       "",
+		"Informe 日本 2024":           "informe-2024",
+	}
+	for entrada, esperado := range casos {
+		if obtenido := slugificar(entrada); obtenido != esperado {
+			t.Errorf("slugificar(%q) = %q, se esperaba %q", entrada, obtenido, esperado)
+		}
+	}
+}
+
+// Nombre generado: slug, guion, sufijo aleatorio de 5 caracteres y extensión
+var nombreAdjunto = regexp.MustCompile(`^([a-z0-9-]+)-[a-z0-9]{5}(\.[a-z0-9]*)?$`)
+
+func TestNombreArchivoAdjunto(t *testing.T) {
+	var casos = []struct {
+		original  string
+		detectada string
+		slug      string
+		extension string
+	}{
+		{"tfg.pdf", ".pdf", "tfg", ".pdf"},
+		{"x.PDF", ".pdf", "x", ".pdf"},
+		{"", ".pdf", "adjunto", ".pdf"},
+		{".pdf", ".pdf", "adjunto", ".pdf"},
+		{"Memoria Año 2024.docx", ".zip", "memoria-ano-2024", ".docx"},
+		{"日本語.pdf", ".pdf", "adjunto", ".pdf"},
+		// Sin extensión, o con una que no lo parece, se usa la del tipo detectado
+		{"memoria", ".pdf", "memoria", ".pdf"},
+		{"memoria.", ".pdf", "memoria", ".pdf"},
+		{"memoria.p df", ".pdf", "memoria", ".pdf"},
+		{"memoria.extensionlarga", ".pdf", "memoria", ".pdf"},
+		// Las rutas del nombre original no llegan al archivo
+		{"../../etc/passwd", "", "passwd", ""},
+	}
+	for _, c := range casos {
+		var nombre = NombreArchivoAdjunto(c.original, c.detectada)
+		var partes = nombreAdjunto.FindStringSubmatch(nombre)
+		if partes == nil {
+			t.Errorf("NombreArchivoAdjunto(%q) = %q, que no tiene el formato esperado", c.original, nombre)
+			continue
+		}
+		if partes[1] != c.slug || partes[2] != c.extension {
+			t.Errorf("NombreArchivoAdjunto(%q) = %q, se esperaba %q con extensión %q", c.original, nombre, c.slug, c.extension)
+		}
+	}
+}
+
+func TestNombreArchivoAdjuntoLargo(t *testing.T) {
+	var nombre = NombreArchivoAdjunto(strings.Repeat("á", 39)+" "+strings.Repeat("b", 30)+".pdf", ".pdf")
+	var partes = nombreAdjunto.FindStringSubmatch(nombre)
+	if partes == nil {
+		t.Fatalf("%q no tiene el formato esperado", nombre)
+	}
+	// Se recorta a 40 caracteres, y el corte cae justo después del guion, que no se deja al final
+	if partes[1] != strings.Repeat("a", 39) {
+		t.Errorf("slug recortado %q, se esperaban 39 aes", partes[1])
+	}
+
+	if a, b := NombreArchivoAdjunto("tfg.pdf", ".pdf"), NombreArchivoAdjunto("tfg.pdf", ".pdf"); a == b {
+		t.Errorf("dos adjuntos con el mismo nombre original no deberían chocar: %q", a)
+	}
+}
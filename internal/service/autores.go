@@ -0,0 +1,200 @@
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/thanhpk/randstr"
+	"golang.org/x/crypto/bcrypt"
+	"vigo360.es/new/internal/correo"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+var Err_AutorDatosInvalidos = errors.New("los datos del autor no son válidos")
+var Err_AutorDuplicado = errors.New("ya existe un autor con ese id o correo")
+var Err_AutorNoExiste = errors.New("el autor no existe")
+var Err_ContraseñaInvalida = errors.New("la contraseña debe tener entre 10 y 72 bytes")
+var Err_ContraseñaIncorrecta = errors.New("la contraseña actual no es correcta")
+var Err_TokenInvalido = errors.New("el enlace no es válido, ya se ha usado o ha caducado")
+
+const (
+	validezInvitacion  = 7 * 24 * time.Hour
+	validezRestablecer = time.Hour
+)
+
+var autorIdRegexp = regexp.MustCompile(`^[a-z0-9\-_]{3,40}$`)
+
+type Autor struct {
+	astore repository.AutorStore
+	sstore repository.SesionStore
+	correo correo.Correo
+}
+
+func NewAutorService(astore repository.AutorStore, sstore repository.SesionStore, correo correo.Correo) Autor {
+	return Autor{astore: astore, sstore: sstore, correo: correo}
+}
+
+/*
+Invitar crea un autor sin contraseña y le envía un enlace para elegirla. Hasta que lo use no puede iniciar sesión.
+*/
+func (se *Autor) Invitar(autor models.Autor) error {
+	type entrada struct {
+		Id     string `validate:"required"`
+		Nombre string `validate:"required,min=3,max=40"`
+		Email  string `validate:"required,email,max=150"`
+		Rol    string `validate:"required,max=40"`
+	}
+	autor.Id = strings.TrimSpace(autor.Id)
+	autor.Nombre = strings.TrimSpace(autor.Nombre)
+	autor.Email = strings.TrimSpace(autor.Email)
+	autor.Rol = strings.TrimSpace(autor.Rol)
+	if err := validator.New().Struct(entrada{autor.Id, autor.Nombre, autor.Email, autor.Rol}); err != nil || !autorIdRegexp.MatchString(autor.Id) {
+		return Err_AutorDatosInvalidos
+	}
+
+	if _, err := se.astore.Obtener(autor.Id); err == nil {
+		return Err_AutorDuplicado
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if _, err := se.astore.ObtenerPorEmail(autor.Email); err == nil {
+		return Err_AutorDuplicado
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if err := se.astore.Crear(autor); err != nil {
+		return err
+	}
+
+	return se.enviarToken(autor, models.TokenInvitacion, validezInvitacion, "Invitación al panel de Vigo360",
+		"Hola, %s:\n\nSe te ha invitado a publicar en Vigo360 con el usuario %s. Para elegir tu contraseña, abre este enlace en los próximos 7 días:\n\n%s\n")
+}
+
+/*
+SolicitarRestablecimiento envía un enlace para restablecer la contraseña al autor con ese correo. Si no existe o está
+desactivado no hace nada ni devuelve error, para no revelar qué correos están registrados.
+*/
+func (se *Autor) SolicitarRestablecimiento(email string) error {
+	autor, err := se.astore.ObtenerPorEmail(strings.TrimSpace(email))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !autor.Activo) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return se.enviarRestablecimiento(autor)
+}
+
+// Envía un enlace para restablecer la contraseña a un autor, por ejemplo a petición de un administrador
+func (se *Autor) EnviarRestablecimiento(autor_id string) error {
+	autor, err := se.astore.Obtener(autor_id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Err_AutorNoExiste
+	} else if err != nil {
+		return err
+	}
+	return se.enviarRestablecimiento(autor)
+}
+
+func (se *Autor) enviarRestablecimiento(autor models.Autor) error {
+	return se.enviarToken(autor, models.TokenRestablecer, validezRestablecer, "Restablecer tu contraseña de Vigo360",
+		"Hola, %s:\n\nSe ha pedido restablecer la contraseña del usuario %s. Si has sido tú, abre este enlace en la próxima hora:\n\n%s\n\nSi no, puedes ignorar este correo.\n")
+}
+
+func (se *Autor) enviarToken(autor models.Autor, tipo models.TipoTokenAutor, validez time.Duration, asunto string, plantilla string) error {
+	var token = randstr.String(32)
+	if err := se.astore.CrearToken(autor.Id, tipo, hashToken(token), validez); err != nil {
+		return err
+	}
+
+	var enlace = os.Getenv("DOMAIN") + "/admin/restablecer/" + token
+	return se.correo.Enviar(autor.Email, asunto, fmt.Sprintf(plantilla, autor.Nombre, autor.Id, enlace))
+}
+
+// Comprueba que un token se puede usar, sin consumirlo
+func (se *Autor) ComprobarToken(token string) (models.TokenAutor, error) {
+	t, err := se.astore.ObtenerToken(hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TokenAutor{}, Err_TokenInvalido
+	}
+	return t, err
+}
+
+// Restablecer usa un token para cambiar la contraseña, y revoca todas las sesiones del autor
+func (se *Autor) Restablecer(token string, nueva string) error {
+	if err := validarContraseña(nueva); err != nil {
+		return err
+	}
+
+	t, err := se.astore.ConsumirToken(hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Err_TokenInvalido
+	} else if err != nil {
+		return err
+	}
+
+	if err := se.guardarContraseña(t.Autor_id, nueva); err != nil {
+		return err
+	}
+	return se.sstore.RevocarTodas(t.Autor_id, "")
+}
+
+// CambiarContraseña comprueba la contraseña actual y revoca todas las sesiones salvo la actual
+func (se *Autor) CambiarContraseña(autor_id string, actual string, nueva string, sesionActual string) error {
+	hash, err := se.astore.ObtenerContraseña(autor_id)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(actual)) != nil {
+		return Err_ContraseñaIncorrecta
+	}
+
+	if err := validarContraseña(nueva); err != nil {
+		return err
+	}
+	if err := se.guardarContraseña(autor_id, nueva); err != nil {
+		return err
+	}
+	return se.sstore.RevocarTodas(autor_id, sesionActual)
+}
+
+// Activa o desactiva un autor. Desactivarlo revoca todas sus sesiones
+func (se *Autor) CambiarActivo(autor_id string, activo bool) error {
+	if _, err := se.astore.Obtener(autor_id); errors.Is(err, sql.ErrNoRows) {
+		return Err_AutorNoExiste
+	} else if err != nil {
+		return err
+	}
+	return se.astore.CambiarActivo(autor_id, activo)
+}
+
+func (se *Autor) guardarContraseña(autor_id string, contraseña string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(contraseña), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return se.astore.CambiarContraseña(autor_id, string(hash))
+}
+
+// bcrypt ignora lo que pase de 72 bytes, así que se rechaza en lugar de truncar en silencio
+func validarContraseña(contraseña string) error {
+	if utf8.RuneCountInString(contraseña) < 10 || len(contraseña) > 72 {
+		return Err_ContraseñaInvalida
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	var suma = sha256.Sum256([]byte(token))
+	return hex.EncodeToString(suma[:])
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"unicode/utf8"
@@ -13,6 +14,7 @@ var Err_ComentarioPublicacionInvalida = errors.New("el ID de la publicación no
 var Err_ComentarioNombreInvalido = errors.New("el nombre de autor del comentario no es válido")
 var Err_ComentarioContenidoInvalido = errors.New("el contenido del comentario no es válido")
 var Err_ComentarioErrorBaseDatos = errors.New("hubo un error guardando el comentario")
+var Err_ComentarioPadreInvalido = errors.New("el comentario al que se responde no es válido")
 
 func (se *Comentario) AgregarComentario(
 	publicacion_id string,
@@ -49,6 +51,19 @@ func (se *Comentario) AgregarRespuesta(
 		return models.Comentario{}, Err_ComentarioPublicacionInvalida
 	}
 
+	if padre != "" {
+		comentario_padre, err := se.cstore.Obtener(padre)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Comentario{}, Err_ComentarioPadreInvalido
+		}
+		if err != nil {
+			return models.Comentario{}, err
+		}
+		if comentario_padre.Publicacion_id != publicacion_id || comentario_padre.Estado != models.EstadoAprobado {
+			return models.Comentario{}, Err_ComentarioPadreInvalido
+		}
+	}
+
 	var nuevo_comentario = models.Comentario{
 		Id:             randstr.String(13),
 		Publicacion_id: publicacion_id,
@@ -68,8 +83,7 @@ func (se *Comentario) AgregarRespuesta(
 
 	dberr := se.cstore.GuardarComentario(nuevo_comentario)
 	if dberr != nil {
-		fmt.Printf("%s\n", dberr.Error())
-		return models.Comentario{}, Err_ComentarioErrorBaseDatos
+		return models.Comentario{}, fmt.Errorf("%w: %s", Err_ComentarioErrorBaseDatos, dberr.Error())
 	}
 	return nuevo_comentario, nil
 }
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+func (se *Comentario) Editar(comentario_id string, contenido string, moderador_id string) error {
+	contenido = strings.TrimSpace(contenido)
+	if contenido == "" || utf8.RuneCountInString(contenido) > 2000 {
+		return Err_ComentarioContenidoInvalido
+	}
+
+	return se.cstore.Editar(comentario_id, contenido, moderador_id)
+}
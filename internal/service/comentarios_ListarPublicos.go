@@ -1,25 +1,109 @@
 package service
 
 import (
+	"strings"
+
+	"vigo360.es/new/internal/logger"
 	"vigo360.es/new/internal/models"
 )
 
+// Cantidad de niveles de respuestas que se anidan. Las respuestas más profundas se muestran al mismo nivel que su padre.
+const ProfundidadMaximaComentarios = 4
+
 type ComentarioTree struct {
 	models.Comentario
+	Respuestas  []ComentarioTree
+	Profundidad int
+	// Nombre del autor al que responde, cuando no se muestra anidado bajo él por superar la profundidad máxima
+	Respondiendo_a string
+	// Indica que responde a un comentario que no es público (pendiente, rechazado o eliminado)
+	Huerfano bool
 }
 
 func (se *Comentario) ListarPublicos(articulo_id string) ([]ComentarioTree, error) {
-	// Obtiene de la base de datos
+	// Obtiene de la base de datos, ordenados por fecha de creación
 	comentariosLinear, err := se.cstore.ListarPublicos(articulo_id)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convierte models.Comentario a ComentarioTree (esencialmente iguales)
-	var clt = make([]ComentarioTree, 0)
-	for _, c := range comentariosLinear {
-		clt = append(clt, ComentarioTree{Comentario: c})
+	return construirArbol(comentariosLinear), nil
+}
+
+/*
+Convierte una lista de comentarios aprobados en un árbol de respuestas, manteniendo el orden original entre hermanos.
+Las respuestas a comentarios que no están en la lista se muestran en la raíz marcadas como huérfanas. Los comentarios
+que no cuelgan de ninguna raíz porque sus padres forman un ciclo se muestran también en la raíz, a partir del más
+antiguo, y se avisa en el log para que se corrija el padre.
+*/
+func construirArbol(comentarios []models.Comentario) []ComentarioTree {
+	var (
+		porId  = make(map[string]models.Comentario, len(comentarios))
+		hijos  = make(map[string][]models.Comentario)
+		raices = make([]models.Comentario, 0)
+	)
+
+	for _, c := range comentarios {
+		porId[c.Id] = c
+	}
+
+	for _, c := range comentarios {
+		if _, ok := porId[c.Padre_id]; c.Padre_id != "" && ok && c.Padre_id != c.Id {
+			hijos[c.Padre_id] = append(hijos[c.Padre_id], c)
+		} else {
+			raices = append(raices, c)
+		}
+	}
+
+	var visitados = make(map[string]bool, len(comentarios))
+
+	// Devuelve el nodo y, si supera la profundidad máxima, sus descendientes como hermanos a continuación
+	var construir func(c models.Comentario, profundidad int) []ComentarioTree
+	construir = func(c models.Comentario, profundidad int) []ComentarioTree {
+		visitados[c.Id] = true
+		var nodo = ComentarioTree{
+			Comentario:  c,
+			Respuestas:  make([]ComentarioTree, 0),
+			Profundidad: profundidad,
+		}
+
+		var hermanos = make([]ComentarioTree, 0)
+		for _, h := range hijos[c.Id] {
+			if visitados[h.Id] {
+				continue
+			}
+
+			if profundidad+1 < ProfundidadMaximaComentarios {
+				nodo.Respuestas = append(nodo.Respuestas, construir(h, profundidad+1)...)
+			} else {
+				aplanados := construir(h, profundidad)
+				aplanados[0].Respondiendo_a = c.Nombre
+				hermanos = append(hermanos, aplanados...)
+			}
+		}
+
+		return append([]ComentarioTree{nodo}, hermanos...)
+	}
+
+	var arbol = make([]ComentarioTree, 0, len(raices))
+	for _, c := range raices {
+		nodos := construir(c, 0)
+		nodos[0].Huerfano = c.Padre_id != ""
+		arbol = append(arbol, nodos...)
+	}
+
+	var enCiclo = make([]string, 0)
+	for _, c := range comentarios {
+		if visitados[c.Id] {
+			continue
+		}
+		enCiclo = append(enCiclo, c.Id)
+		arbol = append(arbol, construir(c, 0)...)
+	}
+	if len(enCiclo) > 0 {
+		log := logger.NewLogger("comentarios")
+		log.Warning("comentarios con un ciclo en sus padres, mostrados en la raíz: %s", strings.Join(enCiclo, ", "))
 	}
 
-	return clt, nil
+	return arbol
 }
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"vigo360.es/new/internal/models"
+)
+
+// Ids del árbol en el orden en que se muestran, con la profundidad de cada uno
+func recorrer(arbol []ComentarioTree, profundidad int, ids map[string]int) {
+	for _, n := range arbol {
+		ids[n.Id] = profundidad
+		recorrer(n.Respuestas, profundidad+1, ids)
+	}
+}
+
+func TestConstruirArbolConCiclo(t *testing.T) {
+	var comentarios = []models.Comentario{
+		{Id: "1"},
+		{Id: "2", Padre_id: "1"},
+		// 3 y 4 se responden entre sí, así que ninguno cuelga de una raíz
+		{Id: "3", Padre_id: "4"},
+		{Id: "4", Padre_id: "3"},
+		{Id: "5", Padre_id: "4"},
+	}
+
+	var arbol = construirArbol(comentarios)
+	var ids = make(map[string]int)
+	recorrer(arbol, 0, ids)
+
+	if len(ids) != len(comentarios) {
+		t.Fatalf("se muestran %d comentarios de %d: %v", len(ids), len(comentarios), ids)
+	}
+	if len(arbol) != 2 || arbol[0].Id != "1" || arbol[1].Id != "3" {
+		t.Fatalf("raíces inesperadas: %+v", arbol)
+	}
+	if ids["4"] != 1 || ids["5"] != 2 {
+		t.Errorf("el ciclo no se anida a partir del más antiguo: %v", ids)
+	}
+}
+
+func TestConstruirArbolHuerfanos(t *testing.T) {
+	var arbol = construirArbol([]models.Comentario{
+		{Id: "1", Padre_id: "borrado"},
+		{Id: "2", Padre_id: "1"},
+	})
+
+	if len(arbol) != 1 || !arbol[0].Huerfano || len(arbol[0].Respuestas) != 1 {
+		t.Fatalf("árbol inesperado: %+v", arbol)
+	}
+}
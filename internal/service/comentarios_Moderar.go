@@ -0,0 +1,32 @@
+package service
+
+import (
+	"errors"
+
+	"vigo360.es/new/internal/models"
+)
+
+var Err_ModeracionAccionInvalida = errors.New("la acción de moderación no es válida")
+var Err_ModeracionSinComentarios = errors.New("no se ha seleccionado ningún comentario")
+
+func (se *Comentario) Moderar(comentario_ids []string, accion models.AccionModeracion, moderador_id string) error {
+	switch accion {
+	case models.AccionAprobar, models.AccionRechazar, models.AccionSpam, models.AccionRevertir:
+	default:
+		return Err_ModeracionAccionInvalida
+	}
+
+	if len(comentario_ids) == 0 {
+		return Err_ModeracionSinComentarios
+	}
+
+	return se.cstore.Moderar(comentario_ids, accion, moderador_id)
+}
+
+func (se *Comentario) Aprobar(comentario_id string, moderador_id string) error {
+	return se.Moderar([]string{comentario_id}, models.AccionAprobar, moderador_id)
+}
+
+func (se *Comentario) Rechazar(comentario_id string, moderador_id string) error {
+	return se.Moderar([]string{comentario_id}, models.AccionRechazar, moderador_id)
+}
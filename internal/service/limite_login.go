@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+var Err_LimiteLoginOcupado = errors.New("demasiados intentos simultáneos para la misma cuenta o IP")
+
+// Política de intentos para un tipo de clave
+type politicaLogin struct {
+	// Intentos que se admiten sin espera
+	libres int
+	// A partir de estos intentos la clave queda bloqueada durante la duración del bloqueo
+	bloqueo  int
+	duracion time.Duration
+	// Si pasa este tiempo sin intentos, la cuenta vuelve a empezar
+	ventana time.Duration
+}
+
+/*
+Una IP puede ser compartida por toda una redacción, así que admite más intentos que una cuenta antes de hacer esperar
+o bloquear.
+*/
+var (
+	politicaCuenta = politicaLogin{libres: 3, bloqueo: 10, duracion: 15 * time.Minute, ventana: time.Hour}
+	politicaIp     = politicaLogin{libres: 10, bloqueo: 50, duracion: time.Hour, ventana: time.Hour}
+)
+
+// Espera que impone haber acumulado n intentos: ninguna al principio, luego el doble cada vez, y al final el bloqueo
+func (p politicaLogin) espera(n int) time.Duration {
+	if n <= p.libres {
+		return 0
+	}
+	if n >= p.bloqueo {
+		return p.duracion
+	}
+	var espera = time.Second << (n - p.libres - 1)
+	if espera > p.duracion {
+		return p.duracion
+	}
+	return espera
+}
+
+// Resultado de pedir permiso para un intento de inicio de sesión
+type IntentoLogin struct {
+	// Tiempo que falta para poder intentarlo. Si es mayor que cero, el intento no se ha permitido ni contado
+	Espera time.Duration
+	// El intento ha dejado bloqueada la cuenta o la IP durante la duración del bloqueo
+	Bloqueo bool
+}
+
+/*
+LimiteLogin cuenta los intentos de inicio de sesión por cuenta y por IP. Cada intento se cuenta antes de comprobar la
+contraseña, para que varias peticiones simultáneas no puedan colarse todas a la vez, y se descuenta si sale bien.
+*/
+type LimiteLogin struct {
+	store repository.LimiteLoginStore
+}
+
+func NewLimiteLoginService(store repository.LimiteLoginStore) LimiteLogin {
+	return LimiteLogin{store: store}
+}
+
+func claveCuenta(autor_id string) string {
+	return "autor:" + strings.ToLower(strings.TrimSpace(autor_id))
+}
+
+func claveIp(ip string) string {
+	return "ip:" + ip
+}
+
+// Intentar cuenta un intento para la cuenta y la IP, salvo que alguna tenga que esperar todavía
+func (se *LimiteLogin) Intentar(autor_id string, ip string) (IntentoLogin, error) {
+	var ahora = time.Now()
+	var claves = []string{claveCuenta(autor_id), claveIp(ip)}
+	var politicas = []politicaLogin{politicaCuenta, politicaIp}
+
+	var resultado IntentoLogin
+	for _, clave := range claves {
+		actual, err := se.store.Obtener(clave)
+		if err != nil {
+			return IntentoLogin{}, err
+		}
+		if espera := actual.Bloqueado_hasta.Sub(ahora); espera > resultado.Espera {
+			resultado.Espera = espera
+		}
+	}
+	if resultado.Espera > 0 {
+		return resultado, nil
+	}
+
+	for i, clave := range claves {
+		intento, err := se.contar(clave, politicas[i], ahora)
+		if err != nil {
+			return IntentoLogin{}, err
+		}
+		if intento.Espera > 0 {
+			return intento, nil
+		}
+		resultado.Bloqueo = resultado.Bloqueo || intento.Bloqueo
+	}
+	return resultado, nil
+}
+
+// Suma un intento a la clave. Si otra petición la cambia a la vez vuelve a leerla, y puede que ya esté bloqueada
+func (se *LimiteLogin) contar(clave string, p politicaLogin, ahora time.Time) (IntentoLogin, error) {
+	for reintento := 0; reintento < 5; reintento++ {
+		actual, err := se.store.Obtener(clave)
+		if err != nil {
+			return IntentoLogin{}, err
+		}
+		if espera := actual.Bloqueado_hasta.Sub(ahora); espera > 0 {
+			return IntentoLogin{Espera: espera}, nil
+		}
+
+		var nuevo = models.IntentosLogin{Intentos: actual.Intentos + 1, Ultimo: ahora}
+		if ahora.Sub(actual.Ultimo) > p.ventana {
+			nuevo.Intentos = 1
+		}
+		if espera := p.espera(nuevo.Intentos); espera > 0 {
+			nuevo.Bloqueado_hasta = ahora.Add(espera)
+		}
+
+		ok, err := se.store.Actualizar(clave, actual, nuevo)
+		if err != nil {
+			return IntentoLogin{}, err
+		}
+		if ok {
+			return IntentoLogin{Bloqueo: nuevo.Intentos >= p.bloqueo}, nil
+		}
+	}
+	return IntentoLogin{}, Err_LimiteLoginOcupado
+}
+
+// Exito olvida los intentos de la cuenta y descuenta el de la IP, que solo debe acumular fallos
+func (se *LimiteLogin) Exito(autor_id string, ip string) error {
+	var ahora = time.Now()
+	if err := se.store.Limpiar(claveCuenta(autor_id), ahora.Add(-24*time.Hour)); err != nil {
+		return err
+	}
+
+	var clave = claveIp(ip)
+	for reintento := 0; reintento < 5; reintento++ {
+		actual, err := se.store.Obtener(clave)
+		if err != nil || actual.Intentos == 0 {
+			return err
+		}
+
+		var nuevo = models.IntentosLogin{Intentos: actual.Intentos - 1, Ultimo: actual.Ultimo}
+		if espera := politicaIp.espera(nuevo.Intentos); espera > 0 {
+			nuevo.Bloqueado_hasta = actual.Ultimo.Add(espera)
+		}
+		if ok, err := se.store.Actualizar(clave, actual, nuevo); err != nil || ok {
+			return err
+		}
+	}
+	return Err_LimiteLoginOcupado
+}
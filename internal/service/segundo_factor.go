@@ -0,0 +1,195 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/thanhpk/randstr"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+	"vigo360.es/new/internal/totp"
+)
+
+var Err_CodigoIncorrecto = errors.New("el código no es correcto o ya se ha usado")
+var Err_SegundoFactorExigido = errors.New("alguno de los roles del autor exige la verificación en dos pasos")
+var Err_SegundoFactorInactivo = errors.New("el autor no tiene la verificación en dos pasos activada")
+var Err_SinAltaPendiente = errors.New("no hay ningún alta de verificación en dos pasos en curso")
+var Err_LoginPendienteInvalido = errors.New("el inicio de sesión ha caducado o ha superado el número de intentos")
+
+const (
+	// Nombre con el que aparece la cuenta en la aplicación de autenticación
+	emisorTotp       = "Vigo360"
+	codigosRecuperar = 10
+	validezLogin     = 10 * time.Minute
+	intentosLogin    = 5
+)
+
+type SegundoFactor struct {
+	store repository.SegundoFactorStore
+}
+
+func NewSegundoFactorService(store repository.SegundoFactorStore) SegundoFactor {
+	return SegundoFactor{store: store}
+}
+
+// Estado de la verificación en dos pasos del autor y si alguno de sus roles la exige
+func (se *SegundoFactor) Estado(autor_id string) (models.SegundoFactor, bool, error) {
+	sf, err := se.store.Obtener(autor_id)
+	if err != nil {
+		return models.SegundoFactor{}, false, err
+	}
+	exigido, err := se.store.Exigido(autor_id)
+	return sf, exigido, err
+}
+
+// Indica si el autor debe pasar por el segundo paso al iniciar sesión, porque lo tiene activado o porque se le exige
+func (se *SegundoFactor) Necesario(autor_id string) (bool, error) {
+	sf, exigido, err := se.Estado(autor_id)
+	return sf.Activo() || exigido, err
+}
+
+/*
+IniciarAlta genera un secreto nuevo sin confirmar y devuelve la URI otpauth:// para la aplicación. Si ya había un alta
+en curso reutiliza su secreto, para que recargar la página no invalide lo que el autor ya haya escaneado.
+*/
+func (se *SegundoFactor) IniciarAlta(autor_id string) (secreto string, uri string, err error) {
+	sf, err := se.store.Obtener(autor_id)
+	if err != nil {
+		return "", "", err
+	}
+
+	secreto = sf.Pendiente
+	if secreto == "" {
+		if secreto, err = totp.GenerarSecreto(); err != nil {
+			return "", "", err
+		}
+		if err := se.store.GuardarPendiente(autor_id, secreto); err != nil {
+			return "", "", err
+		}
+	}
+	return secreto, totp.Uri(emisorTotp, autor_id, secreto), nil
+}
+
+// ConfirmarAlta activa el secreto pendiente si el código es correcto, y devuelve los códigos de recuperación
+func (se *SegundoFactor) ConfirmarAlta(autor_id string, codigo string) ([]string, error) {
+	sf, err := se.store.Obtener(autor_id)
+	if err != nil {
+		return nil, err
+	}
+	if sf.Pendiente == "" {
+		return nil, Err_SinAltaPendiente
+	}
+
+	paso, ok := totp.Validar(sf.Pendiente, codigo, time.Now())
+	if !ok {
+		return nil, Err_CodigoIncorrecto
+	}
+
+	codigos, hashes := generarCodigosRecuperacion()
+	if err := se.store.Activar(autor_id, paso, hashes); errors.Is(err, sql.ErrNoRows) {
+		return nil, Err_SinAltaPendiente
+	} else if err != nil {
+		return nil, err
+	}
+	return codigos, nil
+}
+
+// Verificar acepta un código de la aplicación o uno de recuperación, que queda usado
+func (se *SegundoFactor) Verificar(autor_id string, codigo string) error {
+	sf, err := se.store.Obtener(autor_id)
+	if err != nil {
+		return err
+	}
+	if !sf.Activo() {
+		return Err_SegundoFactorInactivo
+	}
+
+	codigo = strings.TrimSpace(codigo)
+	if paso, ok := totp.Validar(sf.Secreto, codigo, time.Now()); ok {
+		if nuevo, err := se.store.RegistrarPaso(autor_id, paso); err != nil {
+			return err
+		} else if !nuevo {
+			return Err_CodigoIncorrecto
+		}
+		return nil
+	}
+
+	err = se.store.ConsumirCodigo(autor_id, hashToken(normalizarCodigoRecuperacion(codigo)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Err_CodigoIncorrecto
+	}
+	return err
+}
+
+// Desactivar quita la verificación en dos pasos tras comprobar un código, salvo que algún rol del autor la exija
+func (se *SegundoFactor) Desactivar(autor_id string, codigo string) error {
+	if exigido, err := se.store.Exigido(autor_id); err != nil {
+		return err
+	} else if exigido {
+		return Err_SegundoFactorExigido
+	}
+	if err := se.Verificar(autor_id, codigo); err != nil {
+		return err
+	}
+	return se.store.Desactivar(autor_id)
+}
+
+// Quita la verificación en dos pasos de otro autor, por ejemplo si ha perdido el dispositivo
+func (se *SegundoFactor) Restablecer(autor_id string) error {
+	if _, err := se.store.Obtener(autor_id); errors.Is(err, sql.ErrNoRows) {
+		return Err_AutorNoExiste
+	} else if err != nil {
+		return err
+	}
+	return se.store.Desactivar(autor_id)
+}
+
+// RegenerarCodigos sustituye los códigos de recuperación tras comprobar un código
+func (se *SegundoFactor) RegenerarCodigos(autor_id string, codigo string) ([]string, error) {
+	if err := se.Verificar(autor_id, codigo); err != nil {
+		return nil, err
+	}
+	codigos, hashes := generarCodigosRecuperacion()
+	return codigos, se.store.ReemplazarCodigos(autor_id, hashes)
+}
+
+// IniciarLogin guarda que el autor ya ha comprobado su contraseña y devuelve el token para el segundo paso
+func (se *SegundoFactor) IniciarLogin(autor_id string) (string, error) {
+	var token = randstr.String(32)
+	return token, se.store.CrearLogin(hashToken(token), autor_id, validezLogin)
+}
+
+// Obtiene un inicio de sesión pendiente que aún admite intentos
+func (se *SegundoFactor) ObtenerLogin(token string) (models.LoginPendiente, error) {
+	login, err := se.store.ObtenerLogin(hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && login.Intentos >= intentosLogin) {
+		return models.LoginPendiente{}, Err_LoginPendienteInvalido
+	}
+	return login, err
+}
+
+// Apunta un intento fallido del segundo paso
+func (se *SegundoFactor) FallarLogin(token string) error {
+	return se.store.SumarIntento(hashToken(token))
+}
+
+// Elimina el inicio de sesión pendiente una vez completado
+func (se *SegundoFactor) CompletarLogin(token string) error {
+	return se.store.EliminarLogin(hashToken(token))
+}
+
+// Genera códigos de recuperación con forma xxxxx-xxxxx, y sus hashes para guardarlos
+func generarCodigosRecuperacion() (codigos []string, hashes []string) {
+	for i := 0; i < codigosRecuperar; i++ {
+		var codigo = randstr.Hex(10)
+		codigos = append(codigos, codigo[:5]+"-"+codigo[5:])
+		hashes = append(hashes, hashToken(codigo))
+	}
+	return codigos, hashes
+}
+
+func normalizarCodigoRecuperacion(codigo string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(codigo))
+}
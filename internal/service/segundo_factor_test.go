@@ -0,0 +1,208 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/totp"
+)
+
+// SegundoFactorStore en memoria con las mismas condiciones que las consultas de MySQL
+type memSegundoFactorStore struct {
+	estados map[string]models.SegundoFactor
+	// Hash de cada código de recuperación y si ya se ha usado
+	codigos map[string]map[string]bool
+	logins  map[string]models.LoginPendiente
+}
+
+func nuevoMemSegundoFactorStore() *memSegundoFactorStore {
+	return &memSegundoFactorStore{
+		estados: map[string]models.SegundoFactor{},
+		codigos: map[string]map[string]bool{},
+		logins:  map[string]models.LoginPendiente{},
+	}
+}
+
+func (s *memSegundoFactorStore) Obtener(autor_id string) (models.SegundoFactor, error) {
+	var sf = s.estados[autor_id]
+	sf.Autor_id = autor_id
+	for _, usado := range s.codigos[autor_id] {
+		if !usado {
+			sf.Codigos_restantes++
+		}
+	}
+	return sf, nil
+}
+
+func (s *memSegundoFactorStore) Exigido(autor_id string) (bool, error) {
+	return false, nil
+}
+
+func (s *memSegundoFactorStore) GuardarPendiente(autor_id string, secreto string) error {
+	var sf = s.estados[autor_id]
+	sf.Pendiente = secreto
+	s.estados[autor_id] = sf
+	return nil
+}
+
+func (s *memSegundoFactorStore) Activar(autor_id string, paso int64, codigos_hash []string) error {
+	var sf = s.estados[autor_id]
+	if sf.Pendiente == "" {
+		return sql.ErrNoRows
+	}
+	s.estados[autor_id] = models.SegundoFactor{Secreto: sf.Pendiente, Ultimo_paso: paso}
+	return s.ReemplazarCodigos(autor_id, codigos_hash)
+}
+
+func (s *memSegundoFactorStore) Desactivar(autor_id string) error {
+	delete(s.estados, autor_id)
+	delete(s.codigos, autor_id)
+	return nil
+}
+
+func (s *memSegundoFactorStore) RegistrarPaso(autor_id string, paso int64) (bool, error) {
+	var sf = s.estados[autor_id]
+	if sf.Ultimo_paso >= paso {
+		return false, nil
+	}
+	sf.Ultimo_paso = paso
+	s.estados[autor_id] = sf
+	return true, nil
+}
+
+func (s *memSegundoFactorStore) ReemplazarCodigos(autor_id string, codigos_hash []string) error {
+	s.codigos[autor_id] = map[string]bool{}
+	for _, h := range codigos_hash {
+		s.codigos[autor_id][h] = false
+	}
+	return nil
+}
+
+func (s *memSegundoFactorStore) ConsumirCodigo(autor_id string, codigo_hash string) error {
+	if usado, existe := s.codigos[autor_id][codigo_hash]; !existe || usado {
+		return sql.ErrNoRows
+	}
+	s.codigos[autor_id][codigo_hash] = true
+	return nil
+}
+
+func (s *memSegundoFactorStore) CrearLogin(hash string, autor_id string, validez time.Duration) error {
+	s.logins[hash] = models.LoginPendiente{Autor_id: autor_id}
+	return nil
+}
+
+func (s *memSegundoFactorStore) ObtenerLogin(hash string) (models.LoginPendiente, error) {
+	login, ok := s.logins[hash]
+	if !ok {
+		return models.LoginPendiente{}, sql.ErrNoRows
+	}
+	return login, nil
+}
+
+func (s *memSegundoFactorStore) SumarIntento(hash string) error {
+	var login = s.logins[hash]
+	login.Intentos++
+	s.logins[hash] = login
+	return nil
+}
+
+func (s *memSegundoFactorStore) EliminarLogin(hash string) error {
+	delete(s.logins, hash)
+	return nil
+}
+
+// Da de alta la verificación de ana con el código actual y devuelve el secreto y los códigos de recuperación
+func altaSegundoFactor(t *testing.T, sf *SegundoFactor) (string, []string) {
+	t.Helper()
+	secreto, _, err := sf.IniciarAlta("ana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	codigo, _ := totp.Codigo(secreto, totp.Paso(time.Now()))
+	codigos, err := sf.ConfirmarAlta("ana", codigo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secreto, codigos
+}
+
+func TestVerificarRechazaPasoUsado(t *testing.T) {
+	var store = nuevoMemSegundoFactorStore()
+	var sf = NewSegundoFactorService(store)
+	secreto, _ := altaSegundoFactor(t, &sf)
+
+	// El código con el que se confirmó el alta ya no vale para entrar
+	var paso = store.estados["ana"].Ultimo_paso
+	codigo, _ := totp.Codigo(secreto, paso)
+	if err := sf.Verificar("ana", codigo); !errors.Is(err, Err_CodigoIncorrecto) {
+		t.Fatalf("se esperaba rechazar el paso del alta, se obtuvo %v", err)
+	}
+
+	// El siguiente paso se acepta una vez, y después ni ese ni los anteriores
+	store.estados["ana"] = models.SegundoFactor{Secreto: secreto, Ultimo_paso: paso - 1}
+	codigo, _ = totp.Codigo(secreto, totp.Paso(time.Now()))
+	if err := sf.Verificar("ana", codigo); err != nil {
+		t.Fatalf("el código actual debería aceptarse: %v", err)
+	}
+	if err := sf.Verificar("ana", codigo); !errors.Is(err, Err_CodigoIncorrecto) {
+		t.Fatalf("un código repetido debería rechazarse, se obtuvo %v", err)
+	}
+	anterior, _ := totp.Codigo(secreto, totp.Paso(time.Now())-1)
+	if err := sf.Verificar("ana", anterior); !errors.Is(err, Err_CodigoIncorrecto) {
+		t.Fatalf("un código anterior al último usado debería rechazarse, se obtuvo %v", err)
+	}
+}
+
+func TestCodigosRecuperacionUnSoloUso(t *testing.T) {
+	var store = nuevoMemSegundoFactorStore()
+	var sf = NewSegundoFactorService(store)
+	_, codigos := altaSegundoFactor(t, &sf)
+	if len(codigos) != codigosRecuperar {
+		t.Fatalf("se esperaban %d códigos de recuperación, hay %d", codigosRecuperar, len(codigos))
+	}
+
+	if err := sf.Verificar("ana", codigos[0]); err != nil {
+		t.Fatalf("el código de recuperación debería aceptarse: %v", err)
+	}
+	if err := sf.Verificar("ana", codigos[0]); !errors.Is(err, Err_CodigoIncorrecto) {
+		t.Fatalf("un código de recuperación usado debería rechazarse, se obtuvo %v", err)
+	}
+
+	// Se acepta escrito en mayúsculas, sin guion o con espacios alrededor
+	var otro = " " + strings.ToUpper(strings.ReplaceAll(codigos[1], "-", "")) + " "
+	if err := sf.Verificar("ana", otro); err != nil {
+		t.Fatalf("el código %q debería aceptarse: %v", otro, err)
+	}
+
+	if estado, _, _ := sf.Estado("ana"); estado.Codigos_restantes != codigosRecuperar-2 {
+		t.Errorf("quedan %d códigos, se esperaban %d", estado.Codigos_restantes, codigosRecuperar-2)
+	}
+}
+
+func TestLoginPendienteSeBloqueaTrasCincoIntentos(t *testing.T) {
+	var sf = NewSegundoFactorService(nuevoMemSegundoFactorStore())
+	token, err := sf.IniciarLogin("ana")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < intentosLogin; i++ {
+		if login, err := sf.ObtenerLogin(token); err != nil || login.Autor_id != "ana" {
+			t.Fatalf("tras %d fallos el inicio de sesión debería seguir pendiente: %v", i, err)
+		}
+		if err := sf.FallarLogin(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := sf.ObtenerLogin(token); !errors.Is(err, Err_LoginPendienteInvalido) {
+		t.Fatalf("tras %d fallos se esperaba %v, se obtuvo %v", intentosLogin, Err_LoginPendienteInvalido, err)
+	}
+	if _, err := sf.ObtenerLogin("otro-token"); !errors.Is(err, Err_LoginPendienteInvalido) {
+		t.Fatalf("un token desconocido debería rechazarse, se obtuvo %v", err)
+	}
+}
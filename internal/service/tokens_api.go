@@ -0,0 +1,97 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/thanhpk/randstr"
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+)
+
+var Err_TokenApiDatosInvalidos = errors.New("el nombre o los alcances del token no son válidos")
+var Err_TokenApiSinPermiso = errors.New("el autor no tiene permiso para alguno de los alcances")
+var Err_TokenApiInvalido = errors.New("el token no existe, se ha revocado o ha caducado")
+
+// Todos los tokens empiezan igual, para distinguirlos de un token de sesión y encontrarlos si se filtran
+const PrefijoTokenApi = "v360_"
+
+type TokenApi struct {
+	store repository.TokenApiStore
+}
+
+func NewTokenApiService(store repository.TokenApiStore) TokenApi {
+	return TokenApi{store: store}
+}
+
+/*
+Crear genera un token nuevo y lo devuelve. Es la única vez que se puede ver, porque solo se guarda su hash. Los
+alcances tienen que estar permitidos por los permisos actuales del autor; si luego los pierde, el token deja de
+servir para ellos aunque los siga teniendo.
+*/
+func (se *TokenApi) Crear(autor_id string, nombre string, alcances []string, validez time.Duration, permisos map[string]bool) (string, error) {
+	nombre = strings.TrimSpace(nombre)
+	if utf8.RuneCountInString(nombre) < 3 || utf8.RuneCountInString(nombre) > 80 || len(alcances) == 0 {
+		return "", Err_TokenApiDatosInvalidos
+	}
+
+	var validos []string
+	for _, conocido := range models.AlcancesApi {
+		for _, a := range alcances {
+			if models.AlcanceApi(a) != conocido {
+				continue
+			}
+			if !permisos[conocido.Permiso()] {
+				return "", Err_TokenApiSinPermiso
+			}
+			validos = append(validos, string(conocido))
+			break
+		}
+	}
+	if len(validos) != len(alcances) {
+		return "", Err_TokenApiDatosInvalidos
+	}
+
+	var token = PrefijoTokenApi + randstr.Hex(40)
+	err := se.store.Crear(models.TokenApi{
+		Autor_id: autor_id,
+		Nombre:   nombre,
+		Prefijo:  token[:len(PrefijoTokenApi)+6],
+		Alcances: strings.Join(validos, ","),
+	}, hashToken(token), validez)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (se *TokenApi) Listar(autor_id string) ([]models.TokenApi, error) {
+	return se.store.Listar(autor_id)
+}
+
+// Autenticar devuelve el token si es válido y de un autor activo
+func (se *TokenApi) Autenticar(token string) (models.TokenApi, error) {
+	if !strings.HasPrefix(token, PrefijoTokenApi) {
+		return models.TokenApi{}, Err_TokenApiInvalido
+	}
+
+	t, err := se.store.ObtenerPorHash(hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TokenApi{}, Err_TokenApiInvalido
+	} else if err != nil {
+		return models.TokenApi{}, err
+	}
+
+	return t, nil
+}
+
+func (se *TokenApi) Revocar(id int, autor_id string) error {
+	err := se.store.Revocar(id, autor_id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Err_TokenApiInvalido
+	}
+	return err
+}
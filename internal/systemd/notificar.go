@@ -0,0 +1,42 @@
+/*
+Package systemd avisa a systemd del estado del servicio con el protocolo de sd_notify, para poder usar Type=notify en
+la unidad sin depender de libsystemd.
+*/
+package systemd
+
+import (
+	"net"
+	"os"
+)
+
+const (
+	// El servidor ya acepta conexiones
+	Listo = "READY=1"
+	// El servidor ha empezado a apagarse
+	Deteniendo = "STOPPING=1"
+)
+
+/*
+Notificar envía el estado al socket de NOTIFY_SOCKET. Si la variable no existe, porque no se ejecuta desde systemd o la
+unidad no es Type=notify, no hace nada y devuelve false. Un @ al principio indica un socket abstracto.
+*/
+func Notificar(estado string) (bool, error) {
+	var socket = os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(estado)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"vigo360.es/new/internal/search"
+)
+
+// Las tareas de este tipo las crean triggers en la tabla de publicaciones
+const tareaBusqueda = "busqueda"
+
+type cargaBusqueda struct {
+	Publicacion_id string `json:"publicacion_id"`
+}
+
+// Sube la publicación al índice si es pública, o la elimina si no existe, no se ha publicado o se ha retirado
+func (s *Server) tareaBusqueda(carga []byte) error {
+	var c cargaBusqueda
+	if err := json.Unmarshal(carga, &c); err != nil {
+		return fmt.Errorf("%w: %s", errTareaDefinitiva, err.Error())
+	}
+
+	publicacion, err := s.store.publicacion.ObtenerPorId(c.Publicacion_id, false)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.store.busqueda.Eliminar(c.Publicacion_id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !publicacion.EstaPublicada() || publicacion.Legally_retired_at != "" {
+		return s.store.busqueda.Eliminar(c.Publicacion_id)
+	}
+
+	return s.store.busqueda.Guardar(search.NewDocumento(publicacion))
+}
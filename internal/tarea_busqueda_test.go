@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"vigo360.es/new/internal/models"
+	"vigo360.es/new/internal/repository"
+	"vigo360.es/new/internal/search"
+)
+
+type memPublicacionStore struct {
+	repository.PublicacionStore
+	publicaciones map[string]models.Publicacion
+}
+
+func (s *memPublicacionStore) ObtenerPorId(id string, _ bool) (models.Publicacion, error) {
+	p, ok := s.publicaciones[id]
+	if !ok {
+		return models.Publicacion{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func nuevaPruebaBusqueda() (*Server, *memPublicacionStore) {
+	var pstore = &memPublicacionStore{publicaciones: map[string]models.Publicacion{
+		"faro": {Id: "faro", Titulo: "El faro de Cíes", Resumen: "Una visita", Fecha_publicacion: "2024-01-01 10:00:00", Autor: models.Autor{Nombre: "Ana"}},
+	}}
+	return &Server{store: &Container{publicacion: pstore, busqueda: search.NewMemoriaIndex()}}, pstore
+}
+
+func TestTareaBusquedaGuardaYEliminaDelIndice(t *testing.T) {
+	s, pstore := nuevaPruebaBusqueda()
+
+	if err := s.tareaBusqueda([]byte(`{"publicacion_id":"faro"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if resultados, _ := s.store.busqueda.Buscar("faro"); len(resultados) != 1 {
+		t.Fatalf("la publicación no se añadió al índice: %v", resultados)
+	}
+
+	// Al volver a borrador sale del índice
+	var p = pstore.publicaciones["faro"]
+	p.Fecha_publicacion = ""
+	pstore.publicaciones["faro"] = p
+	if err := s.tareaBusqueda([]byte(`{"publicacion_id":"faro"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if resultados, _ := s.store.busqueda.Buscar("faro"); len(resultados) != 0 {
+		t.Fatalf("el borrador sigue en el índice: %v", resultados)
+	}
+
+	if err := s.tareaBusqueda([]byte(`no es json`)); err == nil {
+		t.Fatal("una carga inválida debe fallar")
+	}
+}
+
+func TestBusquedaPublicaMuestraResultados(t *testing.T) {
+	s, _ := nuevaPruebaBusqueda()
+	if err := s.tareaBusqueda([]byte(`{"publicacion_id":"faro"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	var w = httptest.NewRecorder()
+	s.handlePublicBusqueda()(w, httptest.NewRequest(http.MethodGet, "/buscar?termino=c%C3%ADes", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/post/faro") {
+		t.Fatalf("se esperaba la publicación en los resultados, se obtuvo %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.handlePublicBusqueda()(w, httptest.NewRequest(http.MethodGet, "/buscar?termino=+", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("una búsqueda vacía debe redirigir, se obtuvo %d", w.Code)
+	}
+}
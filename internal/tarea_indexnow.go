@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"vigo360.es/new/internal/seo"
+)
+
+const tareaIndexnow = "indexnow"
+
+type cargaIndexnow struct {
+	Urls []string `json:"urls"`
+}
+
+// Notifica a IndexNow de las URLs afectadas por una publicación
+func (s *Server) tareaIndexnow(carga []byte) error {
+	var c cargaIndexnow
+	if err := json.Unmarshal(carga, &c); err != nil {
+		return fmt.Errorf("%w: %s", errTareaDefinitiva, err.Error())
+	}
+
+	return seo.BingIndexnowRequest(c.Urls)
+}
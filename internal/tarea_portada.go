@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"vigo360.es/new/internal/imagenes"
+)
+
+const tareaPortada = "portada"
+
+type cargaPortada struct {
+	Id string `json:"id"`
+}
+
+/*
+Guarda la portada subida como original y encola la generación de sus versiones, para no hacer esperar al editor
+mientras se codifica. La portada de publicaciones y trabajos se guarda con su id, así que sirve para ambos.
+*/
+func (s *Server) encolarPortada(portada io.Reader, id string, foco imagenes.Foco) error {
+	original, err := io.ReadAll(portada)
+	if err != nil {
+		return err
+	}
+
+	if err := imagenes.GuardarOriginal(os.Getenv("ORIGINALES_PATH"), id, original, foco); err != nil {
+		return err
+	}
+
+	return s.encolarTarea(tareaPortada, cargaPortada{Id: id})
+}
+
+// Genera todas las versiones de la portada a partir de su original
+func (s *Server) tareaPortada(carga []byte) error {
+	var c cargaPortada
+	if err := json.Unmarshal(carga, &c); err != nil {
+		return fmt.Errorf("%w: %s", errTareaDefinitiva, err.Error())
+	}
+
+	var uppath = os.Getenv("UPLOAD_PATH")
+	original, foco, err := imagenes.LeerOriginal(os.Getenv("ORIGINALES_PATH"), uppath, c.Id)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", errTareaDefinitiva, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	err = imagenes.Generar(uppath, c.Id, original, foco)
+	if errors.Is(err, imagenes.ErrFormatoNoValido) {
+		return fmt.Errorf("%w: %s", errTareaDefinitiva, err.Error())
+	}
+	return err
+}
+
+// Lee el punto de foco de la portada de los campos foco-x y foco-y del formulario, en porcentaje
+func focoDesdeFormulario(x string, y string) imagenes.Foco {
+	var foco = imagenes.FocoCentro
+	if v, err := strconv.ParseFloat(x, 64); err == nil && v >= 0 && v <= 100 {
+		foco.X = v / 100
+	}
+	if v, err := strconv.ParseFloat(y, 64); err == nil && v >= 0 && v <= 100 {
+		foco.Y = v / 100
+	}
+	return foco
+}
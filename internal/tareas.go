@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/models"
+)
+
+// Ejecuta una tarea a partir de su carga en JSON
+type manejadorTarea func(carga []byte) error
+
+/*
+Error que indica que reintentar la tarea no servirá de nada, por ejemplo porque los datos no son válidos.
+La tarea pasa directamente a fallida.
+*/
+var errTareaDefinitiva = errors.New("error definitivo")
+
+// Tiempo que se conservan las tareas completadas para poder consultarlas
+const conservarTareasCompletadas = 7 * 24 * time.Hour
+
+func (s *Server) manejadoresTareas() map[string]manejadorTarea {
+	return map[string]manejadorTarea{
+		tareaIndexnow: s.tareaIndexnow,
+		tareaPortada:  s.tareaPortada,
+		tareaBusqueda: s.tareaBusqueda,
+	}
+}
+
+// Guarda una tarea en la cola y avisa a los trabajadores
+func (s *Server) encolarTarea(tipo string, carga interface{}) error {
+	cargaBytes, err := json.Marshal(carga)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.tarea.Encolar(tipo, string(cargaBytes)); err != nil {
+		return err
+	}
+
+	s.despertarTrabajadores()
+	return nil
+}
+
+// Despierta a un trabajador para que ejecute las tareas nuevas sin esperar al siguiente intervalo
+func (s *Server) despertarTrabajadores() {
+	select {
+	case s.tareas <- struct{}{}:
+	default:
+	}
+}
+
+/*
+RunJobWorkers ejecuta las tareas en cola con varios trabajadores en paralelo. Cuando no hay tareas, cada trabajador
+espera al siguiente intervalo o a que se encole una nueva. Bloquea hasta que se cancela el contexto y todos los
+trabajadores han terminado su tarea actual.
+*/
+func (s *Server) RunJobWorkers(ctx context.Context, trabajadores int, interval time.Duration) {
+	log := logger.NewLogger("tareas")
+	log.Information("iniciando %d trabajadores de tareas", trabajadores)
+
+	var manejadores = s.manejadoresTareas()
+	var wg sync.WaitGroup
+	for i := 0; i < trabajadores; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			var log = logger.NewLogger(fmt.Sprintf("tareas-%d", n))
+			s.trabajador(ctx, &log, manejadores, interval)
+		}(i)
+	}
+
+	var purga = time.NewTicker(time.Hour)
+	defer purga.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			log.Information("trabajadores de tareas detenidos")
+			return
+		case <-purga.C:
+			if err := s.store.tarea.PurgarCompletadas(conservarTareasCompletadas); err != nil {
+				log.Error("error purgando tareas completadas: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (s *Server) trabajador(ctx context.Context, log *logger.Logger, manejadores map[string]manejadorTarea, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Ejecuta tareas mientras haya alguna lista
+		for ctx.Err() == nil {
+			tarea, err := s.store.tarea.Reclamar()
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			if err != nil {
+				log.Error("error reclamando tarea: %s", err.Error())
+				break
+			}
+
+			s.ejecutarTarea(log, manejadores, tarea)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.tareas:
+		}
+	}
+}
+
+func (s *Server) ejecutarTarea(log *logger.Logger, manejadores map[string]manejadorTarea, tarea models.Tarea) {
+	var inicio = time.Now()
+	var err error
+
+	if manejador, ok := manejadores[tarea.Tipo]; !ok {
+		err = fmt.Errorf("%w: tipo de tarea desconocido %s", errTareaDefinitiva, tarea.Tipo)
+	} else {
+		err = ejecutarManejador(manejador, []byte(tarea.Carga))
+	}
+
+	if err != nil {
+		var definitivo = errors.Is(err, errTareaDefinitiva)
+		log.Error("error en tarea %d (%s), intento %d de %d: %s", tarea.Id, tarea.Tipo, tarea.Intentos, tarea.Max_intentos, err.Error())
+		if err := s.store.tarea.Fallar(tarea, err.Error(), definitivo); err != nil {
+			log.Error("error registrando fallo de la tarea %d: %s", tarea.Id, err.Error())
+		}
+		return
+	}
+
+	if err := s.store.tarea.Completar(tarea.Id); err != nil {
+		log.Error("error completando tarea %d: %s", tarea.Id, err.Error())
+		return
+	}
+	log.Information("completada tarea %d (%s) en %s", tarea.Id, tarea.Tipo, time.Since(inicio))
+}
+
+// Un pánico en un manejador no debe detener al trabajador, se trata como un error más
+func ejecutarManejador(manejador manejadorTarea, carga []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pánico ejecutando tarea: %v", r)
+		}
+	}()
+	return manejador(carga)
+}
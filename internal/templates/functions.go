@@ -1,13 +1,33 @@
 package templates
 
 import (
-	"bytes"
 	"html/template"
 	"strings"
 	"time"
+
+	"vigo360.es/new/internal/imagenes"
 )
 
+/*
+Funciones con el token CSRF de la petición. Render las añade a una copia de las plantillas en cada petición con sesión;
+al analizarlas se registran con el token vacío.
+*/
+func funcionesCsrf(token string) template.FuncMap {
+	return template.FuncMap{
+		// Campo oculto con el token CSRF, para todos los formularios POST del panel: {{ csrf }}
+		"csrf": func() template.HTML {
+			return template.HTML(`<input type="hidden" name="csrf" value="` + template.HTMLEscapeString(token) + `">`)
+		},
+		// Token CSRF sin más, para las peticiones que se hacen desde JavaScript
+		"csrfToken": func() string {
+			return token
+		},
+	}
+}
+
 var Functions = template.FuncMap{
+	// Portada de una publicación o trabajo con todas sus versiones: {{ picture id alt sizes lazy }}
+	"picture": imagenes.Picture,
 	"safeHTML": func(text string) template.HTML {
 		return template.HTML(text)
 	},
@@ -27,12 +47,11 @@ var Functions = template.FuncMap{
 		return t.Format("02/01/2006"), nil
 	},
 	"markdown": func(text string) (template.HTML, error) {
-		var buf bytes.Buffer
-		err := parser.Convert([]byte(text), &buf)
+		html, err := Markdown(text)
 		if err != nil {
 			return template.HTML(""), err
 		}
-		return template.HTML(buf.Bytes()), nil
+		return template.HTML(html), nil
 	},
 	"split": func(text string, separator string) []string {
 		return strings.Split(text, separator)
@@ -57,6 +76,14 @@ var Functions = template.FuncMap{
 		}
 		return tm.Format(format)
 	},
+	// Formats a date returned by MySQL (in UTC) in the server's local time zone
+	"date_local": func(sqldate string, format string) string {
+		tm, err := time.Parse("2006-01-02 15:04:05", sqldate)
+		if err != nil {
+			return sqldate
+		}
+		return tm.In(time.Local).Format(format)
+	},
 	"safeURL": func(url string) template.URL {
 		return template.URL(url)
 	},
@@ -1,6 +1,8 @@
 package templates
 
 import (
+	"bytes"
+
 	gmf "github.com/arielcostas/goldmark-figures"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/extension"
@@ -13,3 +15,12 @@ var parser goldmark.Markdown = goldmark.New(
 	goldmark.WithExtensions(extension.Strikethrough),
 	goldmark.WithExtensions(gmf.Extension),
 )
+
+// Convierte Markdown a HTML con las mismas extensiones que se usan en las plantillas
+func Markdown(text string) (string, error) {
+	var buf bytes.Buffer
+	if err := parser.Convert([]byte(text), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -7,13 +7,18 @@ import (
 	"github.com/Masterminds/sprig/v3"
 	"html/template"
 	"io"
+	"net/http"
 	"os"
+	"time"
+
+	"vigo360.es/new/internal/metricas"
 )
 
 //go:embed html/*
 var rawtemplates embed.FS
 
-var t = func() *template.Template {
+// Plantillas sin ejecutar. Solo se clonan, porque html/template no permite clonar una plantilla ya ejecutada
+var base = func() *template.Template {
 	t := template.New("")
 
 	entries, _ := rawtemplates.ReadDir("html")
@@ -21,7 +26,7 @@ var t = func() *template.Template {
 		filename := de.Name()
 		contents, _ := rawtemplates.ReadFile("html/" + filename)
 
-		_, err := t.New(filename).Funcs(Functions).Funcs(sprig.FuncMap()).Parse(string(contents))
+		_, err := t.New(filename).Funcs(Functions).Funcs(funcionesCsrf("")).Funcs(sprig.FuncMap()).Parse(string(contents))
 		if err != nil {
 			fmt.Printf("error parsing template: %s", err.Error())
 			os.Exit(1)
@@ -31,16 +36,53 @@ var t = func() *template.Template {
 	return t
 }()
 
+// Plantillas para las páginas sin token CSRF, que se ejecutan directamente
+var t = template.Must(base.Clone())
+
+// Los ResponseWriter de peticiones con sesión lo implementan para que las páginas lleven el token CSRF en sus formularios
+type ConTokenCsrf interface {
+	TokenCsrf() string
+}
+
+// Busca el token CSRF en el io.Writer o en los ResponseWriter que envuelve, si los hay
+func tokenCsrf(w io.Writer) (string, bool) {
+	for {
+		if tw, ok := w.(ConTokenCsrf); ok {
+			return tw.TokenCsrf(), true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return "", false
+		}
+		w = u.Unwrap()
+	}
+}
+
 /*
 Render ejecuta una plantilla con los datos proveídos, llamando por debajo a ExecuteTemplate.
 Si hay un error al ejecutar la plantilla, no escribe nada al io.Writer y devuelve el error, con lo que es seguro no tener una página escrita a medias.
+Si el io.Writer lleva un token CSRF, la plantilla se ejecuta sobre una copia en la que csrf y csrfToken lo devuelven. Si
+no, quedan vacíos.
 */
 func Render(w io.Writer, name string, data any) error {
 	var output bytes.Buffer
-	err := t.ExecuteTemplate(&output, name, data)
+	var inicio = time.Now()
+
+	var plantillas = t
+	if token, ok := tokenCsrf(w); ok {
+		clon, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		plantillas = clon.Funcs(funcionesCsrf(token))
+	}
+
+	err := plantillas.ExecuteTemplate(&output, name, data)
 	if err != nil {
 		return err
 	}
+	metricas.DuracionPlantillas.Observar(time.Since(inicio).Seconds(), name)
+
 	w.Write(output.Bytes())
 	return nil
 }
@@ -0,0 +1,70 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type escritorConToken struct {
+	bytes.Buffer
+	token string
+}
+
+func (e *escritorConToken) TokenCsrf() string {
+	return e.token
+}
+
+// Plantilla de prueba con un formulario y contenido escrito por usuarios
+func init() {
+	const prueba = `<form>{{ csrf }}</form><meta content="{{ csrfToken }}"><p>{{ .Contenido }}</p>`
+	for _, ts := range []*template.Template{base, t} {
+		template.Must(ts.New("prueba-csrf.html").Parse(prueba))
+	}
+}
+
+func TestRenderNoModificaContenidoConLaMarca(t *testing.T) {
+	var datos = struct{ Contenido string }{"hay que escribir __CSRF_TOKEN__ en el formulario"}
+
+	var conToken = &escritorConToken{token: "abc123"}
+	if err := Render(conToken, "prueba-csrf.html", datos); err != nil {
+		t.Fatal(err)
+	}
+	var html = conToken.String()
+	if !strings.Contains(html, `name="csrf" value="abc123"`) || !strings.Contains(html, `content="abc123"`) {
+		t.Fatalf("falta el token en el formulario: %s", html)
+	}
+	if !strings.Contains(html, "escribir __CSRF_TOKEN__ en") {
+		t.Fatalf("el contenido se modificó: %s", html)
+	}
+
+	var sinToken bytes.Buffer
+	if err := Render(&sinToken, "prueba-csrf.html", datos); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sinToken.String(), `value=""`) || !strings.Contains(sinToken.String(), "escribir __CSRF_TOKEN__ en") {
+		t.Fatalf("sin sesión el token debe quedar vacío y el contenido intacto: %s", sinToken.String())
+	}
+}
+
+func TestRenderConcurrenteUsaCadaToken(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var w = &escritorConToken{token: fmt.Sprintf("token-%d", i)}
+			if err := Render(w, "prueba-csrf.html", struct{ Contenido string }{}); err != nil {
+				t.Error(err)
+				return
+			}
+			if !strings.Contains(w.String(), `value="`+w.token+`"`) {
+				t.Errorf("la página de %s lleva otro token: %s", w.token, w.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+}
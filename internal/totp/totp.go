@@ -0,0 +1,93 @@
+/*
+Package totp implementa contraseñas de un solo uso basadas en tiempo (RFC 6238) con los parámetros que entienden
+todas las aplicaciones de autenticación: HMAC-SHA1, 6 dígitos y pasos de 30 segundos.
+*/
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	periodo = 30
+	digitos = 6
+	// Pasos de diferencia que se aceptan, para tolerar relojes algo desajustados
+	margen = 1
+)
+
+var codificacion = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Genera un secreto aleatorio de 160 bits, codificado en base32
+func GenerarSecreto() (string, error) {
+	var b = make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return codificacion.EncodeToString(b), nil
+}
+
+// URI otpauth:// para dar de alta el secreto en una aplicación, ya sea desde un QR o abriéndola directamente
+func Uri(emisor string, cuenta string, secreto string) string {
+	var q = url.Values{}
+	q.Set("secret", secreto)
+	q.Set("issuer", emisor)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(digitos))
+	q.Set("period", fmt.Sprint(periodo))
+	return "otpauth://totp/" + url.PathEscape(emisor+":"+cuenta) + "?" + q.Encode()
+}
+
+// Paso de tiempo al que pertenece un instante
+func Paso(t time.Time) int64 {
+	return t.Unix() / periodo
+}
+
+// Código de un paso concreto
+func Codigo(secreto string, paso int64) (string, error) {
+	clave, err := codificacion.DecodeString(strings.ToUpper(secreto))
+	if err != nil {
+		return "", err
+	}
+
+	var mensaje [8]byte
+	binary.BigEndian.PutUint64(mensaje[:], uint64(paso))
+	var mac = hmac.New(sha1.New, clave)
+	mac.Write(mensaje[:])
+	var suma = mac.Sum(nil)
+
+	var desplazamiento = suma[len(suma)-1] & 0x0f
+	var valor = binary.BigEndian.Uint32(suma[desplazamiento:desplazamiento+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", digitos, valor%1000000), nil
+}
+
+/*
+Validar comprueba un código contra el paso del instante indicado y los contiguos. Devuelve el paso que coincide, para
+que quien llama pueda rechazar códigos ya usados.
+*/
+func Validar(secreto string, codigo string, t time.Time) (int64, bool) {
+	codigo = strings.ReplaceAll(codigo, " ", "")
+	if len(codigo) != digitos {
+		return 0, false
+	}
+
+	var actual = Paso(t)
+	for paso := actual - margen; paso <= actual+margen; paso++ {
+		esperado, err := Codigo(secreto, paso)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(esperado), []byte(codigo)) == 1 {
+			return paso, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,104 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secreto de los vectores de prueba de SHA-1 del RFC 6238, "12345678901234567890" en base32
+const secretoRfc = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodigoVectoresRfc6238(t *testing.T) {
+	// El RFC da códigos de 8 dígitos, y los de 6 son sus últimas cifras
+	var vectores = []struct {
+		unix   int64
+		codigo string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectores {
+		codigo, err := Codigo(secretoRfc, Paso(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codigo != v.codigo {
+			t.Errorf("en %d se esperaba %s, se obtuvo %s", v.unix, v.codigo, codigo)
+		}
+	}
+
+	// Las aplicaciones pueden guardar el secreto en minúsculas
+	if codigo, _ := Codigo(strings.ToLower(secretoRfc), Paso(time.Unix(59, 0))); codigo != "287082" {
+		t.Errorf("el secreto en minúsculas da %s", codigo)
+	}
+}
+
+func TestValidarMargen(t *testing.T) {
+	var ahora = time.Unix(1111111111, 0)
+	var actual = Paso(ahora)
+
+	for _, diferencia := range []int64{-1, 0, 1} {
+		codigo, _ := Codigo(secretoRfc, actual+diferencia)
+		paso, ok := Validar(secretoRfc, codigo, ahora)
+		if !ok || paso != actual+diferencia {
+			t.Errorf("el código del paso %+d debería aceptarse como ese paso, se obtuvo %d, %t", diferencia, paso-actual, ok)
+		}
+	}
+	for _, diferencia := range []int64{-2, 2} {
+		codigo, _ := Codigo(secretoRfc, actual+diferencia)
+		if _, ok := Validar(secretoRfc, codigo, ahora); ok {
+			t.Errorf("el código del paso %+d no debería aceptarse", diferencia)
+		}
+	}
+}
+
+func TestValidarFormato(t *testing.T) {
+	var ahora = time.Unix(1111111111, 0)
+
+	if _, ok := Validar(secretoRfc, "050 471", ahora); !ok {
+		t.Error("los espacios dentro del código se deberían ignorar")
+	}
+	for _, codigo := range []string{"", "05047", "0504711", "abcdef"} {
+		if _, ok := Validar(secretoRfc, codigo, ahora); ok {
+			t.Errorf("%q no debería aceptarse", codigo)
+		}
+	}
+	if _, ok := Validar("no es base32!", "050471", ahora); ok {
+		t.Error("un secreto mal codificado no debería validar nada")
+	}
+}
+
+func TestGenerarSecreto(t *testing.T) {
+	a, err := GenerarSecreto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerarSecreto()
+	// 160 bits en base32 sin relleno son 32 caracteres
+	if len(a) != 32 || a == b {
+		t.Fatalf("secretos inesperados: %q y %q", a, b)
+	}
+	if _, err := Codigo(a, 1); err != nil {
+		t.Fatalf("el secreto generado no se puede usar: %s", err.Error())
+	}
+}
+
+func TestUri(t *testing.T) {
+	u, err := url.Parse(Uri("Vigo360", "ana", secretoRfc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Vigo360:ana" {
+		t.Errorf("URI inesperada: %s", u)
+	}
+	var q = u.Query()
+	if q.Get("secret") != secretoRfc || q.Get("issuer") != "Vigo360" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("parámetros inesperados: %v", q)
+	}
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"vigo360.es/new/internal"
 	"vigo360.es/new/internal/database"
+	"vigo360.es/new/internal/imagenes"
+	"vigo360.es/new/internal/logger"
+	"vigo360.es/new/internal/systemd"
 )
 
 var (
@@ -20,29 +30,172 @@ func main() {
 		os.Exit(1)
 	}
 
+	log := logger.NewLogger("main")
+	if err := database.Conectar(); err != nil {
+		// Conectar ya lo ha escrito en el log
+		os.Exit(1)
+	}
+
+	// Comandos de mantenimiento, que terminan sin iniciar el servidor
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "regenerar-portadas":
+			err = internal.RegenerarPortadas(internal.NewMysqlContainer(database.GetDB()))
+		case "importar-media":
+			err = internal.ImportarMedia(internal.NewMysqlContainer(database.GetDB()))
+		case "exportar-markdown", "importar-markdown":
+			if len(os.Args) < 3 {
+				err = fmt.Errorf("uso: %s %s <directorio>", os.Args[0], os.Args[1])
+			} else if os.Args[1] == "exportar-markdown" {
+				err = internal.ExportarMarkdown(internal.NewMysqlContainer(database.GetDB()), os.Args[2])
+			} else {
+				err = internal.ImportarMarkdown(internal.NewMysqlContainer(database.GetDB()), os.Args[2])
+			}
+		default:
+			err = fmt.Errorf("comando desconocido: %s", os.Args[1])
+		}
+		if err != nil {
+			log.Error("%s", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := run(); err != nil {
-		fmt.Printf("<3>%s\n", err)
+		log.Error("%s", err.Error())
 		os.Exit(1)
 	}
 }
 
+const (
+	// Tiempo que se espera a que terminen las peticiones en curso al apagar
+	esperaApagado = 30 * time.Second
+)
+
+// Servidor HTTP con límites de tiempo, para que una conexión lenta o abandonada no quede abierta indefinidamente
+func nuevoServidor(direccion string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              direccion,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		// Las importaciones de Markdown, de hasta 50MB, necesitan margen con conexiones lentas. nginx solo admite ese
+		// tamaño en el bloque location = /admin/markdown/importar de deploy/config/nginx.conf
+		ReadTimeout:  2 * time.Minute,
+		WriteTimeout: 2 * time.Minute,
+		IdleTimeout:  2 * time.Minute,
+	}
+}
+
+/*
+Sirve la web hasta recibir SIGTERM o SIGINT. Entonces deja de aceptar conexiones, espera a las peticiones en curso y a
+las tareas, guarda lo que quede del registro de peticiones y cierra la base de datos, en ese orden.
+*/
 func run() error {
-	fmt.Printf("<6>iniciando vigo360 versión %s\n", version)
+	log := logger.NewLogger("main")
+	log.Information("iniciando vigo360 versión %s", version)
 	var PORT string = ":" + os.Getenv("PORT")
 
+	ctx, parar := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer parar()
+
 	var db = database.GetDB()
 	var container = internal.NewMysqlContainer(db)
 
+	internal.RegistrarMetricas(db, version)
 	var s = internal.NewServer(container)
 
-	fmt.Printf("<6>iniciando servidor web en %s\n", PORT)
-	http.Handle("/", s.Router)
+	var web = nuevoServidor(PORT, s.Router)
+	ln, err := net.Listen("tcp", PORT)
+	if err != nil {
+		return err
+	}
+
+	ctxTareas, pararTareas := context.WithCancel(context.Background())
+	var tareas sync.WaitGroup
+	tareas.Add(2)
+	go func() {
+		defer tareas.Done()
+		s.RunPublishScheduler(ctxTareas, time.Minute)
+	}()
+	go func() {
+		defer tareas.Done()
+		s.RunJobWorkers(ctxTareas, 2, time.Minute)
+	}()
+
+	// El registro se detiene el último, para guardar también las peticiones que terminen durante el apagado
+	ctxRegistro, pararRegistro := context.WithCancel(context.Background())
+	var registroDetenido = make(chan struct{})
+	go func() {
+		defer close(registroDetenido)
+		s.RunRequestLogWriter(ctxRegistro)
+	}()
 
-	var err = http.ListenAndServe(PORT, nil)
+	var metricas *http.Server
+	if direccion := os.Getenv("METRICAS_DIRECCION"); direccion != "" {
+		metricas = nuevoServidor(direccion, s.MetricsHandler())
+		go func() {
+			log.Information("sirviendo métricas en %s", direccion)
+			if err := metricas.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("error sirviendo métricas: %s", err.Error())
+			}
+		}()
+	}
+
+	log.Information("iniciando servidor web en %s", PORT)
+	var errServidor = make(chan error, 1)
+	go func() {
+		errServidor <- web.Serve(ln)
+	}()
+
+	if ok, err := systemd.Notificar(systemd.Listo); err != nil {
+		log.Warning("error avisando a systemd de que el servidor está listo: %s", err.Error())
+	} else if ok {
+		log.Debug("systemd avisado de que el servidor está listo")
+	}
+
+	select {
+	case <-ctx.Done():
+		log.Information("señal recibida, apagando el servidor")
+	case err = <-errServidor:
+		log.Error("error sirviendo la web, apagando el servidor: %s", err.Error())
+	}
+	// Una segunda señal termina el proceso sin esperar
+	parar()
+
+	if _, err := systemd.Notificar(systemd.Deteniendo); err != nil {
+		log.Warning("error avisando a systemd del apagado: %s", err.Error())
+	}
+
+	ctxApagado, cancelar := context.WithTimeout(context.Background(), esperaApagado)
+	defer cancelar()
+	for _, srv := range []*http.Server{web, metricas} {
+		if srv == nil {
+			continue
+		}
+		if err := srv.Shutdown(ctxApagado); err != nil {
+			log.Warning("no terminaron a tiempo todas las peticiones de %s, se cortan: %s", srv.Addr, err.Error())
+			srv.Close()
+		}
+	}
+
+	pararTareas()
+	tareas.Wait()
+	pararRegistro()
+	<-registroDetenido
+
+	if err := database.Cerrar(); err != nil {
+		log.Warning("error cerrando la base de datos: %s", err.Error())
+	}
+	log.Information("servidor detenido")
 	return err
 }
 
 func checkEnv() error {
+	if err := logger.Configurar(os.Stdout, os.Getenv("LOG_FORMATO"), os.Getenv("LOG_NIVEL")); err != nil {
+		return err
+	}
+
 	if val, is := os.LookupEnv("PORT"); !is || val == "" {
 		return fmt.Errorf("es necesario especificar PORT")
 	} else {
@@ -55,29 +208,77 @@ func checkEnv() error {
 		}
 	}
 
-	if val, is := os.LookupEnv("UPLOAD_PATH"); !is || val == "" {
-		return fmt.Errorf("es necesario especificar UPLOAD_PATH")
-	} else {
-		info, err := os.Stat(val)
-		if err != nil {
-			return fmt.Errorf("error comprobando validez de UPLOAD_PATH: %s", err.Error())
-		}
-		if !info.IsDir() {
-			return fmt.Errorf("UPLOAD_PATH tiene que ser un directorio: %s", err.Error())
-		}
-		err = os.WriteFile(val+"/.test", []byte{0x00}, os.ModePerm)
-		if err != nil {
-			return fmt.Errorf("no se puede escribir a UPLOAD_PATH: %s", err.Error())
-		}
-		err = os.Remove(val + "/.test")
-		if err != nil {
-			return fmt.Errorf("no se puede escribir a UPLOAD_PATH: %s", err.Error())
-		}
+	if err := comprobarDirectorio("UPLOAD_PATH"); err != nil {
+		return err
+	}
+	if err := comprobarDirectorio("ORIGINALES_PATH"); err != nil {
+		return err
+	}
+	if err := imagenes.ComprobarOriginales(os.Getenv("ORIGINALES_PATH"), os.Getenv("UPLOAD_PATH")); err != nil {
+		return fmt.Errorf("ORIGINALES_PATH no es válido: %w", err)
+	}
+	if err := comprobarDirectorio("ADJUNTOS_PATH"); err != nil {
+		return err
+	}
+	// Igual que con los originales, nginx no debe servir los adjuntos sin comprobar que el trabajo está publicado
+	if err := imagenes.ComprobarOriginales(os.Getenv("ADJUNTOS_PATH"), os.Getenv("UPLOAD_PATH")); errors.Is(err, imagenes.ErrOriginalesPublicos) {
+		return fmt.Errorf("ADJUNTOS_PATH no puede estar dentro de UPLOAD_PATH, que se sirve en /static")
+	} else if err != nil {
+		return fmt.Errorf("ADJUNTOS_PATH no es válido: %w", err)
+	}
+
+	if val := os.Getenv("METRICAS_TOKEN"); val != "" && len(val) < 16 {
+		return fmt.Errorf("METRICAS_TOKEN debe tener al menos 16 caracteres")
 	}
 
 	if val, is := os.LookupEnv("DOMAIN"); !is || val == "" {
 		return fmt.Errorf("es necesario especificar DOMAIN")
 	}
 
+	if _, err := internal.ProxiesConfiablesDesdeEnv(); err != nil {
+		return err
+	}
+
+	if config, err := imagenes.ConfiguracionDesdeEnv(); err != nil {
+		return err
+	} else {
+		imagenes.Configurar(config)
+	}
+
+	switch val := os.Getenv("SEARCH_BACKEND"); val {
+	case "", "mysql", "memoria":
+	case "algolia":
+		if os.Getenv("ALGOLIA_APPLICATION") == "" || os.Getenv("ALGOLIA_INDEX") == "" {
+			return fmt.Errorf("SEARCH_BACKEND=algolia necesita ALGOLIA_APPLICATION y ALGOLIA_INDEX")
+		}
+	default:
+		return fmt.Errorf("SEARCH_BACKEND debe ser algolia, mysql o memoria, no %s", val)
+	}
+
+	return nil
+}
+
+// Comprueba que la variable indica un directorio existente en el que se puede escribir
+func comprobarDirectorio(variable string) error {
+	var val = os.Getenv(variable)
+	if val == "" {
+		return fmt.Errorf("es necesario especificar %s", variable)
+	}
+
+	info, err := os.Stat(val)
+	if err != nil {
+		return fmt.Errorf("error comprobando validez de %s: %s", variable, err.Error())
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s tiene que ser un directorio", variable)
+	}
+	err = os.WriteFile(val+"/.test", []byte{0x00}, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("no se puede escribir a %s: %s", variable, err.Error())
+	}
+	err = os.Remove(val + "/.test")
+	if err != nil {
+		return fmt.Errorf("no se puede escribir a %s: %s", variable, err.Error())
+	}
 	return nil
 }
@@ -0,0 +1,16 @@
+// Añade el token CSRF de la página a las peticiones del panel que modifican datos
+(function () {
+	const meta = document.querySelector('meta[name="csrf-token"]')
+	const token = meta ? meta.content : ""
+	const fetchOriginal = window.fetch
+
+	window.fetch = function (recurso, opciones = {}) {
+		const metodo = (opciones.method || "GET").toUpperCase()
+		if (token !== "" && !["GET", "HEAD", "OPTIONS"].includes(metodo)) {
+			const cabeceras = new Headers(opciones.headers || {})
+			cabeceras.set("X-CSRF-Token", token)
+			opciones = { ...opciones, headers: cabeceras }
+		}
+		return fetchOriginal(recurso, opciones)
+	}
+})()
@@ -3,7 +3,7 @@ let form = document.getElementById("attachment-upload")
 let imageStatus = document.getElementById("attachment-status")
 
 /**
- * @typedef {{id: int, title: string, filename: string}} Attachment
+ * @typedef {{id: int, titulo: string, nombre_archivo: string, bytes: int, mime: string, sha256: string}} Attachment
  * @returns {Promise<Attachment[]>}
  */
 async function listAttachments() {
@@ -58,8 +58,8 @@ function loadAttachments() {
             let li = document.createElement("li")
             li.setAttribute("class", "attachment")
             li.innerHTML = `
-                <a onclick="deleteAttachment(${i.id})"><b>Borrar</b></a> &mdash; ${i.title}
-                (<a href="/static/papers/${i.filename}" target="_blank">${i.filename}</a>)`
+                <a onclick="deleteAttachment(${i.id})"><b>Borrar</b></a> &mdash; ${i.titulo}
+                (<a href="/admin/works/${workId}/adjuntos/${i.nombre_archivo}" target="_blank">${i.nombre_archivo}</a>, ${i.mime}, ${Math.ceil(i.bytes / 1024)} KB)`
             attachmentList.appendChild(li)
         })
     })
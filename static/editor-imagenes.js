@@ -1,9 +1,21 @@
 let artId = window.location.pathname.split("/")[3]
 let form = document.getElementById("extra-image-upload")
 let imageStatus = document.getElementById("image-status")
+let soloArticulo = document.getElementById("media-solo-articulo")
+
+function mostrarEstado(texto) {
+	imageStatus.innerText = texto
+	setTimeout(() => {
+		imageStatus.innerText = ""
+	}, 2000)
+}
 
 async function listarImagenes() {
-	let resp = await fetch('/admin/async/fotosExtra?articulo='+artId)
+	let url = '/admin/async/media'
+	if (soloArticulo.checked) {
+		url += '?articulo=' + encodeURIComponent(artId)
+	}
+	let resp = await fetch(url)
 	let body = await resp.json()
 
 	let imagenes = []
@@ -13,19 +25,46 @@ async function listarImagenes() {
 	return imagenes
 }
 
-function crearFigura(i) {
+function markdownImagen(m) {
+	let url = `/static/extra/${m.nombre_archivo}`
+	if (m.leyenda != "") {
+		return `![${m.alt}](${url} "${m.leyenda.replaceAll('"', '\\"')}")`
+	}
+	return `![${m.alt}](${url})`
+}
+
+function crearCampo(etiqueta, valor) {
+	let label = document.createElement("label")
+	label.innerText = etiqueta
+	let input = document.createElement("input")
+	input.setAttribute("type", "text")
+	input.setAttribute("maxlength", "300")
+	input.value = valor
+	label.appendChild(input)
+	return [label, input]
+}
+
+function crearFigura(m) {
 	let figura = document.createElement("div")
 
 	let img = document.createElement("img")
-	img.setAttribute("src", "/static/extra/" + i)
-	img.setAttribute("title", i)
-	img.setAttribute("alt", i)
+	img.setAttribute("src", "/static/extra/" + m.nombre_archivo)
+	img.setAttribute("title", `${m.nombre_archivo} (${m.ancho}x${m.alto})`)
+	img.setAttribute("alt", m.alt)
 	img.addEventListener("click", _ => {
-		navigator.clipboard.writeText(`![Texto Alternativo](/static/extra/${i})`)
-		imageStatus.innerText = `Imagen copiada al cortapapeles`
-		setTimeout(() => {
-			imageStatus.innerText = ""
-		}, 2000)
+		navigator.clipboard.writeText(markdownImagen(m))
+		mostrarEstado(`Imagen copiada al cortapapeles`)
+	})
+
+	let [altLabel, altInput] = crearCampo("Texto alternativo", m.alt)
+	let [leyendaLabel, leyendaInput] = crearCampo("Leyenda", m.leyenda)
+
+	let saveButton = document.createElement("button")
+	saveButton.setAttribute("class", "button button-primary")
+	saveButton.innerText = "Guardar"
+	saveButton.addEventListener("click", e => {
+		e.preventDefault()
+		editarFotografia(m.id, altInput.value, leyendaInput.value)
 	})
 
 	let deleteButton = document.createElement("button")
@@ -33,28 +72,49 @@ function crearFigura(i) {
 	deleteButton.innerText = "Eliminar"
 	deleteButton.addEventListener("click", e => {
 		e.preventDefault()
-		if (window.confirm(`¿Seguro que desea eliminar ${i}? Esta acción es irreversible`)) {
-			eliminarFotografia(i)
+		if (window.confirm(`¿Seguro que desea eliminar ${m.nombre_archivo}? Esta acción es irreversible`)) {
+			eliminarFotografia(m.id)
 		}
 	})
 
 	figura.appendChild(img)
+	figura.appendChild(altLabel)
+	figura.appendChild(leyendaLabel)
+	figura.appendChild(saveButton)
 	figura.appendChild(deleteButton)
 	return figura
 }
 
-async function eliminarFotografia(n) {
-	let resp = await fetch("/admin/async/fotosExtra?foto=" + n, {
+async function editarFotografia(id, alt, leyenda) {
+	let fd = new URLSearchParams()
+	fd.append("alt", alt)
+	fd.append("leyenda", leyenda)
+
+	let resp = await fetch("/admin/async/media/" + id, {
+		method: "PUT",
+		body: fd
+	})
+	if (resp.status == 200) {
+		cargarImagenes()
+		mostrarEstado(`Imagen actualizada`)
+	} else {
+		let body = await resp.json()
+		imageStatus.innerText = body["error"]
+	}
+}
+
+async function eliminarFotografia(id) {
+	let resp = await fetch("/admin/async/media/" + id, {
 		method: "DELETE"
 	})
 	let body = await resp.json()
 
 	if (resp.status == 200) {
 		cargarImagenes()
-		imageStatus.innerText = `Imagen borrada`
-		setTimeout(() => {
-			imageStatus.innerText = ""
-		}, 2000)
+		mostrarEstado(`Imagen borrada`)
+	} else if (resp.status == 409) {
+		let usos = body["usos"].map(u => `${u.titulo} (${u.id})`).join(", ")
+		imageStatus.innerText = `${body["error"]} Se usa en: ${usos}`
 	} else {
 		imageStatus.innerText = body["error"]
 	}
@@ -64,13 +124,13 @@ function cargarImagenes() {
 	let imageList = document.getElementById("editor-image-grid")
 	imageStatus.innerHTML = "<b>Cargando datos...</b>"
 	listarImagenes().then((img) => {
+		imageList.innerHTML = ""
 		if (img.length < 1) {
 			imageStatus.innerHTML = "<b>Ninguna imágen adicional</b>"
 		} else {
 			imageStatus.innerHTML = ""
-			imageList.innerHTML = ""
-			img.forEach(i => {
-				let figure = crearFigura(i)
+			img.forEach(m => {
+				let figure = crearFigura(m)
 				imageList.appendChild(figure)
 			})
 		}
@@ -78,32 +138,33 @@ function cargarImagenes() {
 }
 
 cargarImagenes()
+soloArticulo.addEventListener("change", cargarImagenes)
 
 form.addEventListener("submit", async (e) => {
 	e.preventDefault()
-	archivo = document.getElementById("archivo")
-	fd = new FormData()
+	let archivo = document.getElementById("archivo")
+	let fd = new FormData()
 	fd.append("foto", archivo.files[0])
 	fd.append("articulo", artId)
+	fd.append("alt", document.getElementById("media-alt").value)
+	fd.append("leyenda", document.getElementById("media-leyenda").value)
 
 	if (archivo.files[0].size > 20971520) {
 		imageStatus.innerText = `La imagen seleccionada es demasiado pesada`
 		return
 	}
-	
-	resp = await fetch('/admin/async/fotosExtra', {
+
+	let resp = await fetch('/admin/async/media', {
 		method: "POST",
 		body: fd
 	})
-	if (resp.status !== 200) {
-		body = await resp.json()
+	if (resp.status !== 201) {
+		let body = await resp.json()
 		imageStatus.innerText = body["error"]
 	} else {
-		imageStatus.innerText = `Imagen guardada exitosamente`
-		setTimeout(() => {
-			imageStatus.innerText = ""
-		}, 2000)
+		form.reset()
+		mostrarEstado(`Imagen guardada exitosamente`)
 	}
 
 	cargarImagenes()
-})
\ No newline at end of file
+})